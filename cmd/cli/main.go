@@ -1,17 +1,22 @@
 package main
 
 import (
-	"fmt"
+	"log/slog"
+	"os"
+	"runtime/debug"
+
 	"trading-bot/internal/interfaces/cli"
 )
 
 // main is the entrypoint for the CLI application.
 func main() {
 
-	// Defer a function to recover from a panic and handle errors gracefully.
+	// Log a panic with its stack, through the redacting logger, and exit
+	// with a failure status rather than reporting success.
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Println("An error occurred:", r)
+			slog.Error("panic", "err", r, "args", os.Args[1:], "stack", string(debug.Stack()))
+			os.Exit(2)
 		}
 	}()
 
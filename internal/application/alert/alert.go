@@ -0,0 +1,183 @@
+// Package alert turns trading signals from charting tools into orders.
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Action is what an alert asks for.
+type Action string
+
+const (
+	Buy    Action = "buy"
+	Sell   Action = "sell"
+	Cancel Action = "cancel" // one order by ID, or every active order of the market
+)
+
+// Alert is a signal as posted by a charting tool. Size and Price may be
+// JSON numbers or strings, since the tools substitute their placeholders
+// into the message text.
+type Alert struct {
+	ID        string `json:"id"` // optional; repeated IDs are dropped as duplicates
+	Secret    string `json:"secret,omitempty"`
+	Timestamp Number `json:"timestamp"` // when it was sent, see Time
+	Action    Action `json:"action"`
+	Market    string `json:"market"`
+	Size      Number `json:"size"`     // base quantity; buy and sell only
+	Price     string `json:"price"`    // a price template, see Price; empty means market
+	OrderID   string `json:"order_id"` // cancel only; empty cancels all of Market
+}
+
+// ParseTime parses a timestamp given as RFC 3339, e.g. TradingView's
+// {{timenow}}, or as Unix seconds or milliseconds.
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, errors.New("alert: timestamp is required")
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n > 1e12 {
+			return time.UnixMilli(n), nil
+		}
+		return time.Unix(n, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("alert: invalid timestamp %q", s)
+	}
+	return t, nil
+}
+
+// Time returns when the alert was sent.
+func (a Alert) Time() (time.Time, error) {
+	return ParseTime(string(a.Timestamp))
+}
+
+// Number is a decimal given as a JSON number or string.
+type Number string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *Number) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*n = Number(strings.TrimSpace(s))
+		return nil
+	}
+	*n = Number(b)
+	return nil
+}
+
+// Float parses n.
+func (n Number) Float() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// Parse decodes an alert, rejecting unknown fields so a typo in a template
+// does not silently change the order.
+func Parse(data []byte) (Alert, error) {
+	var a Alert
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&a); err != nil {
+		return a, fmt.Errorf("alert: malformed JSON: %w", err)
+	}
+	a.Action = Action(strings.ToLower(string(a.Action)))
+	a.Price = strings.TrimSpace(a.Price)
+	return a, nil
+}
+
+// Validate checks the fields the action needs.
+func (a Alert) Validate() error {
+	var errs []error
+	if a.Market == "" {
+		errs = append(errs, errors.New("alert: market is required"))
+	}
+	switch a.Action {
+	case Buy, Sell:
+		if f, err := a.Size.Float(); err != nil || f <= 0 {
+			errs = append(errs, fmt.Errorf("alert: size must be a positive number, got %q", a.Size))
+		}
+		if _, err := ParsePrice(a.Price); err != nil {
+			errs = append(errs, err)
+		}
+	case Cancel:
+	default:
+		errs = append(errs, fmt.Errorf("alert: unknown action %q, use buy, sell or cancel", a.Action))
+	}
+	return errors.Join(errs...)
+}
+
+// Price is a parsed price template:
+//
+//	""  or "market"          a market order
+//	"151000.5"               a limit order at that price
+//	"{{bid}}", "{{ask}}",    a limit order at the current bid, ask, mid or
+//	"{{mid}}", "{{last}}"    last price of the market,
+//	"{{bid}}-0.1%",          optionally offset by a percentage
+//	"{{ask}}+25"             or by an absolute amount
+type Price struct {
+	Market  bool
+	Fixed   float64
+	Ref     string // bid, ask, mid or last
+	Offset  float64
+	Percent bool
+}
+
+var template = regexp.MustCompile(`^\{\{\s*(bid|ask|mid|last)\s*\}\}\s*(?:([+-])\s*([0-9]*\.?[0-9]+)\s*(%?))?$`)
+
+// ParsePrice parses a price template.
+func ParsePrice(s string) (Price, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "market") {
+		return Price{Market: true}, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		if f <= 0 {
+			return Price{}, fmt.Errorf("alert: price must be positive, got %s", s)
+		}
+		return Price{Fixed: f}, nil
+	}
+	m := template.FindStringSubmatch(strings.ToLower(s))
+	if m == nil {
+		return Price{}, fmt.Errorf("alert: invalid price template %q", s)
+	}
+	p := Price{Ref: m[1], Percent: m[4] == "%"}
+	if m[3] != "" {
+		p.Offset, _ = strconv.ParseFloat(m[3], 64)
+		if m[2] == "-" {
+			p.Offset = -p.Offset
+		}
+	}
+	return p, nil
+}
+
+// Resolve returns the limit price given the reference prices of the
+// market, keyed bid, ask, mid and last.
+func (p Price) Resolve(refs map[string]float64) (float64, error) {
+	if p.Ref == "" {
+		return p.Fixed, nil
+	}
+	ref := refs[p.Ref]
+	if ref <= 0 {
+		return 0, fmt.Errorf("alert: no %s price for the market", p.Ref)
+	}
+	price := ref + p.Offset
+	if p.Percent {
+		price = ref * (1 + p.Offset/100)
+	}
+	if price <= 0 {
+		return 0, fmt.Errorf("alert: price template gives %.8f", price)
+	}
+	return price, nil
+}
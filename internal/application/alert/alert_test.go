@@ -0,0 +1,106 @@
+package alert
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePrice(t *testing.T) {
+	refs := map[string]float64{"bid": 100, "ask": 102, "mid": 101, "last": 101.5}
+	tests := []struct {
+		in      string
+		want    Price
+		price   float64 // resolved against refs, 0 for a market order
+		wantErr string
+	}{
+		{in: "", want: Price{Market: true}},
+		{in: "Market", want: Price{Market: true}},
+		{in: "151000.5", want: Price{Fixed: 151000.5}, price: 151000.5},
+		{in: "{{bid}}", want: Price{Ref: "bid"}, price: 100},
+		{in: "{{ ASK }}", want: Price{Ref: "ask"}, price: 102},
+		{in: "{{mid}}+25", want: Price{Ref: "mid", Offset: 25}, price: 126},
+		{in: "{{last}} - .5", want: Price{Ref: "last", Offset: -0.5}, price: 101},
+		{in: "{{bid}}-0.1%", want: Price{Ref: "bid", Offset: -0.1, Percent: true}, price: 99.9},
+		{in: "{{ask}}+2%", want: Price{Ref: "ask", Offset: 2, Percent: true}, price: 104.04},
+		{in: "0", wantErr: "must be positive"},
+		{in: "-5", wantErr: "must be positive"},
+		{in: "{{open}}", wantErr: "invalid price template"},
+		{in: "{{bid}}*2", wantErr: "invalid price template"},
+		{in: "bid", wantErr: "invalid price template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			p, err := ParsePrice(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p != tt.want {
+				t.Errorf("parsed %+v, want %+v", p, tt.want)
+			}
+			if p.Market {
+				return
+			}
+			got, err := p.Resolve(refs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.price) > 1e-9 {
+				t.Errorf("resolved %v, want %v", got, tt.price)
+			}
+		})
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Price
+		refs map[string]float64
+		want string
+	}{
+		{"no reference price", Price{Ref: "mid"}, map[string]float64{"bid": 100}, "no mid price"},
+		{"offset below zero", Price{Ref: "bid", Offset: -150}, map[string]float64{"bid": 100}, "gives"},
+		{"percent below zero", Price{Ref: "bid", Offset: -100, Percent: true}, map[string]float64{"bid": 100}, "gives"},
+	}
+	for _, tt := range tests {
+		if _, err := tt.p.Resolve(tt.refs); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	want := time.Date(2025, 6, 1, 12, 30, 0, 0, time.UTC)
+	for _, in := range []string{"2025-06-01T12:30:00Z", "2025-06-01T09:30:00-03:00", "1748781000", "1748781000000"} {
+		got, err := ParseTime(in)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseTime(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "yesterday", "2025-06-01"} {
+		if _, err := ParseTime(in); err == nil {
+			t.Errorf("ParseTime(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	a, err := Parse([]byte(`{"action": "BUY", "market": "btcbrl", "size": 0.001, "price": " {{bid}} ", "timestamp": 1748781000}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Action != Buy || a.Size != "0.001" || a.Price != "{{bid}}" || a.Timestamp != "1748781000" {
+		t.Errorf("parsed %+v", a)
+	}
+	if _, err := Parse([]byte(`{"action": "buy", "sise": 1}`)); err == nil {
+		t.Error("unknown field accepted")
+	}
+}
@@ -0,0 +1,337 @@
+package alert
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"trading-bot/internal/application/killswitch"
+	"trading-bot/internal/application/risk"
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// Outcome is what became of an alert.
+type Outcome string
+
+const (
+	Placed    Outcome = "PLACED"
+	Cancelled Outcome = "CANCELLED"
+	Duplicate Outcome = "DUPLICATE" // seen within the dedup window, ignored
+	Rejected  Outcome = "REJECTED"  // invalid, or refused by the risk limits or the kill switch
+	Failed    Outcome = "FAILED"    // the exchange failed
+)
+
+// Decision records how an alert was handled.
+type Decision struct {
+	Time     time.Time `json:"time"`
+	AlertID  string    `json:"alert_id,omitempty"`
+	Key      string    `json:"key"` // the dedup key
+	Action   Action    `json:"action"`
+	Market   string    `json:"market"`
+	Size     string    `json:"size,omitempty"`
+	Price    string    `json:"price,omitempty"` // the resolved limit price
+	Outcome  Outcome   `json:"outcome"`
+	OrderIDs []string  `json:"order_ids,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	Err      error     `json:"-"`
+}
+
+// Receiver maps alerts onto the order use cases. Orders go through the
+// exchange they were built with, so the risk limits and the kill switch
+// apply as for any other command.
+//
+// An alert repeated within Window is dropped: by ID when it has one, else
+// by content. The key is kept even when the alert fails, so a sender that
+// retries cannot place an order twice, and in Store when set, so a
+// restart does not forget it.
+type Receiver struct {
+	Place     *usecase.PlaceOrder
+	Cancel    *usecase.CancelOrder
+	CancelAll *usecase.CancelAllOrders
+	Ticker    *usecase.FetchTicker
+	Markets   *usecase.FetchMarkets
+
+	Allowed    []string                        // markets alerts may trade; empty allows all
+	Window     time.Duration                   // zero means five minutes
+	Store      service.StateStore              // optional, keeps the dedup keys
+	OnDecision func(context.Context, Decision) // ctx is the one given to Handle
+
+	mu      sync.Mutex
+	seen    map[string]time.Time
+	swept   time.Time // when expired keys were last removed from Store
+	markets map[string]model.Market
+}
+
+// seenKey is the prefix of the dedup keys in the state store.
+const seenKey = "alert-seen-"
+
+// seenEntry is a dedup key saved in the state store.
+type seenEntry struct {
+	Key  string    `json:"key"`
+	Time time.Time `json:"time"`
+}
+
+// Handle acts on a and reports the decision to OnDecision.
+func (r *Receiver) Handle(ctx context.Context, a Alert) Decision {
+	d := Decision{Time: time.Now(), AlertID: a.ID, Key: dedupKey(a), Action: a.Action, Market: a.Market, Size: string(a.Size)}
+	dup, err := r.duplicate(d.Key, d.Time)
+	switch {
+	case err != nil:
+		// without its dedup key an alert could be acted on twice
+		d.Outcome, d.Err = Failed, err
+	case dup:
+		d.Outcome, d.Reason = Duplicate, "already received within "+r.window().String()
+	default:
+		r.act(ctx, a, &d)
+	}
+	if d.Err != nil {
+		d.Reason = d.Err.Error()
+	}
+	if r.OnDecision != nil {
+		r.OnDecision(ctx, d)
+	}
+	return d
+}
+
+func (r *Receiver) act(ctx context.Context, a Alert, d *Decision) {
+	if err := a.Validate(); err != nil {
+		d.Outcome, d.Err = Rejected, err
+		return
+	}
+	if !r.allowed(a.Market) {
+		d.Outcome, d.Err = Rejected, rejectf("market %s is not allowed", a.Market)
+		return
+	}
+	if a.Action == Cancel {
+		r.cancel(ctx, a, d)
+		return
+	}
+
+	o, err := r.order(ctx, a)
+	if err != nil {
+		d.Outcome, d.Err = classify(err), err
+		return
+	}
+	d.Size, d.Price = o.Quantity, o.Price
+	placed, err := r.Place.Execute(ctx, o)
+	if err != nil {
+		d.Outcome, d.Err = classify(err), err
+		return
+	}
+	d.Outcome, d.OrderIDs = Placed, []string{placed.ID}
+}
+
+func (r *Receiver) cancel(ctx context.Context, a Alert, d *Decision) {
+	if a.OrderID != "" {
+		if err := r.Cancel.Execute(ctx, a.OrderID); err != nil {
+			d.Outcome, d.Err = classify(err), err
+			return
+		}
+		d.Outcome, d.OrderIDs = Cancelled, []string{a.OrderID}
+		return
+	}
+	results, err := r.CancelAll.Execute(ctx, a.Market, "")
+	if err != nil {
+		d.Outcome, d.Err = classify(err), err
+		return
+	}
+	var errs []error
+	for _, res := range results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Order.ID, res.Err))
+			continue
+		}
+		d.OrderIDs = append(d.OrderIDs, res.Order.ID)
+	}
+	d.Outcome = Cancelled
+	if len(errs) > 0 {
+		d.Outcome, d.Err = Failed, errors.Join(errs...)
+	}
+}
+
+// order builds the order of a buy or sell alert, resolving the price
+// template and aligning both figures to the market's increments.
+func (r *Receiver) order(ctx context.Context, a Alert) (model.Order, error) {
+	m, err := r.market(ctx, a.Market)
+	if err != nil {
+		return model.Order{}, err
+	}
+	size, _ := a.Size.Float()
+	o := model.Order{
+		MarketSymbol: m.Symbol,
+		Side:         model.Buy,
+		Type:         model.MarketOrder,
+		Quantity:     m.AlignQuantity(size),
+	}
+	if a.Action == Sell {
+		o.Side = model.Sell
+	}
+	if q, _ := strconv.ParseFloat(o.Quantity, 64); q <= 0 {
+		return o, rejectf("size %s is below the quantity increment %s", a.Size, m.QuantityIncrement)
+	}
+
+	p, _ := ParsePrice(a.Price)
+	if p.Market {
+		return o, nil
+	}
+	var refs map[string]float64
+	if p.Ref != "" {
+		t, err := r.Ticker.Execute(ctx, m.Symbol)
+		if err != nil {
+			return o, err
+		}
+		bid, ask, last := parse(t.Bid), parse(t.Ask), parse(t.Last)
+		refs = map[string]float64{"bid": bid, "ask": ask, "last": last}
+		if bid > 0 && ask > 0 {
+			refs["mid"] = (bid + ask) / 2
+		}
+	}
+	price, err := p.Resolve(refs)
+	if err != nil {
+		return o, rejection{err}
+	}
+	o.Type, o.Price = model.Limit, m.AlignPrice(price)
+	return o, nil
+}
+
+// market returns the rules of symbol, loading the markets once.
+func (r *Receiver) market(ctx context.Context, symbol string) (model.Market, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.markets == nil {
+		mkts, err := r.Markets.Execute(ctx)
+		if err != nil {
+			return model.Market{}, err
+		}
+		r.markets = make(map[string]model.Market, len(mkts))
+		for _, m := range mkts {
+			r.markets[strings.ToLower(m.Symbol)] = m
+		}
+	}
+	m, ok := r.markets[strings.ToLower(symbol)]
+	if !ok {
+		return m, rejectf("unknown market %s", symbol)
+	}
+	return m, nil
+}
+
+// duplicate records key and reports whether it was already seen within
+// the window. Expired keys are forgotten on the way.
+func (r *Receiver) duplicate(key string, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen == nil {
+		r.seen = make(map[string]time.Time)
+	}
+	for k, t := range r.seen {
+		if now.Sub(t) > r.window() {
+			delete(r.seen, k)
+		}
+	}
+	if _, ok := r.seen[key]; ok {
+		return true, nil
+	}
+	if r.Store != nil {
+		var e seenEntry
+		found, err := r.Store.Load(storeKey(key), &e)
+		if err != nil {
+			return false, fmt.Errorf("alert: dedup lookup: %w", err)
+		}
+		if found && now.Sub(e.Time) <= r.window() {
+			r.seen[key] = e.Time
+			return true, nil
+		}
+		if err := r.Store.Save(storeKey(key), seenEntry{Key: key, Time: now}); err != nil {
+			return false, fmt.Errorf("alert: dedup save: %w", err)
+		}
+		r.sweep(now)
+	}
+	r.seen[key] = now
+	return false, nil
+}
+
+// sweep deletes the expired keys from the store, at most once a window.
+func (r *Receiver) sweep(now time.Time) {
+	if now.Sub(r.swept) < r.window() {
+		return
+	}
+	r.swept = now
+	keys, err := r.Store.Keys(seenKey)
+	if err != nil {
+		return
+	}
+	for _, k := range keys {
+		var e seenEntry
+		if found, err := r.Store.Load(k, &e); err == nil && found && now.Sub(e.Time) > r.window() {
+			r.Store.Delete(k)
+		}
+	}
+}
+
+// storeKey turns a dedup key, which may hold any character the sender
+// put in the alert ID, into a state store key.
+func storeKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return seenKey + hex.EncodeToString(sum[:16])
+}
+
+func (r *Receiver) window() time.Duration {
+	if r.Window <= 0 {
+		return 5 * time.Minute
+	}
+	return r.Window
+}
+
+func (r *Receiver) allowed(market string) bool {
+	if len(r.Allowed) == 0 {
+		return true
+	}
+	for _, m := range r.Allowed {
+		if strings.EqualFold(m, market) {
+			return true
+		}
+	}
+	return false
+}
+
+// dedupKey is the alert ID, or a hash of the alert without its secret.
+func dedupKey(a Alert) string {
+	if a.ID != "" {
+		return "id:" + a.ID
+	}
+	a.Secret = ""
+	data, _ := json.Marshal(a)
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// rejection is an alert refused by the receiver itself.
+type rejection struct{ error }
+
+func rejectf(format string, args ...any) error {
+	return rejection{fmt.Errorf("alert: "+format, args...)}
+}
+
+// classify tells orders refused on purpose from exchange failures.
+func classify(err error) Outcome {
+	var v *risk.Violation
+	var rej rejection
+	if errors.Is(err, killswitch.ErrEngaged) || errors.As(err, &v) || errors.As(err, &rej) {
+		return Rejected
+	}
+	return Failed
+}
+
+func parse(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
@@ -0,0 +1,77 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupSurvivesRestart(t *testing.T) {
+	store := memStore{}
+	// the market is not allowed, so the alert never reaches the exchange
+	a := Alert{ID: "a/1", Action: Buy, Market: "ETHBRL", Size: "1"}
+
+	first := &Receiver{Allowed: []string{"BTCBRL"}, Store: store}
+	if d := first.Handle(context.Background(), a); d.Outcome != Rejected {
+		t.Fatalf("first alert %s (%s), want REJECTED", d.Outcome, d.Reason)
+	}
+	restarted := &Receiver{Allowed: []string{"BTCBRL"}, Store: store}
+	if d := restarted.Handle(context.Background(), a); d.Outcome != Duplicate {
+		t.Errorf("replayed alert %s, want DUPLICATE", d.Outcome)
+	}
+}
+
+func TestDedupForgetsExpiredKeys(t *testing.T) {
+	store := memStore{}
+	r := &Receiver{Window: time.Minute, Store: store}
+	now := time.Now()
+	if dup, err := r.duplicate("id:old", now.Add(-2*time.Minute)); dup || err != nil {
+		t.Fatalf("duplicate = %v, %v", dup, err)
+	}
+	// a new receiver sweeps the store on its first save
+	r = &Receiver{Window: time.Minute, Store: store}
+	if dup, err := r.duplicate("id:old", now); dup || err != nil {
+		t.Errorf("expired key: duplicate = %v, %v; want false", dup, err)
+	}
+	if dup, _ := r.duplicate("id:old", now.Add(time.Second)); !dup {
+		t.Error("key saved again is not a duplicate")
+	}
+	keys, _ := store.Keys(seenKey)
+	if len(keys) != 1 {
+		t.Errorf("store keeps %v, want one key", keys)
+	}
+}
+
+// memStore is an in-memory service.StateStore.
+type memStore map[string][]byte
+
+func (m memStore) Load(key string, dest interface{}) (bool, error) {
+	b, ok := m[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(b, dest)
+}
+
+func (m memStore) Save(key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	m[key] = b
+	return err
+}
+
+func (m memStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m memStore) Keys(prefix string) ([]string, error) {
+	var keys []string
+	for k := range m {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
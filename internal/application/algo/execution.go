@@ -0,0 +1,125 @@
+package algo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"trading-bot/internal/domain/model"
+)
+
+// Kind selects how the parent quantity is spread over time.
+type Kind string
+
+const (
+	// TWAP gives every slice the same share of the parent quantity.
+	TWAP Kind = "TWAP"
+	// VWAP sizes every slice after the volume historically traded at the
+	// same time of day.
+	VWAP Kind = "VWAP"
+)
+
+// Status is the lifecycle stage of a parent order.
+type Status string
+
+const (
+	Running   Status = "RUNNING"
+	Paused    Status = "PAUSED"
+	Completed Status = "COMPLETED" // the whole quantity was executed
+	Expired   Status = "EXPIRED"   // the schedule ended with quantity left
+	Cancelled Status = "CANCELLED"
+)
+
+// Params describe the parent order to work.
+type Params struct {
+	Kind             Kind            `json:"kind"`
+	Market           string          `json:"market"`
+	Side             model.OrderSide `json:"side"`
+	Quantity         float64         `json:"quantity"`
+	Duration         time.Duration   `json:"duration"`
+	Slices           int             `json:"slices"`
+	LimitPrice       float64         `json:"limit_price,omitempty"`       // never buy above / sell below (0 = none)
+	MaxParticipation float64         `json:"max_participation,omitempty"` // cap as a fraction of market volume (0 = none)
+}
+
+// Slice is one interval of the schedule and its share of the quantity.
+type Slice struct {
+	Start  time.Time `json:"start"`
+	Weight float64   `json:"weight"`
+}
+
+// Execution is the persisted state of a parent order. Filled and Cost only
+// include finished child orders; Child is the one still working, if any.
+type Execution struct {
+	ID       string    `json:"id"`
+	Params   Params    `json:"params"`
+	Status   Status    `json:"status"`
+	Slices   []Slice   `json:"slices"`
+	Next     int       `json:"next"` // index of the next slice to start
+	Child    string    `json:"child,omitempty"`
+	Children []string  `json:"children,omitempty"`
+	Filled   float64   `json:"filled"`
+	Cost     float64   `json:"cost"`
+	PausedAt time.Time `json:"paused_at,omitempty"`
+}
+
+// newExecution lays the slices of p out from start with the given weights.
+func newExecution(p Params, start time.Time, weights []float64) *Execution {
+	e := &Execution{
+		ID:     fmt.Sprintf("%s-%s-%d", strings.ToLower(p.Market), strings.ToLower(string(p.Side)), start.Unix()),
+		Params: p,
+		Status: Running,
+		Slices: make([]Slice, len(weights)),
+	}
+	for i, w := range weights {
+		e.Slices[i] = Slice{Start: start.Add(time.Duration(i) * e.SliceLength()), Weight: w}
+	}
+	return e
+}
+
+// SliceLength returns the duration of a single slice.
+func (e *Execution) SliceLength() time.Duration {
+	if e.Params.Slices <= 0 {
+		return e.Params.Duration
+	}
+	return e.Params.Duration / time.Duration(e.Params.Slices)
+}
+
+// End returns when the last slice is over.
+func (e *Execution) End() time.Time {
+	if len(e.Slices) == 0 {
+		return time.Time{}
+	}
+	return e.Slices[len(e.Slices)-1].Start.Add(e.SliceLength())
+}
+
+// Target returns the cumulative quantity that should be executed once the
+// first n slices have started.
+func (e *Execution) Target(n int) float64 {
+	var w float64
+	for _, s := range e.Slices[:n] {
+		w += s.Weight
+	}
+	return w * e.Params.Quantity
+}
+
+// Progress returns the executed fraction of the parent quantity.
+func (e *Execution) Progress() float64 {
+	if e.Params.Quantity == 0 {
+		return 0
+	}
+	return e.Filled / e.Params.Quantity
+}
+
+// AveragePrice returns the average execution price, or 0 before any fill.
+func (e *Execution) AveragePrice() float64 {
+	if e.Filled == 0 {
+		return 0
+	}
+	return e.Cost / e.Filled
+}
+
+// Done reports whether the execution reached a final status.
+func (e *Execution) Done() bool {
+	return e.Status == Completed || e.Status == Expired || e.Status == Cancelled
+}
@@ -0,0 +1,336 @@
+package algo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// Executor slices parent orders into child limit orders over time.
+// At the start of every slice the working child is cancelled and a new one
+// is sent for the gap between the cumulative target and what was executed
+// so far, so anything left unfilled rolls into the following slice.
+type Executor struct {
+	Place    *usecase.PlaceOrder
+	Cancel   *usecase.CancelOrder
+	Get      *usecase.GetOrder
+	Book     *usecase.FetchOrderBook
+	Candles  *usecase.FetchCandles
+	Store    service.StateStore
+	Market   model.Market
+	Interval time.Duration // how often the working child is polled
+}
+
+// Plan validates p, computes its schedule starting now and saves it.
+// VWAP weights come from the volume traded over the last lookbackDays;
+// without usable history the schedule falls back to equal TWAP slices.
+func (x *Executor) Plan(ctx context.Context, p Params, lookbackDays int) (*Execution, error) {
+	if p.Quantity <= 0 || p.Duration <= 0 || p.Slices <= 0 {
+		return nil, errors.New("algo: quantity, duration and slices must be positive")
+	}
+	if p.Side != model.Buy && p.Side != model.Sell {
+		return nil, fmt.Errorf("algo: invalid side %q", p.Side)
+	}
+	start := time.Now()
+	weights := make([]float64, p.Slices)
+	for i := range weights {
+		weights[i] = 1 / float64(p.Slices)
+	}
+	switch p.Kind {
+	case TWAP:
+	case VWAP:
+		profile, err := VolumeProfile(ctx, x.Candles, p.Market, start, p.Duration/time.Duration(p.Slices), p.Slices, lookbackDays)
+		if err != nil {
+			slog.Warn("algo: no volume profile, using equal slices", "err", err)
+		} else {
+			weights = profile
+		}
+	default:
+		return nil, fmt.Errorf("algo: unknown algorithm %q", p.Kind)
+	}
+	e := newExecution(p, start, weights)
+	return e, x.save(e)
+}
+
+// Load reads a saved execution by ID.
+func (x *Executor) Load(id string) (*Execution, error) {
+	var e Execution
+	found, err := x.Store.Load(key(id), &e)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("algo: execution %q not found", id)
+	}
+	return &e, nil
+}
+
+// Run works e until its schedule ends or ctx is cancelled. Cancelling ctx
+// pauses the execution: the working child is pulled and the remaining
+// slices are shifted by the time spent paused once Run is called again.
+func (x *Executor) Run(ctx context.Context, e *Execution) error {
+	if e.Done() {
+		return fmt.Errorf("algo: execution %s is already %s", e.ID, e.Status)
+	}
+	if e.Status == Paused {
+		shift := time.Since(e.PausedAt)
+		for i := e.Next; i < len(e.Slices); i++ {
+			e.Slices[i].Start = e.Slices[i].Start.Add(shift)
+		}
+		e.Status, e.PausedAt = Running, time.Time{}
+		slog.Info("algo: resuming", "execution", e.ID, "shift", shift.Round(time.Second))
+		if err := x.save(e); err != nil {
+			return err
+		}
+	}
+
+	interval := x.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := x.step(ctx, e); err != nil {
+			slog.Warn("algo: step failed", "execution", e.ID, "err", err)
+		}
+		if e.Done() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			// pulling the child order must outlive ctx
+			return x.pause(context.WithoutCancel(ctx), e)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Abort cancels the working child and marks the execution cancelled.
+func (x *Executor) Abort(ctx context.Context, e *Execution) error {
+	if e.Done() {
+		return fmt.Errorf("algo: execution %s is already %s", e.ID, e.Status)
+	}
+	if err := x.settle(ctx, e); err != nil {
+		return err
+	}
+	e.Status = Cancelled
+	return x.save(e)
+}
+
+// step starts the slice that is due, finishes the execution once the
+// schedule is over, and otherwise checks on the working child.
+func (x *Executor) step(ctx context.Context, e *Execution) error {
+	now := time.Now()
+	switch {
+	case x.filled(e):
+		if err := x.settle(ctx, e); err != nil {
+			return err
+		}
+		e.Status = Completed
+		x.report(e)
+		return x.save(e)
+
+	case e.Next < len(e.Slices) && !now.Before(e.Slices[e.Next].Start):
+		if err := x.settle(ctx, e); err != nil {
+			return err // never send a new child while the old one may still work
+		}
+		e.Next++
+		if err := x.save(e); err != nil {
+			return err
+		}
+		x.report(e)
+		return x.sendChild(ctx, e)
+
+	case e.Next == len(e.Slices) && !now.Before(e.End()):
+		if err := x.settle(ctx, e); err != nil {
+			return err
+		}
+		e.Status = Expired
+		if x.filled(e) {
+			e.Status = Completed
+		}
+		x.report(e)
+		return x.save(e)
+
+	case e.Child != "":
+		o, err := x.Get.Execute(ctx, e.Child)
+		if err != nil {
+			return err
+		}
+		if o.State.Final() {
+			// filled, or ended early with maybe part executed: the rest
+			// rolls into the next slice
+			x.book(e, o)
+			x.report(e)
+			return x.save(e)
+		}
+	}
+	return nil
+}
+
+// sendChild places a marketable limit order for the gap between the
+// cumulative target and the executed quantity, within the participation
+// cap and the limit price.
+func (x *Executor) sendChild(ctx context.Context, e *Execution) error {
+	p := e.Params
+	qty := e.Target(e.Next) - e.Filled
+	if p.MaxParticipation > 0 {
+		vol, err := x.recentVolume(ctx, e)
+		if err != nil {
+			return err
+		}
+		if limit := p.MaxParticipation * vol; qty > limit {
+			qty = limit
+		}
+	}
+	aligned := x.Market.AlignQuantity(qty)
+	if parse(aligned) <= 0 || parse(aligned) < x.minQuantity() {
+		return nil
+	}
+
+	ob, err := x.Book.Execute(ctx, p.Market, 1)
+	if err != nil {
+		return err
+	}
+	price := ob.BestAsk()
+	if p.Side == model.Sell {
+		price = ob.BestBid()
+	}
+	if price == 0 {
+		return fmt.Errorf("algo: order book for %s is empty", p.Market)
+	}
+	if p.LimitPrice > 0 && ((p.Side == model.Buy && price > p.LimitPrice) ||
+		(p.Side == model.Sell && price < p.LimitPrice)) {
+		price = p.LimitPrice // rest at the limit rather than trade through it
+	}
+
+	o, err := x.Place.Execute(ctx, model.Order{
+		MarketSymbol: p.Market,
+		Side:         p.Side,
+		Type:         model.Limit,
+		Price:        x.Market.AlignPrice(price),
+		Quantity:     aligned,
+	})
+	if err != nil {
+		return err
+	}
+	e.Child = o.ID
+	e.Children = append(e.Children, o.ID)
+	return x.save(e)
+}
+
+// recentVolume returns the base volume traded in the market during the
+// last slice length, used to enforce the participation cap.
+func (x *Executor) recentVolume(ctx context.Context, e *Execution) (float64, error) {
+	end := time.Now()
+	candles, err := x.Candles.Execute(ctx, e.Params.Market, "1m", end.Add(-e.SliceLength()), end)
+	if err != nil {
+		return 0, err
+	}
+	var vol float64
+	for _, c := range candles {
+		vol += parse(c.Volume)
+	}
+	return vol, nil
+}
+
+// settle pulls the working child, if any, and books its execution.
+func (x *Executor) settle(ctx context.Context, e *Execution) error {
+	if e.Child == "" {
+		return nil
+	}
+	o, err := x.pull(ctx, e.Child)
+	if err != nil {
+		return err
+	}
+	x.book(e, o)
+	return x.save(e)
+}
+
+// pull cancels order id unless it already finished and returns its final
+// state, so executions that raced the cancel are not lost.
+func (x *Executor) pull(ctx context.Context, id string) (*model.Order, error) {
+	o, err := x.Get.Execute(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if o.State.Final() {
+		return o, nil
+	}
+	if err := x.Cancel.Execute(ctx, id); err != nil {
+		return nil, fmt.Errorf("cancel %s: %w", id, err)
+	}
+	return x.Get.Execute(ctx, id)
+}
+
+// book adds the execution of the finished child o to e.
+func (x *Executor) book(e *Execution, o *model.Order) {
+	qty, cost := executed(o)
+	e.Filled += qty
+	e.Cost += cost
+	e.Child = ""
+}
+
+// executed returns the quantity o executed and the quote amount it cost.
+func executed(o *model.Order) (qty, cost float64) {
+	qty = parse(o.QuantityExecuted)
+	price := parse(o.PriceAvg)
+	if price == 0 {
+		price = parse(o.Price)
+	}
+	return qty, qty * price
+}
+
+// pause pulls the working child and records when the execution stopped.
+func (x *Executor) pause(ctx context.Context, e *Execution) error {
+	if err := x.settle(ctx, e); err != nil {
+		return err
+	}
+	e.Status, e.PausedAt = Paused, time.Now()
+	x.report(e)
+	return x.save(e)
+}
+
+// report logs the progress of e.
+func (x *Executor) report(e *Execution) {
+	slog.Info("algo: execution progress", "execution", e.ID, "status", e.Status, "slice", e.Next, "slices", len(e.Slices),
+		"filled", e.Filled, "quantity", e.Params.Quantity, "progress", e.Progress(), "avg_price", e.AveragePrice())
+}
+
+// filled reports whether what is left of e is too small to trade.
+func (x *Executor) filled(e *Execution) bool {
+	left := e.Params.Quantity - e.Filled
+	return left <= 0 || left < x.minQuantity()
+}
+
+// minQuantity is the smallest child the market accepts.
+func (x *Executor) minQuantity() float64 {
+	if m := parse(x.Market.QuantityMin); m > 0 {
+		return m
+	}
+	return parse(x.Market.QuantityIncrement)
+}
+
+func (x *Executor) save(e *Execution) error {
+	return x.Store.Save(key(e.ID), e)
+}
+
+// key is where an execution is saved.
+func key(id string) string {
+	return "algo-" + id
+}
+
+// parse converts an exchange decimal string to float64, treating
+// malformed input as zero.
+func parse(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
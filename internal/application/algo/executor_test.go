@@ -0,0 +1,223 @@
+package algo
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+var btcbrl = model.Market{
+	Symbol:            "BTCBRL",
+	PriceIncrement:    "1",
+	QuantityMin:       "0.0002",
+	QuantityIncrement: "0.0001",
+	QuantityPrecision: 4,
+}
+
+func TestPlanSchedule(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      Params
+		candles     func(from, to time.Time) []model.Candle
+		wantWeights []float64
+	}{
+		{
+			name:        "TWAP",
+			params:      Params{Kind: TWAP, Market: "BTCBRL", Side: model.Buy, Quantity: 1, Duration: time.Hour, Slices: 4},
+			wantWeights: []float64{0.25, 0.25, 0.25, 0.25},
+		},
+		{
+			name:   "VWAP",
+			params: Params{Kind: VWAP, Market: "BTCBRL", Side: model.Sell, Quantity: 1, Duration: time.Hour, Slices: 2},
+			candles: func(from, to time.Time) []model.Candle {
+				return []model.Candle{{OpenTime: from, Volume: "1"}, {OpenTime: from.Add(30 * time.Minute), Volume: "3"}}
+			},
+			wantWeights: []float64{0.25, 0.75},
+		},
+		{
+			name:        "VWAP without history",
+			params:      Params{Kind: VWAP, Market: "BTCBRL", Side: model.Sell, Quantity: 1, Duration: time.Hour, Slices: 2},
+			wantWeights: []float64{0.5, 0.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := &fakeExchange{candles: tt.candles}
+			x := newExecutor(ex)
+			e, err := x.Plan(context.Background(), tt.params, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(e.Slices) != len(tt.wantWeights) {
+				t.Fatalf("%d slices, want %d", len(e.Slices), len(tt.wantWeights))
+			}
+			step := tt.params.Duration / time.Duration(tt.params.Slices)
+			for i, s := range e.Slices {
+				if math.Abs(s.Weight-tt.wantWeights[i]) > 1e-9 {
+					t.Errorf("slice %d weight %v, want %v", i, s.Weight, tt.wantWeights[i])
+				}
+				if got := s.Start.Sub(e.Slices[0].Start); got != time.Duration(i)*step {
+					t.Errorf("slice %d starts %v after the first, want %v", i, got, time.Duration(i)*step)
+				}
+			}
+			if got := e.End().Sub(e.Slices[0].Start); got != tt.params.Duration {
+				t.Errorf("schedule lasts %v, want %v", got, tt.params.Duration)
+			}
+			if got := e.Target(len(e.Slices)); math.Abs(got-tt.params.Quantity) > 1e-9 {
+				t.Errorf("final target %v, want the whole quantity", got)
+			}
+		})
+	}
+}
+
+func TestPlanRejectsBadParams(t *testing.T) {
+	tests := []Params{
+		{Kind: TWAP, Side: model.Buy, Quantity: 0, Duration: time.Hour, Slices: 2},
+		{Kind: TWAP, Side: model.Buy, Quantity: 1, Duration: time.Hour},
+		{Kind: TWAP, Side: "HOLD", Quantity: 1, Duration: time.Hour, Slices: 2},
+		{Kind: "POV", Side: model.Buy, Quantity: 1, Duration: time.Hour, Slices: 2},
+	}
+	for _, p := range tests {
+		if _, err := newExecutor(&fakeExchange{}).Plan(context.Background(), p, 1); err == nil {
+			t.Errorf("Plan(%+v) accepted", p)
+		}
+	}
+}
+
+func TestStepBooksFinishedChildren(t *testing.T) {
+	tests := []struct {
+		name       string
+		child      model.Order
+		wantFilled float64
+		wantChild  string
+	}{
+		{name: "filled", child: model.Order{State: model.StateFilled, QuantityExecuted: "0.3", PriceAvg: "100"}, wantFilled: 0.3},
+		{name: "cancelled part filled", child: model.Order{State: model.StateCancelled, QuantityExecuted: "0.2", Price: "100"}, wantFilled: 0.2},
+		{name: "rejected", child: model.Order{State: model.StateRejected, QuantityExecuted: "0"}},
+		{name: "still working", child: model.Order{State: model.StatePartiallyFilled, QuantityExecuted: "0.1", Price: "100"}, wantChild: "c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.child.ID = "c"
+			ex := &fakeExchange{orders: map[string]model.Order{"c": tt.child}}
+			x := newExecutor(ex)
+			now := time.Now()
+			e := &Execution{
+				ID:     "e",
+				Params: Params{Market: "BTCBRL", Side: model.Buy, Quantity: 1, Duration: 2 * time.Hour, Slices: 2},
+				Status: Running,
+				Slices: []Slice{{Start: now.Add(-time.Hour), Weight: 0.5}, {Start: now.Add(time.Hour), Weight: 0.5}},
+				Next:   1,
+				Child:  "c",
+			}
+
+			if err := x.step(context.Background(), e); err != nil {
+				t.Fatal(err)
+			}
+			if e.Child != tt.wantChild || math.Abs(e.Filled-tt.wantFilled) > 1e-9 || math.Abs(e.Cost-tt.wantFilled*100) > 1e-9 {
+				t.Errorf("child %q, filled %v for %v; want %q, %v", e.Child, e.Filled, e.Cost, tt.wantChild, tt.wantFilled)
+			}
+			if len(ex.placed) > 0 {
+				t.Errorf("placed %+v before the next slice", ex.placed)
+			}
+		})
+	}
+}
+
+func TestStepRollsTheGapIntoTheNextSlice(t *testing.T) {
+	// the first child executed 0.1 of its 0.5: the second is sent for the
+	// 0.4 missing from the first half and the 0.5 of the second
+	ex := &fakeExchange{
+		orders: map[string]model.Order{"c": {ID: "c", State: model.StatePartiallyFilled, QuantityExecuted: "0.1", Price: "100"}},
+		book:   model.OrderBook{Bids: [][]string{{"99", "1"}}, Asks: [][]string{{"101", "1"}}},
+	}
+	x := newExecutor(ex)
+	now := time.Now()
+	e := &Execution{
+		ID:     "e",
+		Params: Params{Market: "BTCBRL", Side: model.Buy, Quantity: 1, Duration: 2 * time.Hour, Slices: 2},
+		Status: Running,
+		Slices: []Slice{{Start: now.Add(-2 * time.Hour), Weight: 0.5}, {Start: now.Add(-time.Minute), Weight: 0.5}},
+		Next:   1,
+		Child:  "c",
+	}
+
+	if err := x.step(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	if got := ex.orders["c"].State; got != model.StateCancelled {
+		t.Errorf("first child %s, want it cancelled", got)
+	}
+	if len(ex.placed) != 1 || ex.placed[0].Quantity != "0.9000" || ex.placed[0].Price != "101" {
+		t.Fatalf("placed %+v, want 0.9000 at the ask", ex.placed)
+	}
+	if e.Next != 2 || e.Filled != 0.1 || e.Child != ex.placed[0].ID || len(e.Children) != 1 {
+		t.Errorf("execution %+v", e)
+	}
+}
+
+func newExecutor(ex *fakeExchange) *Executor {
+	return &Executor{
+		Place:   &usecase.PlaceOrder{Ex: ex},
+		Cancel:  &usecase.CancelOrder{Ex: ex},
+		Get:     &usecase.GetOrder{Ex: ex},
+		Book:    &usecase.FetchOrderBook{Ex: ex},
+		Candles: &usecase.FetchCandles{Ex: ex},
+		Store:   memStore{},
+		Market:  btcbrl,
+	}
+}
+
+// fakeExchange serves the orders it holds, records the ones placed and
+// answers candle requests with candles, no candles when nil.
+type fakeExchange struct {
+	service.Exchange
+	orders  map[string]model.Order
+	placed  []model.Order
+	book    model.OrderBook
+	candles func(from, to time.Time) []model.Candle
+
+	interval    string
+	candleCalls []time.Time
+}
+
+func (f *fakeExchange) GetCandles(ctx context.Context, market, interval string, start, end time.Time) ([]model.Candle, error) {
+	f.interval = interval
+	f.candleCalls = append(f.candleCalls, start)
+	if f.candles == nil {
+		return nil, nil
+	}
+	return f.candles(start, end), nil
+}
+
+func (f *fakeExchange) GetOrderBook(ctx context.Context, market string, depth int) (*model.OrderBook, error) {
+	return &f.book, nil
+}
+
+func (f *fakeExchange) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
+	o, ok := f.orders[id]
+	if !ok {
+		return nil, service.ErrOrderNotFound
+	}
+	return &o, nil
+}
+
+func (f *fakeExchange) CancelOrder(ctx context.Context, id string) error {
+	o := f.orders[id]
+	o.State = model.StateCancelled
+	f.orders[id] = o
+	return nil
+}
+
+func (f *fakeExchange) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
+	f.placed = append(f.placed, o)
+	o.ID = "child-" + o.Quantity
+	o.State = model.StateActive
+	f.placed[len(f.placed)-1].ID = o.ID
+	return &o, nil
+}
@@ -0,0 +1,234 @@
+package algo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"time"
+
+	"trading-bot/internal/domain/model"
+)
+
+// IcebergParams describe an order of which only a small clip is shown.
+type IcebergParams struct {
+	Market   string          `json:"market"`
+	Side     model.OrderSide `json:"side"`
+	Price    float64         `json:"price"`
+	Quantity float64         `json:"quantity"` // total, visible plus hidden
+	ClipMin  float64         `json:"clip_min"` // smallest visible clip
+	ClipMax  float64         `json:"clip_max"` // largest visible clip
+	PostOnly bool            `json:"post_only"`
+}
+
+// Iceberg is the persisted state of an iceberg order. Filled and Cost only
+// include finished clips; Clip is the one resting on the book, if any.
+type Iceberg struct {
+	ID       string        `json:"id"`
+	Params   IcebergParams `json:"params"`
+	Status   Status        `json:"status"`
+	Clip     string        `json:"clip,omitempty"`
+	Clips    []string      `json:"clips,omitempty"`
+	Filled   float64       `json:"filled"`
+	Cost     float64       `json:"cost"`
+	PausedAt time.Time     `json:"paused_at,omitempty"`
+}
+
+// Hidden returns the quantity not yet shown on the book.
+func (ib *Iceberg) Hidden() float64 {
+	return ib.Params.Quantity - ib.Filled
+}
+
+// AveragePrice returns the average execution price, or 0 before any fill.
+func (ib *Iceberg) AveragePrice() float64 {
+	if ib.Filled == 0 {
+		return 0
+	}
+	return ib.Cost / ib.Filled
+}
+
+// Done reports whether the iceberg reached a final status.
+func (ib *Iceberg) Done() bool {
+	return ib.Status == Completed || ib.Status == Cancelled
+}
+
+// PlanIceberg validates p and saves a new iceberg order.
+func (x *Executor) PlanIceberg(p IcebergParams) (*Iceberg, error) {
+	if p.Quantity <= 0 || p.Price <= 0 {
+		return nil, errors.New("algo: iceberg price and quantity must be positive")
+	}
+	if p.Side != model.Buy && p.Side != model.Sell {
+		return nil, fmt.Errorf("algo: invalid side %q", p.Side)
+	}
+	if p.ClipMin < x.minQuantity() || p.ClipMax < p.ClipMin {
+		return nil, fmt.Errorf("algo: clip bounds must satisfy %s <= min <= max", x.Market.QuantityMin)
+	}
+	ib := &Iceberg{
+		ID:     fmt.Sprintf("iceberg-%s-%s-%d", strings.ToLower(p.Market), strings.ToLower(string(p.Side)), time.Now().Unix()),
+		Params: p,
+		Status: Running,
+	}
+	return ib, x.saveIceberg(ib)
+}
+
+// LoadIceberg reads a saved iceberg order by ID.
+func (x *Executor) LoadIceberg(id string) (*Iceberg, error) {
+	var ib Iceberg
+	found, err := x.Store.Load(icebergKey(id), &ib)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("algo: iceberg %q not found", id)
+	}
+	return &ib, nil
+}
+
+// RunIceberg keeps one clip of ib on the book, replacing it from the hidden
+// quantity every time it fills, until everything is executed or ctx is
+// cancelled. Cancelling ctx pauses the iceberg and pulls the visible clip.
+// A clip cancelled by someone else cancels the whole iceberg.
+func (x *Executor) RunIceberg(ctx context.Context, ib *Iceberg) error {
+	if ib.Done() {
+		return fmt.Errorf("algo: iceberg %s is already %s", ib.ID, ib.Status)
+	}
+	ib.Status, ib.PausedAt = Running, time.Time{}
+
+	interval := x.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := x.stepIceberg(ctx, ib); err != nil {
+			slog.Warn("algo: iceberg step failed", "iceberg", ib.ID, "err", err)
+		}
+		if ib.Done() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			// pulling the clip must outlive ctx
+			return x.pauseIceberg(context.WithoutCancel(ctx), ib)
+		case <-ticker.C:
+		}
+	}
+}
+
+// AbortIceberg pulls the visible clip and marks the iceberg cancelled.
+func (x *Executor) AbortIceberg(ctx context.Context, ib *Iceberg) error {
+	if ib.Done() {
+		return fmt.Errorf("algo: iceberg %s is already %s", ib.ID, ib.Status)
+	}
+	if err := x.settleClip(ctx, ib); err != nil {
+		return err
+	}
+	ib.Status = Cancelled
+	return x.saveIceberg(ib)
+}
+
+// stepIceberg books a finished clip and shows the next one.
+func (x *Executor) stepIceberg(ctx context.Context, ib *Iceberg) error {
+	if ib.Clip != "" {
+		o, err := x.Get.Execute(ctx, ib.Clip)
+		if err != nil {
+			return err
+		}
+		switch {
+		case o.State == model.StateFilled:
+		case o.State.Final():
+			slog.Warn("algo: clip closed externally, cancelling the iceberg", "iceberg", ib.ID, "clip", ib.Clip, "state", o.State)
+			ib.Status = Cancelled
+		default:
+			return nil
+		}
+		qty, cost := executed(o)
+		ib.Filled += qty
+		ib.Cost += cost
+		ib.Clip = ""
+		x.reportIceberg(ib)
+		if err := x.saveIceberg(ib); err != nil || ib.Done() {
+			return err
+		}
+	}
+
+	clip := x.Market.AlignQuantity(x.clipSize(ib))
+	if parse(clip) <= 0 || parse(clip) < x.minQuantity() {
+		ib.Status = Completed
+		x.reportIceberg(ib)
+		return x.saveIceberg(ib)
+	}
+	p := ib.Params
+	o, err := x.Place.Execute(ctx, model.Order{
+		MarketSymbol: p.Market,
+		Side:         p.Side,
+		Type:         model.Limit,
+		Price:        x.Market.AlignPrice(p.Price),
+		Quantity:     clip,
+		PostOnly:     p.PostOnly,
+	})
+	if err != nil {
+		return err
+	}
+	ib.Clip = o.ID
+	ib.Clips = append(ib.Clips, o.ID)
+	return x.saveIceberg(ib)
+}
+
+// clipSize draws the next visible quantity uniformly between the clip
+// bounds. When what would be left afterwards is too small to trade on its
+// own, it is shown together with this clip instead of as a dust order.
+func (x *Executor) clipSize(ib *Iceberg) float64 {
+	p := ib.Params
+	size := p.ClipMin + rand.Float64()*(p.ClipMax-p.ClipMin)
+	if hidden := ib.Hidden(); size >= hidden || hidden-size < x.minQuantity() {
+		return hidden
+	}
+	return size
+}
+
+// settleClip pulls the visible clip, if any, and books its execution.
+func (x *Executor) settleClip(ctx context.Context, ib *Iceberg) error {
+	if ib.Clip == "" {
+		return nil
+	}
+	o, err := x.pull(ctx, ib.Clip)
+	if err != nil {
+		return err
+	}
+	qty, cost := executed(o)
+	ib.Filled += qty
+	ib.Cost += cost
+	ib.Clip = ""
+	return x.saveIceberg(ib)
+}
+
+// pauseIceberg pulls the visible clip and records when the iceberg stopped.
+func (x *Executor) pauseIceberg(ctx context.Context, ib *Iceberg) error {
+	if err := x.settleClip(ctx, ib); err != nil {
+		return err
+	}
+	ib.Status, ib.PausedAt = Paused, time.Now()
+	x.reportIceberg(ib)
+	return x.saveIceberg(ib)
+}
+
+// reportIceberg logs the progress of ib.
+func (x *Executor) reportIceberg(ib *Iceberg) {
+	slog.Info("algo: iceberg progress", "iceberg", ib.ID, "status", ib.Status, "filled", ib.Filled,
+		"quantity", ib.Params.Quantity, "clips", len(ib.Clips), "avg_price", ib.AveragePrice())
+}
+
+func (x *Executor) saveIceberg(ib *Iceberg) error {
+	return x.Store.Save(icebergKey(ib.ID), ib)
+}
+
+// icebergKey is where an iceberg is saved, under a prefix of its own so
+// that it is never loaded as an execution, nor an execution as an iceberg.
+// Iceberg IDs already start with the prefix, so the key is the ID.
+func icebergKey(id string) string {
+	return "iceberg-" + strings.TrimPrefix(id, "iceberg-")
+}
@@ -0,0 +1,66 @@
+package algo
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"trading-bot/internal/domain/model"
+)
+
+func TestIcebergsAndExecutionsAreKeptApart(t *testing.T) {
+	store := memStore{}
+	x := &Executor{Store: store, Market: model.Market{QuantityMin: "0.0001"}}
+	ib, err := x.PlanIceberg(IcebergParams{
+		Market: "BTCBRL", Side: model.Buy, Price: 100, Quantity: 1, ClipMin: 0.1, ClipMax: 0.2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store[ib.ID]; !ok {
+		t.Fatalf("iceberg saved under %v, want %q", store.keys(), ib.ID)
+	}
+	if _, err := x.Load(ib.ID); err == nil {
+		t.Error("Load decoded an iceberg as an execution")
+	}
+	if _, err := x.LoadIceberg(ib.ID); err != nil {
+		t.Error(err)
+	}
+}
+
+// memStore is an in-memory service.StateStore.
+type memStore map[string][]byte
+
+func (m memStore) Load(key string, dest interface{}) (bool, error) {
+	b, ok := m[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(b, dest)
+}
+
+func (m memStore) Save(key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	m[key] = b
+	return err
+}
+
+func (m memStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m memStore) Keys(prefix string) ([]string, error) {
+	var keys []string
+	for k := range m {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (m memStore) keys() []string {
+	keys, _ := m.Keys("")
+	return keys
+}
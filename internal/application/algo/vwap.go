@@ -0,0 +1,84 @@
+package algo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"trading-bot/internal/application/usecase"
+)
+
+// candleIntervals lists the bar sizes the exchanges support, smallest first.
+var candleIntervals = []struct {
+	name string
+	d    time.Duration
+}{
+	{"1m", time.Minute},
+	{"5m", 5 * time.Minute},
+	{"15m", 15 * time.Minute},
+	{"30m", 30 * time.Minute},
+	{"1h", time.Hour},
+	{"2h", 2 * time.Hour},
+	{"4h", 4 * time.Hour},
+	{"6h", 6 * time.Hour},
+	{"12h", 12 * time.Hour},
+	{"1d", 24 * time.Hour},
+}
+
+// maxCandles is the number of bars requested per call.
+const maxCandles = 500
+
+// VolumeProfile returns, for each of the slices starting at start, the share
+// of the volume that was traded in the same time-of-day window over the
+// previous days. The weights sum to 1.
+func VolumeProfile(ctx context.Context, candles *usecase.FetchCandles, market string, start time.Time, sliceLen time.Duration, slices, days int) ([]float64, error) {
+	if days <= 0 {
+		days = 7
+	}
+	total := sliceLen * time.Duration(slices)
+	interval := pickInterval(sliceLen, total)
+
+	weights := make([]float64, slices)
+	var sum float64
+	for d := 1; d <= days; d++ {
+		from := start.AddDate(0, 0, -d)
+		bars, err := candles.Execute(ctx, market, interval, from, from.Add(total))
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range bars {
+			if c.OpenTime.Before(from) {
+				continue
+			}
+			i := int(c.OpenTime.Sub(from) / sliceLen)
+			if i >= slices {
+				continue
+			}
+			v := parse(c.Volume)
+			weights[i] += v
+			sum += v
+		}
+	}
+	if sum == 0 {
+		return nil, errors.New("algo: no historical volume for " + market)
+	}
+	for i := range weights {
+		weights[i] /= sum
+	}
+	return weights, nil
+}
+
+// pickInterval chooses the largest bar that still fits in a slice, moving
+// to larger bars if a whole day of the schedule would need too many calls.
+func pickInterval(sliceLen, total time.Duration) string {
+	best := 0
+	for i, iv := range candleIntervals {
+		if iv.d <= sliceLen {
+			best = i
+		}
+	}
+	for best < len(candleIntervals)-1 && total/candleIntervals[best].d > maxCandles {
+		best++
+	}
+	return candleIntervals[best].name
+}
@@ -0,0 +1,91 @@
+package algo
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+)
+
+func TestVolumeProfile(t *testing.T) {
+	start := time.Date(2024, 5, 10, 14, 0, 0, 0, time.UTC)
+	ex := &fakeExchange{candles: func(from, to time.Time) []model.Candle {
+		// every day trades 1 in the first slice and 3 in the second,
+		// and more around the window that must not count
+		return []model.Candle{
+			{OpenTime: from.Add(-time.Minute), Volume: "100"},
+			{OpenTime: from, Volume: "1"},
+			{OpenTime: from.Add(15 * time.Minute), Volume: "1"},
+			{OpenTime: from.Add(30 * time.Minute), Volume: "2"},
+			{OpenTime: to, Volume: "100"},
+		}
+	}}
+
+	weights, err := VolumeProfile(context.Background(), &usecase.FetchCandles{Ex: ex}, "BTCBRL", start, 30*time.Minute, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(weights) != 2 || math.Abs(weights[0]-0.5) > 1e-9 || math.Abs(weights[1]-0.5) > 1e-9 {
+		t.Errorf("weights %v, want [0.5 0.5]", weights)
+	}
+	if len(ex.candleCalls) != 3 || !ex.candleCalls[0].Equal(start.AddDate(0, 0, -1)) || !ex.candleCalls[2].Equal(start.AddDate(0, 0, -3)) {
+		t.Errorf("asked for the days starting %v, want the 3 days before %v", ex.candleCalls, start)
+	}
+	if ex.interval != "30m" {
+		t.Errorf("interval %q, want 30m", ex.interval)
+	}
+}
+
+func TestVolumeProfileWeighting(t *testing.T) {
+	start := time.Date(2024, 5, 10, 14, 0, 0, 0, time.UTC)
+	yesterday := start.AddDate(0, 0, -1)
+	ex := &fakeExchange{candles: func(from, to time.Time) []model.Candle {
+		if from.Equal(yesterday) {
+			return []model.Candle{{OpenTime: from, Volume: "6"}, {OpenTime: from.Add(time.Hour), Volume: "2"}}
+		}
+		return []model.Candle{{OpenTime: from.Add(2 * time.Hour), Volume: "2"}}
+	}}
+
+	weights, err := VolumeProfile(context.Background(), &usecase.FetchCandles{Ex: ex}, "BTCBRL", start, time.Hour, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{0.6, 0.2, 0.2}
+	for i := range want {
+		if math.Abs(weights[i]-want[i]) > 1e-9 {
+			t.Fatalf("weights %v, want %v", weights, want)
+		}
+	}
+}
+
+func TestVolumeProfileWithoutVolume(t *testing.T) {
+	ex := &fakeExchange{candles: func(from, to time.Time) []model.Candle {
+		return []model.Candle{{OpenTime: from, Volume: "0"}}
+	}}
+	if _, err := VolumeProfile(context.Background(), &usecase.FetchCandles{Ex: ex}, "BTCBRL", time.Now(), time.Hour, 2, 1); err == nil {
+		t.Error("no error without historical volume")
+	}
+}
+
+func TestPickInterval(t *testing.T) {
+	tests := []struct {
+		slice, total time.Duration
+		want         string
+	}{
+		{slice: 5 * time.Minute, total: time.Hour, want: "5m"},
+		{slice: 7 * time.Minute, total: time.Hour, want: "5m"},
+		{slice: 30 * time.Second, total: time.Hour, want: "1m"},
+		{slice: 90 * time.Minute, total: 6 * time.Hour, want: "1h"},
+		// 1m bars would take 600 per call
+		{slice: time.Minute, total: 10 * time.Hour, want: "5m"},
+		{slice: 48 * time.Hour, total: 10 * 24 * time.Hour, want: "1d"},
+	}
+	for _, tt := range tests {
+		if got := pickInterval(tt.slice, tt.total); got != tt.want {
+			t.Errorf("pickInterval(%v, %v) = %q, want %q", tt.slice, tt.total, got, tt.want)
+		}
+	}
+}
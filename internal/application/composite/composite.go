@@ -0,0 +1,118 @@
+package composite
+
+import (
+	"strconv"
+	"time"
+
+	"trading-bot/internal/application/trigger"
+	"trading-bot/internal/domain/model"
+)
+
+// Kind distinguishes the composite order flavours.
+type Kind string
+
+const (
+	// OCO protects an existing position with a take-profit limit order and
+	// a protective stop; whichever executes first cancels the other.
+	OCO Kind = "OCO"
+	// Bracket places an entry order first and attaches an OCO pair to
+	// whatever quantity of the entry fills.
+	Bracket Kind = "BRACKET"
+)
+
+// Status is the lifecycle stage of a composite order.
+type Status string
+
+const (
+	WaitingEntry Status = "WAITING_ENTRY" // bracket entry not filled yet
+	Active       Status = "ACTIVE"        // exits are protecting a position
+	Completed    Status = "COMPLETED"     // the position was closed
+	Cancelled    Status = "CANCELLED"
+)
+
+// Order is the persisted state of an OCO or bracket order.
+//
+// Exit orders are sized to the open position: the protected quantity (the
+// OCO quantity, or what the bracket entry filled) minus what the take-profit
+// and the stop already executed. When the take-profit partially fills the
+// stop shrinks with it, and when the entry fills further the take-profit is
+// cancelled and replaced with the larger size.
+type Order struct {
+	ID              string          `json:"id"`
+	Kind            Kind            `json:"kind"`
+	Market          string          `json:"market"`
+	Side            model.OrderSide `json:"side"` // side of the exits: SELL closes a long
+	Quantity        string          `json:"quantity"`
+	EntryPrice      string          `json:"entry_price,omitempty"`
+	TakeProfitPrice string          `json:"take_profit_price"`
+	StopPrice       string          `json:"stop_price"`
+	StopLimitPrice  string          `json:"stop_limit_price,omitempty"` // stop-limit instead of stop-market
+	Status          Status          `json:"status"`
+	CreatedAt       time.Time       `json:"created_at"`
+
+	EntryID     string  `json:"entry_id,omitempty"`
+	EntryFilled float64 `json:"entry_filled,omitempty"`
+	EntryDone   bool    `json:"entry_done,omitempty"`
+
+	TakeProfitID     string  `json:"take_profit_id,omitempty"`
+	TakeProfitSize   float64 `json:"take_profit_size,omitempty"`   // quantity of the working take-profit
+	TakeProfitBooked float64 `json:"take_profit_booked,omitempty"` // executed by replaced take-profits
+	TakeProfitFilled float64 `json:"take_profit_filled,omitempty"` // executed by all take-profits
+
+	TriggerID  string    `json:"trigger_id,omitempty"` // the stop, saved as a trigger run-triggers fires
+	Stopped    bool      `json:"stopped,omitempty"`
+	StoppedAt  time.Time `json:"stopped_at,omitempty"`
+	StopID     string    `json:"stop_id,omitempty"`     // working stop order
+	StopBooked float64   `json:"stop_booked,omitempty"` // executed by earlier stop orders
+	StopFilled float64   `json:"stop_filled,omitempty"` // executed by all stop orders
+}
+
+// EntrySide returns the side of the bracket entry, opposite to the exits.
+func (o *Order) EntrySide() model.OrderSide {
+	if o.Side == model.Sell {
+		return model.Buy
+	}
+	return model.Sell
+}
+
+// Protected returns the position size the exits have to close.
+func (o *Order) Protected() float64 {
+	if o.Kind == Bracket {
+		return o.EntryFilled
+	}
+	return parse(o.Quantity)
+}
+
+// Open returns the part of the position not closed by any exit yet.
+func (o *Order) Open() float64 {
+	return o.Protected() - o.TakeProfitFilled - o.StopFilled
+}
+
+// Done reports whether the composite reached a final status.
+func (o *Order) Done() bool {
+	return o.Status == Completed || o.Status == Cancelled
+}
+
+// stop describes the protective leg for the given open quantity, so the
+// trigger rules decide when it fires and which order it sends.
+func (o *Order) stop(qty string) trigger.Trigger {
+	t := trigger.Trigger{
+		Type:      model.StopMarket,
+		Market:    o.Market,
+		Side:      o.Side,
+		Quantity:  qty,
+		StopPrice: o.StopPrice,
+		Source:    trigger.Last,
+	}
+	if o.StopLimitPrice != "" {
+		t.Type, t.LimitPrice = model.StopLimit, o.StopLimitPrice
+	}
+	return t
+}
+
+// parse converts an exchange decimal string to float64, treating
+// malformed input as zero.
+func parse(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
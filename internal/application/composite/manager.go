@@ -0,0 +1,475 @@
+package composite
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"trading-bot/internal/application/trigger"
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// Manager places and supervises OCO and bracket orders. The take-profit
+// and the entry rest on the exchange; the stop is saved as a trigger that
+// run-triggers sends as a market (or limit) order when the last traded
+// price reaches it, cancelling the take-profit first, so the position stays
+// protected when no Manager is running.
+type Manager struct {
+	Place    *usecase.PlaceOrder
+	Cancel   *usecase.CancelOrder
+	Get      *usecase.GetOrder
+	Triggers *trigger.Engine
+	Store    service.StateStore
+	Market   model.Market
+	Interval time.Duration // how often orders are checked
+
+	// StopTimeout is how long a stop-limit order may rest unfilled, e.g.
+	// after the price gapped through its limit, before it is cancelled and
+	// the rest of the position closed at market. Default 1m.
+	StopTimeout time.Duration
+}
+
+// Submit validates o, sends the bracket entry if there is one and saves it.
+func (m *Manager) Submit(ctx context.Context, o Order) (*Order, error) {
+	if err := m.validate(&o); err != nil {
+		return nil, err
+	}
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("composite: failed to generate ID: %w", err)
+	}
+	o.ID = strings.ToLower(string(o.Kind)) + "-" + hex.EncodeToString(id)
+	o.CreatedAt = time.Now()
+	o.Status = Active
+
+	if o.Kind == Bracket {
+		entry, err := m.Place.Execute(ctx, model.Order{
+			MarketSymbol: o.Market,
+			Side:         o.EntrySide(),
+			Type:         model.Limit,
+			Price:        o.EntryPrice,
+			Quantity:     o.Quantity,
+		})
+		if err != nil {
+			return nil, err
+		}
+		o.EntryID = entry.ID
+		o.Status = WaitingEntry
+	}
+	return &o, m.save(&o)
+}
+
+// Load reads a saved composite order by ID.
+func (m *Manager) Load(id string) (*Order, error) {
+	var o Order
+	found, err := m.Store.Load(key(id), &o)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("composite: %q not found", id)
+	}
+	return &o, nil
+}
+
+// Run supervises o until it completes or ctx is cancelled: it sizes the
+// take-profit and the stop trigger to the open position, and makes sure a
+// fired stop closes all of it. Resting orders and the stop trigger stay in
+// place when ctx is cancelled.
+func (m *Manager) Run(ctx context.Context, o *Order) error {
+	if o.Done() {
+		return fmt.Errorf("composite: %s is already %s", o.ID, o.Status)
+	}
+	interval := m.Interval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if saved, err := m.Load(o.ID); err == nil && saved.Status == Cancelled {
+			*o = *saved // aborted or disarmed by another process
+			return nil
+		}
+		if err := m.step(ctx, o); err != nil {
+			slog.Warn("composite: step failed", "order", o.ID, "err", err)
+		}
+		if o.Done() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Abort cancels the entry and the take-profit and marks o cancelled.
+// Whatever already executed stays executed.
+func (m *Manager) Abort(ctx context.Context, o *Order) error {
+	if o.Done() {
+		return fmt.Errorf("composite: %s is already %s", o.ID, o.Status)
+	}
+	if o.Kind == Bracket && !o.EntryDone {
+		final, err := m.pull(ctx, o.EntryID)
+		if err != nil {
+			return err
+		}
+		o.EntryFilled, o.EntryDone = parse(final.QuantityExecuted), true
+	}
+	if err := m.pullTakeProfit(ctx, o); err != nil {
+		return err
+	}
+	if err := m.disarm(o); err != nil {
+		return err
+	}
+	o.Status = Cancelled
+	return m.save(o)
+}
+
+// DisarmAll marks every composite order still working as cancelled and
+// cancels its pending stop trigger, so that none resumes after the kill
+// switch is rearmed. Its resting orders are left to the caller, which
+// cancels every active order anyway. It returns the cancelled composites.
+func (m *Manager) DisarmAll(reason string) ([]model.Order, error) {
+	keys, err := m.Store.Keys(key(""))
+	if err != nil {
+		return nil, err
+	}
+	var out []model.Order
+	var errs []error
+	for _, k := range keys {
+		o, err := m.Load(strings.TrimPrefix(k, key("")))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if o.Done() {
+			continue
+		}
+		if err := m.disarm(o); err != nil {
+			errs = append(errs, fmt.Errorf("composite %s: %w", o.ID, err))
+		}
+		o.Status = Cancelled
+		if err := m.save(o); err != nil {
+			errs = append(errs, fmt.Errorf("composite %s: %w", o.ID, err))
+			continue
+		}
+		slog.Warn("composite: disarmed", "order", o.ID, "reason", reason)
+		out = append(out, model.Order{ID: o.ID, MarketSymbol: o.Market, Side: o.Side, Quantity: o.Quantity})
+	}
+	return out, errors.Join(errs...)
+}
+
+// step refreshes every leg of o and reacts to what changed.
+func (m *Manager) step(ctx context.Context, o *Order) error {
+	if o.Kind == Bracket && !o.EntryDone {
+		entry, err := m.Get.Execute(ctx, o.EntryID)
+		if err != nil {
+			return err
+		}
+		if filled := parse(entry.QuantityExecuted); filled != o.EntryFilled {
+			slog.Info("composite: entry filled", "order", o.ID, "filled", filled, "quantity", o.Quantity)
+			o.EntryFilled = filled
+		}
+		o.EntryDone = entry.State.Final()
+		switch {
+		case o.EntryDone && o.EntryFilled == 0:
+			o.Status = Cancelled
+			return m.save(o)
+		case o.EntryFilled > 0:
+			o.Status = Active
+		default:
+			return m.save(o)
+		}
+	}
+
+	if o.TakeProfitID != "" {
+		tp, err := m.Get.Execute(ctx, o.TakeProfitID)
+		if err != nil {
+			return err
+		}
+		o.TakeProfitFilled = o.TakeProfitBooked + parse(tp.QuantityExecuted)
+		if tp.State.Final() {
+			o.TakeProfitBooked, o.TakeProfitID = o.TakeProfitFilled, ""
+		}
+	}
+	var sl *model.Order
+	if o.StopID != "" {
+		var err error
+		if sl, err = m.Get.Execute(ctx, o.StopID); err != nil {
+			return err
+		}
+		o.StopFilled = o.StopBooked + parse(sl.QuantityExecuted)
+	}
+
+	entryPending := o.Kind == Bracket && !o.EntryDone
+	if !entryPending && m.negligible(o.Open()) {
+		if err := m.pullTakeProfit(ctx, o); err != nil {
+			return err
+		}
+		if err := m.disarm(o); err != nil {
+			return err
+		}
+		o.Status = Completed
+		slog.Info("composite: completed", "order", o.ID, "take_profit_filled", o.TakeProfitFilled, "stop_filled", o.StopFilled)
+		return m.save(o)
+	}
+	if m.negligible(o.Open()) {
+		return m.save(o)
+	}
+	if o.Stopped {
+		return m.closeStopped(ctx, o, sl)
+	}
+	return m.watchStop(ctx, o)
+}
+
+// watchStop follows the stop trigger: while it is pending, the take-profit
+// and the trigger are kept sized to the open position; once run-triggers
+// fired it, o is marked stopped and closeStopped takes over on the next
+// step.
+func (m *Manager) watchStop(ctx context.Context, o *Order) error {
+	if o.TriggerID == "" {
+		if err := m.sizeTakeProfit(ctx, o); err != nil {
+			return err
+		}
+		return m.armStop(o)
+	}
+	t, err := m.Triggers.Get(o.TriggerID)
+	if err != nil {
+		return err
+	}
+	switch t.Status {
+	case trigger.Pending:
+		if err := m.sizeTakeProfit(ctx, o); err != nil {
+			return err
+		}
+		return m.armStop(o)
+	case trigger.Triggered:
+		if t.OrderID == "" {
+			return m.save(o) // run-triggers is sending it
+		}
+		o.StopID = t.OrderID
+		slog.Info("composite: stop fired", "order", o.ID, "side", t.Side, "quantity", t.Quantity, "stop_order", t.OrderID)
+	case trigger.Failed:
+		// no stop order: closeStopped sends the position at market
+		slog.Warn("composite: stop order rejected, closing at market", "order", o.ID, "trigger", t.ID, "err", t.Error)
+	case trigger.Cancelled:
+		slog.Warn("composite: stop trigger cancelled, cancelling the order", "order", o.ID, "trigger", t.ID)
+		if err := m.pullTakeProfit(ctx, o); err != nil {
+			return err
+		}
+		o.Status = Cancelled
+		return m.save(o)
+	}
+	// run-triggers cancelled the take-profit before sending the stop: book
+	// what it executed
+	if err := m.pullTakeProfit(ctx, o); err != nil {
+		return err
+	}
+	o.Stopped, o.StoppedAt = true, t.TriggeredAt
+	return m.save(o)
+}
+
+// armStop saves the stop as a trigger paired with the take-profit, or
+// updates the saved one, for the open position.
+func (m *Manager) armStop(o *Order) error {
+	qty := m.Market.AlignQuantity(o.Open())
+	executed := o.TakeProfitFilled - o.TakeProfitBooked // by the working take-profit
+	if o.TriggerID == "" {
+		t := o.stop(qty)
+		t.Parent, t.OCO, t.OCOExecuted = o.ID, o.TakeProfitID, executed
+		added, err := m.Triggers.Add(t)
+		if err != nil {
+			return fmt.Errorf("stop %s: %w", o.ID, err)
+		}
+		o.TriggerID = added.ID
+		slog.Info("composite: stop armed", "order", o.ID, "trigger", added.ID, "quantity", qty, "stop", o.StopPrice)
+		return m.save(o)
+	}
+	_, err := m.Triggers.Update(o.TriggerID, func(t *trigger.Trigger) bool {
+		if t.Quantity == qty && t.OCO == o.TakeProfitID && t.OCOExecuted == executed {
+			return false
+		}
+		t.Quantity, t.OCO, t.OCOExecuted = qty, o.TakeProfitID, executed
+		return true
+	})
+	return err
+}
+
+// closeStopped makes sure a fired stop closes the whole open position: a
+// stop order that closed without doing so, or a stop-limit resting for
+// longer than StopTimeout, is replaced with a market order for the rest.
+func (m *Manager) closeStopped(ctx context.Context, o *Order, sl *model.Order) error {
+	if sl != nil {
+		if !sl.State.Final() {
+			if o.StopLimitPrice == "" || time.Since(o.StoppedAt) < m.stopTimeout() {
+				return m.save(o)
+			}
+			final, err := m.pull(ctx, o.StopID)
+			if err != nil {
+				return err
+			}
+			sl = final
+		}
+		o.StopBooked += parse(sl.QuantityExecuted)
+		o.StopFilled, o.StopID = o.StopBooked, ""
+	}
+	open := m.Market.AlignQuantity(o.Open())
+	if m.negligible(parse(open)) {
+		return m.save(o) // completed on the next step
+	}
+	mkt, err := m.Place.Execute(ctx, model.Order{
+		MarketSymbol: o.Market,
+		Side:         o.Side,
+		Type:         model.MarketOrder,
+		Quantity:     open,
+	})
+	if err != nil {
+		m.save(o)
+		return fmt.Errorf("stop %s: %w", o.ID, err)
+	}
+	o.StopID, o.StoppedAt = mkt.ID, time.Now()
+	slog.Warn("composite: stop did not close the position, sent the rest at market", "order", o.ID,
+		"quantity", open, "stop_order", mkt.ID)
+	return m.save(o)
+}
+
+// disarm cancels the stop trigger of o if it has not fired.
+func (m *Manager) disarm(o *Order) error {
+	if o.TriggerID == "" {
+		return nil
+	}
+	t, err := m.Triggers.Get(o.TriggerID)
+	if err != nil || t.Status != trigger.Pending {
+		return err
+	}
+	return m.Triggers.Cancel(t.ID)
+}
+
+func (m *Manager) stopTimeout() time.Duration {
+	if m.StopTimeout > 0 {
+		return m.StopTimeout
+	}
+	return time.Minute
+}
+
+// sizeTakeProfit keeps one take-profit resting for the open quantity,
+// replacing it when the entry filled further since it was placed.
+func (m *Manager) sizeTakeProfit(ctx context.Context, o *Order) error {
+	open := o.Open()
+	if o.TakeProfitID != "" {
+		working := o.TakeProfitSize - (o.TakeProfitFilled - o.TakeProfitBooked)
+		if m.negligible(math.Abs(working - open)) {
+			return m.save(o)
+		}
+		slog.Info("composite: resizing take-profit", "order", o.ID, "from", working, "to", open)
+		if err := m.pullTakeProfit(ctx, o); err != nil {
+			return err
+		}
+		open = o.Open()
+	}
+	qty := m.Market.AlignQuantity(open)
+	if m.negligible(parse(qty)) {
+		return m.save(o)
+	}
+	tp, err := m.Place.Execute(ctx, model.Order{
+		MarketSymbol: o.Market,
+		Side:         o.Side,
+		Type:         model.Limit,
+		Price:        o.TakeProfitPrice,
+		Quantity:     qty,
+	})
+	if err != nil {
+		m.save(o)
+		return fmt.Errorf("take-profit %s: %w", o.ID, err)
+	}
+	o.TakeProfitID, o.TakeProfitSize = tp.ID, parse(qty)
+	return m.save(o)
+}
+
+// pullTakeProfit cancels the working take-profit, if any, and books
+// everything it executed.
+func (m *Manager) pullTakeProfit(ctx context.Context, o *Order) error {
+	if o.TakeProfitID == "" {
+		return nil
+	}
+	final, err := m.pull(ctx, o.TakeProfitID)
+	if err != nil {
+		return err
+	}
+	o.TakeProfitBooked += parse(final.QuantityExecuted)
+	o.TakeProfitFilled = o.TakeProfitBooked
+	o.TakeProfitID, o.TakeProfitSize = "", 0
+	return nil
+}
+
+// pull cancels order id unless it already finished and returns its final
+// state, so executions that raced the cancel are not lost.
+func (m *Manager) pull(ctx context.Context, id string) (*model.Order, error) {
+	o, err := m.Get.Execute(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if o.State.Final() {
+		return o, nil
+	}
+	if err := m.Cancel.Execute(ctx, id); err != nil {
+		return nil, fmt.Errorf("cancel %s: %w", id, err)
+	}
+	return m.Get.Execute(ctx, id)
+}
+
+// validate checks that the exit prices sit on the right sides.
+func (m *Manager) validate(o *Order) error {
+	if o.Kind != OCO && o.Kind != Bracket {
+		return fmt.Errorf("composite: unknown kind %q", o.Kind)
+	}
+	if o.Side != model.Buy && o.Side != model.Sell {
+		return fmt.Errorf("composite: invalid side %q", o.Side)
+	}
+	qty, tp, stop := parse(o.Quantity), parse(o.TakeProfitPrice), parse(o.StopPrice)
+	if m.negligible(qty) || tp <= 0 || stop <= 0 {
+		return errors.New("composite: quantity, take-profit price and stop price are required")
+	}
+	long := o.Side == model.Sell
+	if (long && tp <= stop) || (!long && tp >= stop) {
+		return errors.New("composite: the take-profit must be on the profitable side of the stop")
+	}
+	if o.Kind == Bracket {
+		entry := parse(o.EntryPrice)
+		if entry <= 0 {
+			return errors.New("composite: a bracket needs an entry price")
+		}
+		if (long && (entry <= stop || entry >= tp)) || (!long && (entry >= stop || entry <= tp)) {
+			return errors.New("composite: the entry price must lie between the stop and the take-profit")
+		}
+	}
+	return nil
+}
+
+// negligible reports whether q is too small to be sent as an order.
+func (m *Manager) negligible(q float64) bool {
+	min := parse(m.Market.QuantityMin)
+	if min <= 0 {
+		min = parse(m.Market.QuantityIncrement)
+	}
+	return q <= 1e-12 || q < min
+}
+
+func (m *Manager) save(o *Order) error {
+	return m.Store.Save(key(o.ID), o)
+}
+
+func key(id string) string {
+	return "composite-" + id
+}
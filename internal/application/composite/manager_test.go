@@ -0,0 +1,273 @@
+package composite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"trading-bot/internal/application/trigger"
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+func TestStopFiredByTriggerEngine(t *testing.T) {
+	ex := newFakeExchange()
+	mgr, engine := newManager(ex)
+	o, err := mgr.Submit(context.Background(), Order{
+		Kind: OCO, Market: "BTCBRL", Side: model.Sell, Quantity: "1.0000",
+		TakeProfitPrice: "120", StopPrice: "90",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first step places the take-profit and arms the stop against it
+	if err := mgr.step(context.Background(), o); err != nil {
+		t.Fatal(err)
+	}
+	if o.TakeProfitID == "" || o.TriggerID == "" {
+		t.Fatalf("take-profit %q, trigger %q: want both", o.TakeProfitID, o.TriggerID)
+	}
+	armed, err := engine.Get(o.TriggerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if armed.Quantity != "1.0000" || armed.OCO != o.TakeProfitID || armed.Parent != o.ID {
+		t.Errorf("armed %+v, want 1.0000 paired with %s", armed, o.TakeProfitID)
+	}
+
+	// the take-profit executes 0.3 behind the manager's back, then the
+	// price falls through the stop while only run-triggers is running
+	ex.execute(o.TakeProfitID, "0.3", model.StatePartiallyFilled)
+	ex.last = "89"
+	if err := engine.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := ex.orders[o.TakeProfitID].State; got != model.StateCancelled {
+		t.Errorf("take-profit %s, want it cancelled before the stop", got)
+	}
+	stop := ex.lastPlaced()
+	if stop.Type != model.MarketOrder || stop.Quantity != "0.7" {
+		t.Errorf("stop sent %s %s, want MARKET 0.7", stop.Type, stop.Quantity)
+	}
+
+	// the manager adopts the stop order and completes once it fills
+	if err := mgr.step(context.Background(), o); err != nil {
+		t.Fatal(err)
+	}
+	if !o.Stopped || o.StopID != stop.ID || o.TakeProfitFilled != 0.3 {
+		t.Fatalf("after the stop: %+v", o)
+	}
+	ex.execute(stop.ID, "0.7", model.StateFilled)
+	for i := 0; i < 2 && !o.Done(); i++ {
+		if err := mgr.step(context.Background(), o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if o.Status != Completed || o.StopFilled != 0.7 {
+		t.Errorf("status %s, stop filled %v: want COMPLETED after 0.7", o.Status, o.StopFilled)
+	}
+}
+
+func TestStopLimitRestingIsClosedAtMarket(t *testing.T) {
+	tests := []struct {
+		name     string
+		executed string
+		state    model.OrderState
+		age      time.Duration
+		want     string // quantity sent at market, empty if none
+	}{
+		{name: "resting within the timeout", executed: "0", state: model.StateActive, age: time.Second},
+		{name: "resting past the timeout", executed: "0", state: model.StateActive, age: 2 * time.Minute, want: "1.0000"},
+		{name: "partly filled past the timeout", executed: "0.4", state: model.StatePartiallyFilled, age: 2 * time.Minute, want: "0.6000"},
+		{name: "cancelled after a partial fill", executed: "0.4", state: model.StateCancelled, age: time.Second, want: "0.6000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := newFakeExchange()
+			mgr, _ := newManager(ex)
+			stop, _ := ex.CreateOrder(context.Background(), model.Order{MarketSymbol: "BTCBRL", Side: model.Sell, Type: model.Limit, Price: "85", Quantity: "1"})
+			ex.execute(stop.ID, tt.executed, tt.state)
+			o := &Order{
+				ID: "oco-1", Kind: OCO, Market: "BTCBRL", Side: model.Sell, Quantity: "1",
+				StopPrice: "90", StopLimitPrice: "85", Status: Active,
+				Stopped: true, StoppedAt: time.Now().Add(-tt.age), StopID: stop.ID,
+			}
+			placed := len(ex.placed)
+			if err := mgr.step(context.Background(), o); err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if len(ex.placed) != placed {
+					t.Errorf("sent %+v, want nothing", ex.lastPlaced())
+				}
+				return
+			}
+			if len(ex.placed) != placed+1 {
+				t.Fatalf("sent %d orders, want one", len(ex.placed)-placed)
+			}
+			got := ex.lastPlaced()
+			if got.Type != model.MarketOrder || got.Quantity != tt.want {
+				t.Errorf("sent %s %s, want MARKET %s", got.Type, got.Quantity, tt.want)
+			}
+			if ex.orders[stop.ID].State.Working() {
+				t.Error("the stop-limit is still working")
+			}
+			if o.StopID != got.ID || o.StopFilled != parse(tt.executed) {
+				t.Errorf("stop %s filled %v, want %s filled %s", o.StopID, o.StopFilled, got.ID, tt.executed)
+			}
+		})
+	}
+}
+
+func TestDisarmAll(t *testing.T) {
+	ex := newFakeExchange()
+	mgr, engine := newManager(ex)
+	o, err := mgr.Submit(context.Background(), Order{
+		Kind: OCO, Market: "BTCBRL", Side: model.Sell, Quantity: "1.0000",
+		TakeProfitPrice: "120", StopPrice: "90",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.step(context.Background(), o); err != nil {
+		t.Fatal(err)
+	}
+
+	disarmed, err := engine.DisarmAll("panic")
+	if err != nil || len(disarmed) != 1 {
+		t.Fatalf("triggers disarmed %+v, %v: want the stop", disarmed, err)
+	}
+	disarmed, err = mgr.DisarmAll("panic")
+	if err != nil || len(disarmed) != 1 || disarmed[0].ID != o.ID {
+		t.Fatalf("composites disarmed %+v, %v: want %s", disarmed, err, o.ID)
+	}
+	stop, err := engine.Get(o.TriggerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stop.Status != trigger.Cancelled {
+		t.Errorf("stop %s, want CANCELLED", stop.Status)
+	}
+	// a manager still running notices and stops without touching anything
+	placed := len(ex.placed)
+	if err := mgr.Run(context.Background(), o); err != nil {
+		t.Fatal(err)
+	}
+	if o.Status != Cancelled || len(ex.placed) != placed {
+		t.Errorf("status %s after %d new orders, want CANCELLED and none", o.Status, len(ex.placed)-placed)
+	}
+	if again, _ := mgr.DisarmAll("panic"); len(again) != 0 {
+		t.Errorf("disarmed %+v again", again)
+	}
+}
+
+func newManager(ex *fakeExchange) (*Manager, *trigger.Engine) {
+	store := memStore{}
+	engine := &trigger.Engine{
+		Place:       &usecase.PlaceOrder{Ex: ex},
+		Ticker:      &usecase.FetchTicker{Ex: ex},
+		Store:       store,
+		GetOrder:    &usecase.GetOrder{Ex: ex},
+		CancelOrder: &usecase.CancelOrder{Ex: ex},
+	}
+	return &Manager{
+		Place:    &usecase.PlaceOrder{Ex: ex},
+		Cancel:   &usecase.CancelOrder{Ex: ex},
+		Get:      &usecase.GetOrder{Ex: ex},
+		Triggers: engine,
+		Store:    store,
+		Market: model.Market{
+			Symbol: "BTCBRL", PriceIncrement: "1", QuantityMin: "0.0001",
+			QuantityIncrement: "0.0001", QuantityPrecision: 4,
+		},
+	}, engine
+}
+
+// fakeExchange keeps the orders placed on it, which only change when a
+// test executes or cancels them.
+type fakeExchange struct {
+	service.Exchange
+	last   string
+	orders map[string]*model.Order
+	placed []string
+}
+
+func newFakeExchange() *fakeExchange {
+	return &fakeExchange{last: "100", orders: map[string]*model.Order{}}
+}
+
+func (f *fakeExchange) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
+	o.ID = fmt.Sprint(len(f.placed) + 1)
+	o.State, o.QuantityExecuted = model.StateActive, "0"
+	f.orders[o.ID] = &o
+	f.placed = append(f.placed, o.ID)
+	c := o
+	return &c, nil
+}
+
+func (f *fakeExchange) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
+	o, ok := f.orders[id]
+	if !ok {
+		return nil, service.ErrOrderNotFound
+	}
+	c := *o
+	return &c, nil
+}
+
+func (f *fakeExchange) CancelOrder(ctx context.Context, id string) error {
+	o, ok := f.orders[id]
+	if !ok || o.State.Final() {
+		return fmt.Errorf("cannot cancel %s", id)
+	}
+	o.State = model.StateCancelled
+	return nil
+}
+
+func (f *fakeExchange) GetTicker(ctx context.Context, market string) (*model.Ticker, error) {
+	return &model.Ticker{MarketSymbol: market, Last: f.last}, nil
+}
+
+func (f *fakeExchange) execute(id, qty string, state model.OrderState) {
+	f.orders[id].QuantityExecuted, f.orders[id].State = qty, state
+}
+
+func (f *fakeExchange) lastPlaced() model.Order {
+	return *f.orders[f.placed[len(f.placed)-1]]
+}
+
+// memStore is an in-memory service.StateStore.
+type memStore map[string][]byte
+
+func (m memStore) Load(key string, dest interface{}) (bool, error) {
+	b, ok := m[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(b, dest)
+}
+
+func (m memStore) Save(key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	m[key] = b
+	return err
+}
+
+func (m memStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m memStore) Keys(prefix string) ([]string, error) {
+	var keys []string
+	for k := range m {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
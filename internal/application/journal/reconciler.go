@@ -0,0 +1,259 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// Discrepancy kinds.
+type Kind string
+
+const (
+	Orphan  Kind = "ORPHAN"  // active on the exchange, never journaled
+	Filled  Kind = "FILLED"  // executed more than the journal knows
+	Closed  Kind = "CLOSED"  // finished on the exchange without filling more, e.g. cancelled elsewhere
+	Missing Kind = "MISSING" // open in the journal, unknown to the exchange
+)
+
+// Action is what the reconciler does about a discrepancy.
+type Action string
+
+const (
+	Report Action = "report" // only emit the discrepancy
+	Repair Action = "repair" // bring the journal in line with the exchange
+	Cancel Action = "cancel" // cancel the order on the exchange (orphans only)
+)
+
+// Policy chooses the action for each kind of discrepancy. Repairing an
+// orphan adopts it into the journal, repairing a fill or a close records
+// the exchange's view of the order and repairing a missing order marks it
+// cancelled.
+type Policy struct {
+	Orphans Action
+	Drift   Action // Filled and Closed
+	Missing Action
+}
+
+// DefaultPolicy trusts the exchange about the orders the journal knows
+// but leaves unknown and missing orders to a human.
+var DefaultPolicy = Policy{Orphans: Report, Drift: Repair, Missing: Report}
+
+// ParseAction converts a policy flag value to an Action.
+func ParseAction(s string) (Action, error) {
+	switch a := Action(strings.ToLower(s)); a {
+	case Report, Repair, Cancel:
+		return a, nil
+	}
+	return "", fmt.Errorf("journal: unknown action %q, use report, repair or cancel", s)
+}
+
+// Validate rejects actions that make no sense for their kind.
+func (p Policy) Validate() error {
+	for _, a := range []Action{p.Orphans, p.Drift, p.Missing} {
+		if _, err := ParseAction(string(a)); err != nil {
+			return err
+		}
+	}
+	if p.Drift == Cancel || p.Missing == Cancel {
+		return errors.New("journal: only orphans can be cancelled")
+	}
+	return nil
+}
+
+// Discrepancy is one difference between the journal and the exchange.
+type Discrepancy struct {
+	Kind    Kind
+	Market  string
+	OrderID string
+	Local   *model.Order // latest journal snapshot, nil for orphans
+	Remote  *model.Order // exchange view, nil for missing orders
+	Action  Action       // what was done about it
+	Err     error        // why the action failed
+}
+
+// Reconciler compares the orders the journal believes open with the
+// exchange and acts on the differences according to Policy.
+//
+// Ex must not be wrapped in a Recorder: reading through one would
+// silently repair the journal whatever the policy says.
+type Reconciler struct {
+	Ex      service.Exchange
+	Journal service.Journal
+	Policy  Policy
+	// OrphanGrace is how old an order unknown to the journal must be to
+	// count as an orphan, so that one placed by another command is not
+	// acted on before its answer is journaled; 1m when zero. Orders the
+	// exchange reports no creation time for are taken as old enough.
+	OrphanGrace time.Duration
+	// OnDiscrepancy, if set, is called for every discrepancy found.
+	OnDiscrepancy func(Discrepancy)
+}
+
+// Run reconciles market (all markets when empty) every interval until ctx
+// is cancelled.
+func (r *Reconciler) Run(ctx context.Context, market string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.Reconcile(ctx, market); err != nil {
+			slog.Warn("journal: reconcile failed", "market", market, "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile makes one pass over market (all markets when empty) and
+// returns the discrepancies found. Orders that cannot be checked are
+// skipped and reported in the error; the rest are still reconciled.
+func (r *Reconciler) Reconcile(ctx context.Context, market string) ([]Discrepancy, error) {
+	local, err := r.Journal.Open(market)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := r.Ex.GetActiveOrders(ctx, market)
+	if err != nil {
+		return nil, err
+	}
+	open := make(map[string]model.Order, len(local))
+	for _, o := range local {
+		open[o.ID] = o
+	}
+
+	var out []Discrepancy
+	var errs []error
+	active := make(map[string]bool, len(remote))
+	for _, o := range remote {
+		active[o.ID] = true
+		if l, ok := open[o.ID]; ok {
+			out = r.compare(out, l, o)
+			continue
+		}
+		last, err := r.Journal.Last(o.ID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if last == nil {
+			if r.young(o) {
+				continue
+			}
+			out = r.orphan(ctx, out, o)
+			continue
+		}
+		out = r.compare(out, *last, o)
+	}
+	for _, l := range local {
+		if active[l.ID] {
+			continue
+		}
+		o, err := r.Ex.GetOrderByID(ctx, l.ID)
+		if errors.Is(err, service.ErrOrderNotFound) {
+			out = r.missing(out, l)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("order %s: %w", l.ID, err))
+			continue
+		}
+		out = r.compare(out, l, *o)
+	}
+	return out, errors.Join(errs...)
+}
+
+// compare records how remote differs from the journal snapshot local.
+// Plain state updates, e.g. an order the journal only saw being accepted
+// now reported ACTIVE, or one we cancelled now reported CANCELLED, are
+// journaled without a discrepancy.
+func (r *Reconciler) compare(out []Discrepancy, local, remote model.Order) []Discrepancy {
+	entries := changes(&local, remote)
+	if len(entries) == 0 {
+		return out
+	}
+	var kind Kind
+	switch {
+	case parse(remote.QuantityExecuted) > parse(local.QuantityExecuted):
+		kind = Filled
+	case remote.State.Final() && !(local.State == model.StatePendingCancel && remote.State == model.StateCancelled):
+		kind = Closed
+	default:
+		r.append(entries...)
+		return out
+	}
+	d := Discrepancy{Kind: kind, Market: remote.MarketSymbol, OrderID: remote.ID, Local: &local, Remote: &remote, Action: r.Policy.Drift}
+	if d.Action == Repair {
+		d.Err = r.Journal.Append(entries...)
+	}
+	return r.emit(out, d)
+}
+
+// young reports whether o was created within the orphan grace period.
+func (r *Reconciler) young(o model.Order) bool {
+	grace := r.OrphanGrace
+	if grace <= 0 {
+		grace = time.Minute
+	}
+	return !o.CreatedAt.IsZero() && time.Since(o.CreatedAt) < grace
+}
+
+func (r *Reconciler) orphan(ctx context.Context, out []Discrepancy, remote model.Order) []Discrepancy {
+	d := Discrepancy{Kind: Orphan, Market: remote.MarketSymbol, OrderID: remote.ID, Remote: &remote, Action: r.Policy.Orphans}
+	if d.Action != Report {
+		// the command that placed it may have journaled it since the
+		// listing: look again right before acting
+		last, err := r.Journal.Last(remote.ID)
+		if err != nil {
+			d.Err = err
+			return r.emit(out, d)
+		}
+		if last != nil {
+			return out
+		}
+	}
+	switch d.Action {
+	case Repair:
+		d.Err = r.Journal.Append(entry(model.JournalAdopted, remote))
+	case Cancel:
+		d.Err = r.Ex.CancelOrder(ctx, remote.ID)
+		r.append(cancel(remote.ID, remote.MarketSymbol, d.Err))
+	}
+	return r.emit(out, d)
+}
+
+func (r *Reconciler) missing(out []Discrepancy, local model.Order) []Discrepancy {
+	d := Discrepancy{Kind: Missing, Market: local.MarketSymbol, OrderID: local.ID, Local: &local, Action: r.Policy.Missing}
+	if d.Action == Repair {
+		closed := local
+		if _, err := closed.Transition(model.StateCancelled); err != nil {
+			d.Err = err
+			return r.emit(out, d)
+		}
+		e := entry(model.JournalState, closed)
+		e.Error = "not found on the exchange"
+		d.Err = r.Journal.Append(e)
+	}
+	return r.emit(out, d)
+}
+
+func (r *Reconciler) emit(out []Discrepancy, d Discrepancy) []Discrepancy {
+	if r.OnDiscrepancy != nil {
+		r.OnDiscrepancy(d)
+	}
+	return append(out, d)
+}
+
+func (r *Reconciler) append(entries ...model.JournalEntry) {
+	if err := r.Journal.Append(entries...); err != nil {
+		slog.Error("journal: append failed", "err", err)
+	}
+}
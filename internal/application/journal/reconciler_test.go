@@ -0,0 +1,68 @@
+package journal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"trading-bot/internal/domain/model"
+)
+
+func TestReconcileOrphans(t *testing.T) {
+	tests := []struct {
+		name       string
+		age        time.Duration // zero: the exchange reports no creation time
+		journaled  bool          // journaled by its command during the pass
+		wantKind   Kind          // empty if no discrepancy
+		wantCancel bool
+	}{
+		{name: "old orphan", age: 5 * time.Minute, wantKind: Orphan, wantCancel: true},
+		{name: "unknown age", wantKind: Orphan, wantCancel: true},
+		{name: "within the grace period", age: 10 * time.Second},
+		{name: "journaled meanwhile", age: 5 * time.Minute, journaled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := model.Order{ID: "x", MarketSymbol: "BTCBRL", Quantity: "1", QuantityExecuted: "0", State: model.StateActive}
+			if tt.age > 0 {
+				o.CreatedAt = time.Now().Add(-tt.age)
+			}
+			ex := &fakeExchange{orders: map[string]model.Order{o.ID: o}}
+			j := &racyJournal{id: o.ID, journaled: tt.journaled}
+			r := &Reconciler{Ex: ex, Journal: j, Policy: Policy{Orphans: Cancel, Drift: Repair, Missing: Report}}
+			ds, err := r.Reconcile(context.Background(), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var kind Kind
+			if len(ds) > 0 {
+				kind = ds[0].Kind
+			}
+			if kind != tt.wantKind {
+				t.Errorf("discrepancies %+v, want %q", ds, tt.wantKind)
+			}
+			if got := len(ex.cancelled) > 0; got != tt.wantCancel {
+				t.Errorf("cancelled %v, want %v", ex.cancelled, tt.wantCancel)
+			}
+		})
+	}
+}
+
+// racyJournal journals order id between the reconciler's first lookup and
+// the next one when journaled is set, as the command that placed it would.
+type racyJournal struct {
+	memJournal
+	id        string
+	journaled bool
+	lookups   int
+}
+
+func (j *racyJournal) Last(id string) (*model.Order, error) {
+	if id == j.id {
+		j.lookups++
+		if j.journaled && j.lookups == 2 {
+			j.Append(entry(model.JournalAccepted, model.Order{ID: id, MarketSymbol: "BTCBRL", State: model.StateActive}))
+		}
+	}
+	return j.memJournal.Last(id)
+}
@@ -0,0 +1,205 @@
+// Package journal records every order request, response, state change and
+// fill that goes through the exchange port.
+package journal
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// Recorder is a service.Exchange decorator that writes to a Journal.
+// State changes and fills are detected whenever an order is seen, by
+// comparing it with its latest journaled snapshot, so they are recorded by
+// whichever command happens to look at the order.
+//
+// A journal failure is logged and never fails the exchange call: losing a
+// history line is better than losing an order.
+type Recorder struct {
+	service.Exchange
+	Journal service.Journal
+}
+
+// CreateOrder implements Exchange.CreateOrder.
+func (r *Recorder) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
+	r.append(request(o))
+	placed, err := r.Exchange.CreateOrder(ctx, o)
+	r.append(r.result(o, placed, err))
+	return placed, err
+}
+
+// PlaceOrders implements Exchange.PlaceOrders.
+func (r *Recorder) PlaceOrders(ctx context.Context, orders []model.Order) []model.OrderResult {
+	var requests []model.JournalEntry
+	for _, o := range orders {
+		requests = append(requests, request(o))
+	}
+	r.append(requests...)
+	results := r.Exchange.PlaceOrders(ctx, orders)
+	var entries []model.JournalEntry
+	for i, res := range results {
+		placed := &res.Order
+		if res.Err != nil {
+			placed = nil
+		}
+		entries = append(entries, r.result(orders[i], placed, res.Err))
+	}
+	r.append(entries...)
+	return results
+}
+
+// CancelOrder implements Exchange.CancelOrder.
+func (r *Recorder) CancelOrder(ctx context.Context, id string) error {
+	err := r.Exchange.CancelOrder(ctx, id)
+	r.append(r.cancel(id, "", err))
+	return err
+}
+
+// CancelOrders implements Exchange.CancelOrders.
+func (r *Recorder) CancelOrders(ctx context.Context, ids []string) []model.OrderResult {
+	results := r.Exchange.CancelOrders(ctx, ids)
+	r.cancelled(results)
+	return results
+}
+
+// CancelAllOrders implements Exchange.CancelAllOrders.
+func (r *Recorder) CancelAllOrders(ctx context.Context, market string, side model.OrderSide) ([]model.OrderResult, error) {
+	results, err := r.Exchange.CancelAllOrders(ctx, market, side)
+	r.cancelled(results)
+	return results, err
+}
+
+// GetOrderByID implements Exchange.GetOrderByID.
+func (r *Recorder) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
+	o, err := r.Exchange.GetOrderByID(ctx, id)
+	if err == nil {
+		r.observe(*o)
+	}
+	return o, err
+}
+
+// GetActiveOrders implements Exchange.GetActiveOrders.
+func (r *Recorder) GetActiveOrders(ctx context.Context, market string) ([]model.Order, error) {
+	orders, err := r.Exchange.GetActiveOrders(ctx, market)
+	for _, o := range orders {
+		r.observe(o)
+	}
+	return orders, err
+}
+
+// observe journals what changed in o since its latest snapshot.
+func (r *Recorder) observe(o model.Order) {
+	if o.ID == "" {
+		return
+	}
+	last, err := r.Journal.Last(o.ID)
+	if err != nil {
+		slog.Error("journal: lookup failed", "order", o.ID, "err", err)
+		return
+	}
+	r.append(changes(last, o)...)
+}
+
+// changes returns the FILL and STATE entries that take an order from its
+// snapshot prev (nil if never journaled) to o.
+func changes(prev *model.Order, o model.Order) []model.JournalEntry {
+	var before model.Order
+	if prev != nil {
+		before = *prev
+	}
+	var entries []model.JournalEntry
+	if filled := parse(o.QuantityExecuted) - parse(before.QuantityExecuted); filled > 0 {
+		e := entry(model.JournalFill, o)
+		e.Filled = strconv.FormatFloat(filled, 'f', -1, 64)
+		entries = append(entries, e)
+	}
+	if o.State != before.State {
+		e := entry(model.JournalState, o)
+		if err := model.CheckTransition(before.State, o.State); err != nil {
+			// the exchange has the last word; keep its state but flag it
+			slog.Warn("journal: unexpected state change", "order", o.ID, "err", err)
+			e.Error = err.Error()
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// result is the entry recording the answer to a create request.
+func (r *Recorder) result(req model.Order, placed *model.Order, err error) model.JournalEntry {
+	if err != nil {
+		req.State = model.StateRejected
+		e := entry(model.JournalRejected, req)
+		e.Error = err.Error()
+		return e
+	}
+	return entry(model.JournalAccepted, *placed)
+}
+
+// request is the entry recording o about to be sent.
+func request(o model.Order) model.JournalEntry {
+	o.State = model.StatePendingSubmit
+	return entry(model.JournalRequest, o)
+}
+
+// entry records kind with a snapshot of o. Entries with an order ID also
+// become the latest snapshot of that order.
+func entry(kind model.JournalKind, o model.Order) model.JournalEntry {
+	return model.JournalEntry{Kind: kind, OrderID: o.ID, Market: o.MarketSymbol, Order: &o}
+}
+
+// cancelled journals the outcome of a batch cancel.
+func (r *Recorder) cancelled(results []model.OrderResult) {
+	var entries []model.JournalEntry
+	for _, res := range results {
+		entries = append(entries, r.cancel(res.Order.ID, res.Order.MarketSymbol, res.Err))
+	}
+	r.append(entries...)
+}
+
+// cancel is the entry recording the answer to a cancel request. An
+// accepted cancel moves the journaled order to PENDING_CANCEL, so it is
+// not taken for working until the exchange confirms it is cancelled.
+func (r *Recorder) cancel(id, market string, err error) model.JournalEntry {
+	e := cancel(id, market, err)
+	if err != nil {
+		return e
+	}
+	last, lerr := r.Journal.Last(id)
+	if lerr != nil {
+		slog.Error("journal: lookup failed", "order", id, "err", lerr)
+		return e
+	}
+	if last == nil || last.State.Final() {
+		return e
+	}
+	if _, terr := last.Transition(model.StatePendingCancel); terr == nil {
+		e.Order = last
+		e.Market = last.MarketSymbol
+	}
+	return e
+}
+
+func cancel(id, market string, err error) model.JournalEntry {
+	e := model.JournalEntry{Kind: model.JournalCancel, OrderID: id, Market: market}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return e
+}
+
+func (r *Recorder) append(entries ...model.JournalEntry) {
+	if err := r.Journal.Append(entries...); err != nil {
+		slog.Error("journal: append failed", "err", err)
+	}
+}
+
+// parse converts an exchange decimal string to float64, treating
+// malformed input as zero.
+func parse(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
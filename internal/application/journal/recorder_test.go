@@ -0,0 +1,136 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+func TestRecorderCancel(t *testing.T) {
+	tests := []struct {
+		name      string
+		cancelErr error
+		want      model.OrderState // latest snapshot after the cancel
+	}{
+		{name: "accepted", want: model.StatePendingCancel},
+		{name: "refused", cancelErr: errors.New("order already filled"), want: model.StateActive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := &fakeExchange{orders: map[string]model.Order{}, cancelErr: tt.cancelErr}
+			j := &memJournal{}
+			r := &Recorder{Exchange: ex, Journal: j}
+			o, err := r.CreateOrder(context.Background(), model.Order{MarketSymbol: "BTCBRL", Side: model.Buy, Type: model.Limit, Price: "100", Quantity: "1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.CancelOrder(context.Background(), o.ID)
+			last, _ := j.Last(o.ID)
+			if last == nil || last.State != tt.want || last.Quantity != "1" {
+				t.Fatalf("snapshot %+v, want %s", last, tt.want)
+			}
+			if tt.cancelErr != nil {
+				return
+			}
+
+			// the exchange confirms: a plain state update, not a discrepancy
+			ex.orders[o.ID] = model.Order{ID: o.ID, MarketSymbol: "BTCBRL", Quantity: "1", QuantityExecuted: "0", State: model.StateCancelled}
+			rec := &Reconciler{Ex: ex, Journal: j, Policy: DefaultPolicy}
+			ds, err := rec.Reconcile(context.Background(), "")
+			if err != nil || len(ds) != 0 {
+				t.Errorf("discrepancies %+v, %v: want none", ds, err)
+			}
+			if open, _ := j.Open(""); len(open) != 0 {
+				t.Errorf("still open: %+v", open)
+			}
+		})
+	}
+}
+
+// fakeExchange holds orders by ID; only the ones ACTIVE or PARTIALLY_FILLED
+// are listed as active.
+type fakeExchange struct {
+	service.Exchange
+	orders    map[string]model.Order
+	cancelErr error
+	cancelled []string
+}
+
+func (f *fakeExchange) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
+	o.ID = string(rune('a' + len(f.orders)))
+	o.State, o.QuantityExecuted = model.StateActive, "0"
+	f.orders[o.ID] = o
+	return &o, nil
+}
+
+func (f *fakeExchange) CancelOrder(ctx context.Context, id string) error {
+	if f.cancelErr != nil {
+		return f.cancelErr
+	}
+	f.cancelled = append(f.cancelled, id)
+	return nil
+}
+
+func (f *fakeExchange) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
+	o, ok := f.orders[id]
+	if !ok {
+		return nil, service.ErrOrderNotFound
+	}
+	return &o, nil
+}
+
+func (f *fakeExchange) GetActiveOrders(ctx context.Context, market string) ([]model.Order, error) {
+	var out []model.Order
+	for _, o := range f.orders {
+		if o.State == model.StateActive || o.State == model.StatePartiallyFilled {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
+
+// memJournal is an in-memory service.Journal.
+type memJournal struct {
+	entries []model.JournalEntry
+	last    map[string]model.Order
+}
+
+func (j *memJournal) Append(entries ...model.JournalEntry) error {
+	if j.last == nil {
+		j.last = make(map[string]model.Order)
+	}
+	for _, e := range entries {
+		e.Seq = uint64(len(j.entries) + 1)
+		j.entries = append(j.entries, e)
+		if e.Order != nil && e.OrderID != "" {
+			j.last[e.OrderID] = *e.Order
+		}
+	}
+	return nil
+}
+
+func (j *memJournal) Query(f model.JournalFilter) ([]model.JournalEntry, error) {
+	return j.entries, nil
+}
+
+func (j *memJournal) Last(id string) (*model.Order, error) {
+	o, ok := j.last[id]
+	if !ok {
+		return nil, nil
+	}
+	return &o, nil
+}
+
+func (j *memJournal) Open(market string) ([]model.Order, error) {
+	var out []model.Order
+	for _, o := range j.last {
+		if !o.State.Final() && (market == "" || strings.EqualFold(o.MarketSymbol, market)) {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
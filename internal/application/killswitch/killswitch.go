@@ -0,0 +1,126 @@
+package killswitch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// ErrEngaged is returned for orders sent while the kill switch is engaged.
+var ErrEngaged = errors.New("killswitch: trading is halted, re-arm before sending new orders")
+
+// State describes why and when the kill switch was engaged.
+type State struct {
+	EngagedAt time.Time `json:"engaged_at"`
+	Reason    string    `json:"reason"`
+}
+
+// Switch is a lock file shared by every process of the bot: while it
+// exists no order can be sent and running strategies stop.
+type Switch struct {
+	Path string
+}
+
+// Engage creates the lock file.
+func (s *Switch) Engage(reason string) error {
+	data, err := json.Marshal(State{EngagedAt: time.Now(), Reason: reason})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return fmt.Errorf("killswitch: %w", err)
+	}
+	if err := os.WriteFile(s.Path, data, 0o600); err != nil {
+		return fmt.Errorf("killswitch: %w", err)
+	}
+	return nil
+}
+
+// Rearm removes the lock file so orders can be sent again.
+func (s *Switch) Rearm() error {
+	if err := os.Remove(s.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("killswitch: %w", err)
+	}
+	return nil
+}
+
+// State returns the current state, or nil when the switch is not engaged.
+// An unreadable lock file still counts as engaged.
+func (s *Switch) State() *State {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	var st State
+	if err == nil {
+		err = json.Unmarshal(data, &st)
+	}
+	if err != nil {
+		st.Reason = fmt.Sprintf("unreadable lock file: %v", err)
+	}
+	return &st
+}
+
+// Engaged reports whether the switch is engaged.
+func (s *Switch) Engaged() bool {
+	return s.State() != nil
+}
+
+// Watch returns a copy of ctx that is cancelled once the switch is
+// engaged, checking every interval. Long-running strategies run under it
+// so they wind down when someone pulls the switch from another terminal.
+func (s *Switch) Watch(ctx context.Context, interval time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if st := s.State(); st != nil {
+				slog.Warn("killswitch: engaged, halting", "reason", st.Reason)
+				cancel()
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return ctx, cancel
+}
+
+// Guard is a service.Exchange decorator that refuses new orders while the
+// switch is engaged. Cancels and queries still go through.
+type Guard struct {
+	service.Exchange
+	Switch *Switch
+}
+
+// CreateOrder implements Exchange.CreateOrder.
+func (g *Guard) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
+	if g.Switch.Engaged() {
+		return nil, ErrEngaged
+	}
+	return g.Exchange.CreateOrder(ctx, o)
+}
+
+// PlaceOrders implements Exchange.PlaceOrders.
+func (g *Guard) PlaceOrders(ctx context.Context, orders []model.Order) []model.OrderResult {
+	if !g.Switch.Engaged() {
+		return g.Exchange.PlaceOrders(ctx, orders)
+	}
+	results := make([]model.OrderResult, len(orders))
+	for i, o := range orders {
+		results[i] = model.OrderResult{Order: o, Err: ErrEngaged}
+	}
+	return results
+}
@@ -0,0 +1,156 @@
+package killswitch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// Action is one step taken by Panic.
+type Action struct {
+	Kind     string // CANCEL, DISARM or SELL
+	Market   string
+	OrderID  string
+	Quantity string
+	Err      error
+}
+
+// Disarmer holds orders client-side, such as triggers, that would
+// otherwise still fire once the switch is rearmed.
+type Disarmer interface {
+	// DisarmAll cancels every pending order held and returns them.
+	DisarmAll(reason string) ([]model.Order, error)
+}
+
+// maxSweeps bounds how many times Panic lists the active orders again,
+// in case strategies keep placing orders until they notice the switch.
+const maxSweeps = 5
+
+// Panic engages the switch, disarms the local orders so that none fires
+// after a rearm, cancels every active order in every market and, when
+// flattenTo is set, market-sells all available balances into that
+// currency. ex must be the undecorated exchange, otherwise the sells would
+// be refused by the switch it just engaged.
+//
+// Failures do not stop the sweep: each one is recorded in its Action.
+// Orders still working once it ends are reported in the error, after the
+// balances are flattened.
+func Panic(ctx context.Context, ex service.Exchange, sw *Switch, reason, flattenTo string, local ...Disarmer) ([]Action, error) {
+	if err := sw.Engage(reason); err != nil {
+		return nil, err
+	}
+	var actions []Action
+	for _, d := range local {
+		disarmed, err := d.DisarmAll(reason)
+		for _, o := range disarmed {
+			actions = append(actions, Action{Kind: "DISARM", Market: o.MarketSymbol, OrderID: o.ID, Quantity: o.Quantity})
+		}
+		if err != nil {
+			actions = append(actions, Action{Kind: "DISARM", Err: err})
+		}
+	}
+
+	// list again until nothing new shows up: orders placed meanwhile, or
+	// beyond what one listing returns, are cancelled too
+	tried := make(map[string]bool)
+	var active []model.Order
+	for sweep := 0; ; sweep++ {
+		var err error
+		active, err = ex.GetActiveOrders(ctx, "")
+		if err != nil {
+			return actions, fmt.Errorf("killswitch: failed to list active orders: %w", err)
+		}
+		if sweep == maxSweeps {
+			break // the last listing only checks what is left
+		}
+		fresh := 0
+		for _, o := range active {
+			if tried[o.ID] {
+				continue // its cancel failed: recorded already
+			}
+			tried[o.ID] = true
+			fresh++
+			actions = append(actions, Action{
+				Kind:     "CANCEL",
+				Market:   o.MarketSymbol,
+				OrderID:  o.ID,
+				Quantity: o.Quantity,
+				Err:      ex.CancelOrder(ctx, o.ID),
+			})
+		}
+		if fresh == 0 {
+			break
+		}
+	}
+	var left error
+	if len(active) > 0 {
+		ids := make([]string, len(active))
+		for i, o := range active {
+			ids[i] = o.ID
+		}
+		left = fmt.Errorf("killswitch: %d orders still working after the sweep: %s", len(ids), strings.Join(ids, ", "))
+	}
+	if flattenTo == "" {
+		return actions, left
+	}
+
+	markets, err := ex.GetMarkets(ctx)
+	if err != nil {
+		return actions, errors.Join(left, fmt.Errorf("killswitch: failed to list markets: %w", err))
+	}
+	balances, err := ex.GetBalances(ctx)
+	if err != nil {
+		return actions, errors.Join(left, fmt.Errorf("killswitch: failed to list balances: %w", err))
+	}
+	for _, b := range balances {
+		if strings.EqualFold(b.Currency, flattenTo) || parse(b.Available) <= 0 {
+			continue
+		}
+		m, ok := find(markets, b.Currency, flattenTo)
+		if !ok {
+			actions = append(actions, Action{Kind: "SELL", Market: strings.ToUpper(b.Currency + flattenTo),
+				Quantity: b.Available, Err: fmt.Errorf("no %s market", strings.ToUpper(flattenTo))})
+			continue
+		}
+		qty := m.AlignQuantity(parse(b.Available))
+		if q := parse(qty); q <= 0 || q < parse(m.QuantityMin) {
+			continue // dust the exchange would reject
+		}
+		a := Action{Kind: "SELL", Market: m.Symbol, Quantity: qty}
+		o, err := ex.CreateOrder(ctx, model.Order{
+			MarketSymbol: m.Symbol,
+			Side:         model.Sell,
+			Type:         model.MarketOrder,
+			Quantity:     qty,
+		})
+		if err != nil {
+			a.Err = err
+		} else {
+			a.OrderID = o.ID
+		}
+		actions = append(actions, a)
+	}
+	return actions, left
+}
+
+// find returns the market trading base against quote.
+func find(markets []model.Market, base, quote string) (model.Market, bool) {
+	for _, m := range markets {
+		if strings.EqualFold(m.Base.Symbol, base) && strings.EqualFold(m.Quote.Symbol, quote) {
+			return m, true
+		}
+	}
+	return model.Market{}, false
+}
+
+// parse converts an exchange decimal string to float64, treating
+// malformed input as zero.
+func parse(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
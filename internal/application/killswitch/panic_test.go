@@ -0,0 +1,134 @@
+package killswitch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+func TestPanicSweepsUntilNoNewOrders(t *testing.T) {
+	// a strategy places one more order while the first listing is cancelled
+	ex := &fakeExchange{active: []model.Order{{ID: "1"}, {ID: "2"}}, late: []model.Order{{ID: "3"}}}
+	local := &fakeDisarmer{orders: []model.Order{{ID: "t1", MarketSymbol: "BTCBRL", Quantity: "0.1"}}}
+	sw := &Switch{Path: filepath.Join(t.TempDir(), "kill-switch")}
+
+	actions, err := Panic(context.Background(), ex, sw, "test", "", local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sw.State() == nil {
+		t.Error("the switch is not engaged")
+	}
+	if local.reason != "test" {
+		t.Errorf("disarmed with %q, want the panic reason", local.reason)
+	}
+	want := []string{"DISARM t1", "CANCEL 1", "CANCEL 2", "CANCEL 3"}
+	if len(actions) != len(want) {
+		t.Fatalf("actions %+v, want %v", actions, want)
+	}
+	for i, a := range actions {
+		if got := a.Kind + " " + a.OrderID; got != want[i] || a.Err != nil {
+			t.Errorf("action %d = %s (%v), want %s", i, got, a.Err, want[i])
+		}
+	}
+}
+
+func TestPanicGivesUpOnOrdersThatWillNotCancel(t *testing.T) {
+	ex := &fakeExchange{active: []model.Order{{ID: "1"}}, stuck: map[string]bool{"1": true}}
+	sw := &Switch{Path: filepath.Join(t.TempDir(), "kill-switch")}
+
+	actions, err := Panic(context.Background(), ex, sw, "test", "")
+	if err == nil || !strings.Contains(err.Error(), "1 orders still working") {
+		t.Errorf("err = %v, want the order left working", err)
+	}
+	if len(actions) != 1 || actions[0].Err == nil {
+		t.Errorf("actions %+v, want one failed cancel", actions)
+	}
+	if ex.listings != 2 {
+		t.Errorf("listed %d times, want 2", ex.listings)
+	}
+}
+
+func TestPanicCancelsPartiallyFilledOrders(t *testing.T) {
+	ex := &fakeExchange{active: []model.Order{
+		{ID: "1", State: model.StateActive},
+		{ID: "2", State: model.StatePartiallyFilled, QuantityExecuted: "0.05"},
+	}}
+	sw := &Switch{Path: filepath.Join(t.TempDir(), "kill-switch")}
+
+	actions, err := Panic(context.Background(), ex, sw, "test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 2 || actions[1].OrderID != "2" || actions[1].Err != nil {
+		t.Errorf("actions %+v, want both orders cancelled", actions)
+	}
+	if len(ex.active) != 0 {
+		t.Errorf("still working: %+v", ex.active)
+	}
+}
+
+func TestPanicFailsWhenOrdersKeepShowingUp(t *testing.T) {
+	ex := &fakeExchange{active: []model.Order{{ID: "1"}}, spawn: true}
+	sw := &Switch{Path: filepath.Join(t.TempDir(), "kill-switch")}
+
+	actions, err := Panic(context.Background(), ex, sw, "test", "")
+	if err == nil {
+		t.Fatal("no error with orders still showing up")
+	}
+	if len(actions) != maxSweeps || ex.listings != maxSweeps+1 {
+		t.Errorf("%d cancels in %d listings, want %d in %d", len(actions), ex.listings, maxSweeps, maxSweeps+1)
+	}
+}
+
+// fakeExchange lists the orders still working. The ones in late show up
+// after the first listing.
+type fakeExchange struct {
+	service.Exchange
+	active   []model.Order
+	late     []model.Order
+	stuck    map[string]bool
+	spawn    bool // each cancel is replaced by a new order
+	listings int
+}
+
+func (f *fakeExchange) GetActiveOrders(ctx context.Context, market string) ([]model.Order, error) {
+	f.listings++
+	out := append([]model.Order(nil), f.active...)
+	if f.listings == 1 {
+		f.active = append(f.active, f.late...)
+	}
+	return out, nil
+}
+
+func (f *fakeExchange) CancelOrder(ctx context.Context, id string) error {
+	if f.stuck[id] {
+		return errors.New("cannot cancel")
+	}
+	for i, o := range f.active {
+		if o.ID == id {
+			f.active = append(f.active[:i], f.active[i+1:]...)
+			if f.spawn {
+				f.active = append(f.active, model.Order{ID: id + "+"})
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%s not found", id)
+}
+
+type fakeDisarmer struct {
+	orders []model.Order
+	reason string
+}
+
+func (f *fakeDisarmer) DisarmAll(reason string) ([]model.Order, error) {
+	f.reason = reason
+	return f.orders, nil
+}
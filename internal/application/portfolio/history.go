@@ -0,0 +1,79 @@
+package portfolio
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// historyKey is where the trade history is kept in the state store.
+const historyKey = "portfolio-trades"
+
+// History is a local copy of our trades, kept in the state store so the
+// whole history is not downloaded again on every run.
+type History struct {
+	Trades *usecase.FetchTrades
+	Store  service.StateStore
+}
+
+// historyState is the persisted history: every trade between From and
+// Synced.
+type historyState struct {
+	From   time.Time     `json:"from"`
+	Synced time.Time     `json:"synced"`
+	Trades []model.Trade `json:"trades"`
+}
+
+// Sync downloads the trades missing from the local copy, going back to
+// start if it is earlier than what was downloaded before, and returns the
+// whole history, oldest first.
+func (h *History) Sync(ctx context.Context, start time.Time) ([]model.Trade, error) {
+	var st historyState
+	if _, err := h.Store.Load(historyKey, &st); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if st.Synced.IsZero() {
+		st.From, st.Synced = start, start
+	}
+	if start.Before(st.From) {
+		older, err := h.Trades.Execute(ctx, "", start, st.From)
+		if err != nil {
+			return nil, err
+		}
+		st.Trades = append(st.Trades, older...)
+		st.From = start
+	}
+	// overlap a little: trades may show up late around the boundary
+	newer, err := h.Trades.Execute(ctx, "", st.Synced.Add(-time.Minute), now)
+	if err != nil {
+		return nil, err
+	}
+	st.Trades = append(st.Trades, newer...)
+	st.Synced = now
+
+	seen := make(map[string]bool, len(st.Trades))
+	unique := st.Trades[:0]
+	for _, t := range st.Trades {
+		if !seen[t.ID] {
+			seen[t.ID] = true
+			unique = append(unique, t)
+		}
+	}
+	st.Trades = unique
+	sort.SliceStable(st.Trades, func(i, j int) bool {
+		return st.Trades[i].CreatedAt.Before(st.Trades[j].CreatedAt)
+	})
+	return st.Trades, h.Store.Save(historyKey, st)
+}
+
+// Load returns the local copy without contacting the exchange.
+func (h *History) Load() ([]model.Trade, error) {
+	var st historyState
+	_, err := h.Store.Load(historyKey, &st)
+	return st.Trades, err
+}
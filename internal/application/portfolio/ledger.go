@@ -0,0 +1,110 @@
+package portfolio
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Method selects how the cost of a sale is computed.
+type Method string
+
+const (
+	FIFO    Method = "FIFO"    // sales consume the oldest purchases first
+	Average Method = "AVERAGE" // every unit costs the weighted average price
+)
+
+// ParseMethod converts "fifo" or "average" to a Method.
+func ParseMethod(s string) (Method, error) {
+	switch strings.ToUpper(s) {
+	case "FIFO":
+		return FIFO, nil
+	case "AVERAGE", "AVG":
+		return Average, nil
+	default:
+		return "", fmt.Errorf("portfolio: unknown cost method %q, use fifo or average", s)
+	}
+}
+
+// lot is a quantity acquired at a known total cost.
+type lot struct {
+	qty  float64
+	cost float64
+}
+
+// ledger holds the cost lots of one asset. With Average there is never
+// more than one lot.
+type ledger struct {
+	method   Method
+	lots     []lot
+	realized float64
+}
+
+// quantity returns the quantity with a known cost.
+func (l *ledger) quantity() float64 {
+	var q float64
+	for _, lt := range l.lots {
+		q += lt.qty
+	}
+	return q
+}
+
+// cost returns the total cost of the held quantity.
+func (l *ledger) cost() float64 {
+	var c float64
+	for _, lt := range l.lots {
+		c += lt.cost
+	}
+	return c
+}
+
+// add records the acquisition of qty for a total cost.
+func (l *ledger) add(qty, cost float64) {
+	if qty <= 0 {
+		return
+	}
+	if l.method == Average && len(l.lots) == 1 {
+		l.lots[0].qty += qty
+		l.lots[0].cost += cost
+		return
+	}
+	l.lots = append(l.lots, lot{qty: qty, cost: cost})
+}
+
+// remove records the disposal of qty for proceeds and books the realized
+// result. Quantity beyond what the ledger holds has no known cost — it
+// came from deposits or from before the history starts — and is assumed
+// to have cost exactly its proceeds.
+func (l *ledger) remove(qty, proceeds float64) {
+	if qty <= 0 {
+		return
+	}
+	total := qty
+	var cost float64
+	for qty > 1e-12 && len(l.lots) > 0 {
+		lt := &l.lots[0]
+		take := qty
+		if take > lt.qty {
+			take = lt.qty
+		}
+		part := lt.cost * take / lt.qty
+		cost += part
+		lt.qty -= take
+		lt.cost -= part
+		qty -= take
+		if lt.qty <= 1e-12 {
+			l.lots = l.lots[1:]
+		}
+	}
+	known := total - qty
+	l.realized += proceeds*known/total - cost
+}
+
+// trim drops quantity the account no longer holds without booking a
+// result, e.g. after a withdrawal, consuming lots like a sale would.
+func (l *ledger) trim(held float64) {
+	if excess := l.quantity() - held; excess > 1e-12 {
+		saved := l.realized
+		l.remove(excess, 0)
+		l.realized = saved
+	}
+}
@@ -0,0 +1,98 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLedger(t *testing.T) {
+	type op struct {
+		qty, amount float64 // amount is the cost of a buy, the proceeds of a sale
+		sell        bool
+	}
+	tests := []struct {
+		name         string
+		method       Method
+		ops          []op
+		wantQty      float64
+		wantCost     float64
+		wantRealized float64
+	}{
+		{
+			name:    "fifo sells the oldest lot first",
+			method:  FIFO,
+			ops:     []op{{qty: 1, amount: 100}, {qty: 1, amount: 200}, {qty: 1, amount: 250, sell: true}},
+			wantQty: 1, wantCost: 200, wantRealized: 150,
+		},
+		{
+			name:    "average sells at the weighted cost",
+			method:  Average,
+			ops:     []op{{qty: 1, amount: 100}, {qty: 1, amount: 200}, {qty: 1, amount: 250, sell: true}},
+			wantQty: 1, wantCost: 150, wantRealized: 100,
+		},
+		{
+			name:    "fifo sale across lots",
+			method:  FIFO,
+			ops:     []op{{qty: 1, amount: 100}, {qty: 2, amount: 400}, {qty: 2, amount: 600, sell: true}},
+			wantQty: 1, wantCost: 200, wantRealized: 300,
+		},
+		{
+			name:    "average keeps one lot",
+			method:  Average,
+			ops:     []op{{qty: 2, amount: 200}, {qty: 1, amount: 150, sell: true}, {qty: 1, amount: 200}},
+			wantQty: 2, wantCost: 300, wantRealized: 50,
+		},
+		{
+			name:    "quantity without a known cost books nothing",
+			method:  FIFO,
+			ops:     []op{{qty: 1, amount: 100}, {qty: 3, amount: 600, sell: true}},
+			wantQty: 0, wantCost: 0, wantRealized: 100,
+		},
+		{
+			name:    "selling from nothing",
+			method:  Average,
+			ops:     []op{{qty: 1, amount: 100, sell: true}},
+			wantQty: 0, wantCost: 0, wantRealized: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &ledger{method: tt.method}
+			for _, o := range tt.ops {
+				if o.sell {
+					l.remove(o.qty, o.amount)
+				} else {
+					l.add(o.qty, o.amount)
+				}
+			}
+			if got := l.quantity(); !near(got, tt.wantQty) {
+				t.Errorf("quantity = %v, want %v", got, tt.wantQty)
+			}
+			if got := l.cost(); !near(got, tt.wantCost) {
+				t.Errorf("cost = %v, want %v", got, tt.wantCost)
+			}
+			if !near(l.realized, tt.wantRealized) {
+				t.Errorf("realized = %v, want %v", l.realized, tt.wantRealized)
+			}
+		})
+	}
+}
+
+func TestLedgerTrim(t *testing.T) {
+	for _, method := range []Method{FIFO, Average} {
+		l := &ledger{method: method}
+		l.add(1, 100)
+		l.add(1, 300)
+		l.trim(1.5) // half a unit was withdrawn
+		if got := l.quantity(); !near(got, 1.5) {
+			t.Errorf("%s: quantity = %v, want 1.5", method, got)
+		}
+		if l.realized != 0 {
+			t.Errorf("%s: realized %v on a withdrawal", method, l.realized)
+		}
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
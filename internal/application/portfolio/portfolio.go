@@ -0,0 +1,188 @@
+package portfolio
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+)
+
+// Position is the state of one asset, with amounts in the valuation
+// currency.
+type Position struct {
+	Asset       string
+	Quantity    float64 // balance held
+	Tracked     float64 // part of Quantity with a known cost
+	AverageCost float64 // per unit of Tracked
+	Mark        float64 // order book mid
+	Value       float64 // Quantity × Mark
+	Realized    float64
+	Unrealized  float64 // Tracked × Mark − cost of Tracked
+}
+
+// Report is a snapshot of the whole portfolio.
+type Report struct {
+	Method     Method
+	Currency   string
+	Positions  []Position
+	Value      float64
+	Realized   float64
+	Unrealized float64
+}
+
+// Tracker builds portfolio reports from the trade history and the current
+// balances.
+//
+// Amounts are converted to Currency with the current order book mid of
+// each asset's Currency market. Trades quoted in another currency (e.g.
+// USDT) are converted at today's rate, which is exact only for markets
+// quoted in Currency itself.
+type Tracker struct {
+	History  *History
+	Balances *usecase.FetchBalances
+	Markets  *usecase.FetchMarkets
+	Book     *usecase.FetchOrderBook
+	Currency string // valuation currency, "brl" when empty
+
+	marks map[string]float64
+}
+
+// Report syncs the trades made since start and values the portfolio.
+func (t *Tracker) Report(ctx context.Context, method Method, start time.Time) (*Report, error) {
+	cur := strings.ToLower(t.Currency)
+	if cur == "" {
+		cur = "brl"
+	}
+	mkts, err := t.Markets.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	markets := make(map[string]model.Market, len(mkts))
+	for _, m := range mkts {
+		markets[strings.ToUpper(m.Symbol)] = m
+	}
+	trades, err := t.History.Sync(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := t.Balances.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.marks = map[string]float64{cur: 1}
+
+	ledgers := make(map[string]*ledger)
+	get := func(asset string) *ledger {
+		asset = strings.ToLower(asset)
+		if ledgers[asset] == nil {
+			ledgers[asset] = &ledger{method: method}
+		}
+		return ledgers[asset]
+	}
+	for _, tr := range trades {
+		m, ok := markets[strings.ToUpper(tr.MarketSymbol)]
+		if !ok {
+			slog.Warn("portfolio: skipping trade in unknown market", "trade", tr.ID, "market", tr.MarketSymbol)
+			continue
+		}
+		base, quote := strings.ToLower(m.Base.Symbol), strings.ToLower(m.Quote.Symbol)
+		qty := parse(tr.Quantity)
+		notional := qty * parse(tr.Price)
+		value := notional * t.mark(ctx, mkts, quote, cur)
+		if tr.Side == model.Buy {
+			get(base).add(qty, value)
+			if quote != cur {
+				get(quote).remove(notional, value)
+			}
+		} else {
+			get(base).remove(qty, value)
+			if quote != cur {
+				get(quote).add(notional, value)
+			}
+		}
+		if fee := parse(tr.Fee); fee > 0 {
+			// no fee currency means the quote, as in the tax report
+			fc := strings.ToLower(tr.FeeCurrency)
+			if fc == "" {
+				fc = quote
+			}
+			if fc == cur {
+				get(base).realized -= fee
+			} else {
+				get(fc).remove(fee, 0)
+			}
+		}
+	}
+
+	r := &Report{Method: method, Currency: cur}
+	held := make(map[string]float64)
+	for _, b := range balances {
+		held[strings.ToLower(b.Currency)] = parse(b.Total)
+	}
+	for asset := range ledgers {
+		if _, ok := held[asset]; !ok {
+			held[asset] = 0
+		}
+	}
+	for asset, qty := range held {
+		p := Position{Asset: asset, Quantity: qty, Mark: t.mark(ctx, mkts, asset, cur)}
+		if l := ledgers[asset]; l != nil && asset != cur {
+			l.trim(qty)
+			p.Tracked = l.quantity()
+			if p.Tracked > 0 {
+				p.AverageCost = l.cost() / p.Tracked
+			}
+			p.Realized = l.realized
+			p.Unrealized = p.Tracked*p.Mark - l.cost()
+		} else if l != nil {
+			p.Realized = l.realized
+		}
+		p.Value = p.Quantity * p.Mark
+		if p.Quantity == 0 && p.Realized == 0 {
+			continue
+		}
+		r.Positions = append(r.Positions, p)
+		r.Value += p.Value
+		r.Realized += p.Realized
+		r.Unrealized += p.Unrealized
+	}
+	sort.Slice(r.Positions, func(i, j int) bool { return r.Positions[i].Asset < r.Positions[j].Asset })
+	return r, nil
+}
+
+// mark returns the price of one unit of asset in cur from the mid of the
+// asset/cur order book, or 0 when there is no such market.
+func (t *Tracker) mark(ctx context.Context, markets []model.Market, asset, cur string) float64 {
+	asset = strings.ToLower(asset)
+	if asset == "" {
+		return 1
+	}
+	if p, ok := t.marks[asset]; ok {
+		return p
+	}
+	t.marks[asset] = 0
+	for _, m := range markets {
+		if strings.EqualFold(m.Base.Symbol, asset) && strings.EqualFold(m.Quote.Symbol, cur) {
+			ob, err := t.Book.Execute(ctx, m.Symbol, 1)
+			if err != nil {
+				slog.Warn("portfolio: no mark", "asset", asset, "err", err)
+				return 0
+			}
+			t.marks[asset] = ob.Mid()
+			break
+		}
+	}
+	return t.marks[asset]
+}
+
+// parse converts an exchange decimal string to float64, treating
+// malformed input as zero.
+func parse(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
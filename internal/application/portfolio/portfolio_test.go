@@ -0,0 +1,137 @@
+package portfolio
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+func TestReportFeeCurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		trade       model.Trade
+		wantBTC     float64 // realized in BRL
+		wantUSDT    float64
+		wantUSDTQty float64 // tracked USDT left
+	}{
+		{
+			name:    "fee in the valuation currency",
+			trade:   model.Trade{MarketSymbol: "BTCBRL", Side: model.Buy, Price: "300000", Quantity: "0.01", Fee: "6", FeeCurrency: "brl"},
+			wantBTC: -6,
+		},
+		{
+			name:    "no fee currency in a BRL market",
+			trade:   model.Trade{MarketSymbol: "BTCBRL", Side: model.Buy, Price: "300000", Quantity: "0.01", Fee: "6"},
+			wantBTC: -6,
+		},
+		{
+			// the fee is 1 USDT, not 1 BRL: it spends USDT bought at 5 BRL
+			name:        "no fee currency in a USDT market",
+			trade:       model.Trade{MarketSymbol: "BTCUSDT", Side: model.Buy, Price: "60000", Quantity: "0.01", Fee: "1"},
+			wantUSDT:    -5,
+			wantUSDTQty: 399,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			fund := model.Trade{ID: "1", MarketSymbol: "USDTBRL", Side: model.Buy, Price: "5", Quantity: "1000", CreatedAt: now.Add(-2 * time.Hour)}
+			tt.trade.ID, tt.trade.CreatedAt = "2", now.Add(-time.Hour)
+			ex := &fakeExchange{
+				trades: []model.Trade{fund, tt.trade},
+				balances: []model.Balance{
+					{Currency: "btc", Total: "0.01"},
+					{Currency: "usdt", Total: "1000"},
+				},
+			}
+			tr := &Tracker{
+				History:  &History{Trades: &usecase.FetchTrades{Ex: ex}, Store: memStore{}},
+				Balances: &usecase.FetchBalances{Ex: ex},
+				Markets:  &usecase.FetchMarkets{Ex: ex},
+				Book:     &usecase.FetchOrderBook{Ex: ex},
+			}
+			r, err := tr.Report(context.Background(), FIFO, now.Add(-24*time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]Position{}
+			for _, p := range r.Positions {
+				got[p.Asset] = p
+			}
+			if !near(got["btc"].Realized, tt.wantBTC) {
+				t.Errorf("btc realized = %v, want %v", got["btc"].Realized, tt.wantBTC)
+			}
+			if !near(got["usdt"].Realized, tt.wantUSDT) {
+				t.Errorf("usdt realized = %v, want %v", got["usdt"].Realized, tt.wantUSDT)
+			}
+			if tt.wantUSDTQty != 0 && !near(got["usdt"].Tracked, tt.wantUSDTQty) {
+				t.Errorf("usdt tracked = %v, want %v", got["usdt"].Tracked, tt.wantUSDTQty)
+			}
+		})
+	}
+}
+
+// fakeExchange serves fixed markets, books, trades and balances.
+type fakeExchange struct {
+	service.Exchange
+	trades   []model.Trade
+	balances []model.Balance
+}
+
+func (f *fakeExchange) GetMarkets(ctx context.Context) ([]model.Market, error) {
+	market := func(base, quote string) model.Market {
+		return model.Market{Symbol: strings.ToUpper(base + quote), Base: model.Asset{Symbol: base}, Quote: model.Asset{Symbol: quote}}
+	}
+	return []model.Market{market("btc", "brl"), market("usdt", "brl"), market("btc", "usdt")}, nil
+}
+
+func (f *fakeExchange) GetOrderBook(ctx context.Context, market string, depth int) (*model.OrderBook, error) {
+	mid := map[string]string{"BTCBRL": "300000", "USDTBRL": "5", "BTCUSDT": "60000"}[market]
+	return &model.OrderBook{Bids: [][]string{{mid, "1"}}, Asks: [][]string{{mid, "1"}}}, nil
+}
+
+func (f *fakeExchange) GetTrades(ctx context.Context, market string, start, end time.Time) ([]model.Trade, error) {
+	return f.trades, nil
+}
+
+func (f *fakeExchange) GetBalances(ctx context.Context) ([]model.Balance, error) {
+	return f.balances, nil
+}
+
+// memStore is an in-memory service.StateStore.
+type memStore map[string][]byte
+
+func (m memStore) Load(key string, dest interface{}) (bool, error) {
+	b, ok := m[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(b, dest)
+}
+
+func (m memStore) Save(key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	m[key] = b
+	return err
+}
+
+func (m memStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m memStore) Keys(prefix string) ([]string, error) {
+	var keys []string
+	for k := range m {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,312 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// equityTTL is how long a valuation of the account is reused before the
+// daily loss check prices the balances again.
+const equityTTL = time.Minute
+
+// Guard is a service.Exchange decorator that runs the pre-trade checks on
+// every CreateOrder and forwards everything else untouched.
+//
+// Checks that reduce exposure are lenient on purpose: sells are never
+// blocked by the position or daily loss limits, so stops can still close
+// positions on a bad day.
+type Guard struct {
+	service.Exchange
+	Limits Limits
+	Store  service.StateStore // keeps the opening equity of the day; nil keeps it in memory
+
+	mu       sync.Mutex
+	markets  map[string]model.Market
+	sent     []time.Time
+	pending  map[int]model.Order // orders checked but not yet answered
+	seq      int
+	day      day
+	equity   float64
+	pricedAt time.Time
+}
+
+// day is the opening equity the daily loss is measured against.
+type day struct {
+	Date   string  `json:"date"`
+	Equity float64 `json:"equity"`
+}
+
+// NewGuard wraps ex with the given limits.
+func NewGuard(ex service.Exchange, l Limits, st service.StateStore) *Guard {
+	if l.Currency == "" {
+		l.Currency = "brl"
+	}
+	return &Guard{Exchange: ex, Limits: l, Store: st}
+}
+
+// CreateOrder implements Exchange.CreateOrder, rejecting orders that break
+// a limit with a *Violation before they reach the exchange.
+//
+// The order reserves its slot in the rate and open order limits while it
+// is being sent, so concurrent orders are checked against each other
+// without waiting on the exchange; a failed order gives its slot back.
+func (g *Guard) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
+	g.mu.Lock()
+	if err := g.check(ctx, o); err != nil {
+		g.mu.Unlock()
+		return nil, err
+	}
+	at := time.Now()
+	g.sent = append(g.sent, at)
+	if g.pending == nil {
+		g.pending = make(map[int]model.Order)
+	}
+	g.seq++
+	id := g.seq
+	g.pending[id] = o
+	g.mu.Unlock()
+
+	placed, err := g.Exchange.CreateOrder(ctx, o)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.pending, id)
+	if err != nil {
+		if i := slices.IndexFunc(g.sent, at.Equal); i >= 0 {
+			g.sent = slices.Delete(g.sent, i, i+1)
+		}
+	}
+	return placed, err
+}
+
+// PlaceOrders implements Exchange.PlaceOrders. The orders are sent one at
+// a time so each check sees the ones placed before it.
+func (g *Guard) PlaceOrders(ctx context.Context, orders []model.Order) []model.OrderResult {
+	results := make([]model.OrderResult, len(orders))
+	for i, o := range orders {
+		results[i].Order = o
+		placed, err := g.CreateOrder(ctx, o)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Order = *placed
+	}
+	return results
+}
+
+// check runs the cheap checks first so a burst of bad orders does not
+// cost API calls.
+func (g *Guard) check(ctx context.Context, o model.Order) error {
+	l := g.Limits
+	violation := func(r Rule, value, limit float64) error {
+		return &Violation{Rule: r, Market: o.MarketSymbol, Value: value, Limit: limit}
+	}
+
+	if l.MaxOrdersPerMinute > 0 {
+		cutoff := time.Now().Add(-time.Minute)
+		for len(g.sent) > 0 && g.sent[0].Before(cutoff) {
+			g.sent = g.sent[1:]
+		}
+		if n := len(g.sent) + 1; n > l.MaxOrdersPerMinute {
+			return violation(RateLimit, float64(n), float64(l.MaxOrdersPerMinute))
+		}
+	}
+
+	qty := parse(o.Quantity)
+	price := parse(o.Price)
+	if l.PriceBand > 0 || (l.MaxNotional > 0 && o.Type == model.MarketOrder) {
+		ob, err := g.GetOrderBook(ctx, o.MarketSymbol, 1)
+		if err != nil {
+			return fmt.Errorf("risk: %w", err)
+		}
+		mid := ob.Mid()
+		if o.Type == model.MarketOrder {
+			price = ob.BestAsk()
+			if o.Side == model.Sell {
+				price = ob.BestBid()
+			}
+		}
+		if l.PriceBand > 0 && mid > 0 && o.Type != model.MarketOrder {
+			if dev := math.Abs(price-mid) / mid; dev > l.PriceBand {
+				return violation(PriceBand, dev, l.PriceBand)
+			}
+		}
+	}
+	if l.MaxNotional > 0 {
+		if notional := price * qty; notional > l.MaxNotional {
+			return violation(MaxNotional, notional, l.MaxNotional)
+		}
+	}
+
+	buy := o.Side == model.Buy
+	maxPos := 0.0
+	var m model.Market
+	if len(l.MaxPosition) > 0 && buy {
+		var err error
+		if m, err = g.market(ctx, o.MarketSymbol); err != nil {
+			return err
+		}
+		maxPos = l.maxPosition(m.Base.Symbol)
+	}
+	if l.MaxOpenOrders > 0 || maxPos > 0 {
+		active, err := g.GetActiveOrders(ctx, o.MarketSymbol)
+		if err != nil {
+			return fmt.Errorf("risk: %w", err)
+		}
+		if n := len(active) + g.inflight(o.MarketSymbol) + 1; l.MaxOpenOrders > 0 && n > l.MaxOpenOrders {
+			return violation(MaxOpenOrders, float64(n), float64(l.MaxOpenOrders))
+		}
+		if maxPos > 0 {
+			// the position if this order and every resting buy filled
+			pos := qty
+			for _, a := range active {
+				if a.Side == model.Buy {
+					pos += parse(a.Quantity) - parse(a.QuantityExecuted)
+				}
+			}
+			for _, p := range g.pending {
+				if p.Side == model.Buy && strings.EqualFold(p.MarketSymbol, o.MarketSymbol) {
+					pos += parse(p.Quantity)
+				}
+			}
+			held, err := g.balance(ctx, m.Base.Symbol)
+			if err != nil {
+				return err
+			}
+			if pos += held; pos > maxPos {
+				return violation(MaxPosition, pos, maxPos)
+			}
+		}
+	}
+
+	if l.DailyLoss > 0 && buy {
+		loss, err := g.loss(ctx)
+		if err != nil {
+			return err
+		}
+		if loss > l.DailyLoss {
+			return violation(DailyLoss, loss, l.DailyLoss)
+		}
+	}
+	return nil
+}
+
+// inflight counts the orders on market still being sent.
+func (g *Guard) inflight(market string) int {
+	n := 0
+	for _, p := range g.pending {
+		if strings.EqualFold(p.MarketSymbol, market) {
+			n++
+		}
+	}
+	return n
+}
+
+// market returns the rules of symbol, listing the markets once.
+func (g *Guard) market(ctx context.Context, symbol string) (model.Market, error) {
+	if g.markets == nil {
+		mkts, err := g.GetMarkets(ctx)
+		if err != nil {
+			return model.Market{}, fmt.Errorf("risk: %w", err)
+		}
+		g.markets = make(map[string]model.Market, len(mkts))
+		for _, m := range mkts {
+			g.markets[strings.ToUpper(m.Symbol)] = m
+		}
+	}
+	m, ok := g.markets[strings.ToUpper(symbol)]
+	if !ok {
+		return model.Market{}, fmt.Errorf("risk: unknown market %s", symbol)
+	}
+	return m, nil
+}
+
+// balance returns the total amount of currency held.
+func (g *Guard) balance(ctx context.Context, currency string) (float64, error) {
+	balances, err := g.GetBalances(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("risk: %w", err)
+	}
+	for _, b := range balances {
+		if strings.EqualFold(b.Currency, currency) {
+			return parse(b.Total), nil
+		}
+	}
+	return 0, nil
+}
+
+// loss returns how much the account equity dropped since the first
+// valuation of the day. The opening equity is saved so restarting the
+// process does not reset the limit.
+func (g *Guard) loss(ctx context.Context) (float64, error) {
+	if time.Since(g.pricedAt) > equityTTL {
+		eq, err := g.valuate(ctx)
+		if err != nil {
+			return 0, err
+		}
+		g.equity, g.pricedAt = eq, time.Now()
+	}
+
+	today := time.Now().Format("2006-01-02")
+	if g.day.Date != today && g.Store != nil {
+		if _, err := g.Store.Load("risk-day", &g.day); err != nil {
+			return 0, err
+		}
+	}
+	if g.day.Date != today {
+		g.day = day{Date: today, Equity: g.equity}
+		if g.Store != nil {
+			if err := g.Store.Save("risk-day", g.day); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return g.day.Equity - g.equity, nil
+}
+
+// valuate prices every balance in the valuation currency with the last
+// trade of its market. Currencies without such a market are ignored.
+func (g *Guard) valuate(ctx context.Context) (float64, error) {
+	balances, err := g.GetBalances(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("risk: %w", err)
+	}
+	var equity float64
+	for _, b := range balances {
+		amount := parse(b.Total)
+		if amount == 0 {
+			continue
+		}
+		if strings.EqualFold(b.Currency, g.Limits.Currency) {
+			equity += amount
+			continue
+		}
+		symbol := b.Currency + g.Limits.Currency
+		if _, err := g.market(ctx, symbol); err != nil {
+			continue
+		}
+		tk, err := g.GetTicker(ctx, symbol)
+		if err != nil {
+			return 0, fmt.Errorf("risk: %w", err)
+		}
+		equity += amount * parse(tk.Last)
+	}
+	return equity, nil
+}
+
+// parse converts an exchange decimal string to float64, treating
+// malformed input as zero.
+func parse(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
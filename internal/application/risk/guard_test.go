@@ -0,0 +1,250 @@
+package risk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+func TestGuardLimits(t *testing.T) {
+	limit := func(side model.OrderSide, price, qty string) model.Order {
+		return model.Order{MarketSymbol: "BTCBRL", Side: side, Type: model.Limit, Price: price, Quantity: qty}
+	}
+	market := func(side model.OrderSide, qty string) model.Order {
+		return model.Order{MarketSymbol: "BTCBRL", Side: side, Type: model.MarketOrder, Quantity: qty}
+	}
+	ago := func(d time.Duration) time.Time { return time.Now().Add(-d) }
+	tests := []struct {
+		name   string
+		limits Limits
+		order  model.Order
+		sent   []time.Time
+		active []model.Order
+		want   Rule // empty when the order goes through
+	}{
+		{name: "rate limit", limits: Limits{MaxOrdersPerMinute: 2}, order: limit(model.Buy, "100", "1"), sent: []time.Time{ago(30 * time.Second), ago(time.Second)}, want: RateLimit},
+		{name: "rate limit window passed", limits: Limits{MaxOrdersPerMinute: 2}, order: limit(model.Buy, "100", "1"), sent: []time.Time{ago(2 * time.Minute), ago(time.Second)}},
+
+		{name: "outside the price band", limits: Limits{PriceBand: 0.05}, order: limit(model.Buy, "106", "1"), want: PriceBand},
+		{name: "inside the price band", limits: Limits{PriceBand: 0.05}, order: limit(model.Sell, "96", "1")},
+		{name: "market orders have no band", limits: Limits{PriceBand: 0.05}, order: market(model.Buy, "1")},
+
+		{name: "notional of a limit order", limits: Limits{MaxNotional: 500}, order: limit(model.Sell, "100", "6"), want: MaxNotional},
+		{name: "notional of a market buy at the ask", limits: Limits{MaxNotional: 500}, order: market(model.Buy, "4.96"), want: MaxNotional},
+		{name: "notional of a market sell at the bid", limits: Limits{MaxNotional: 500}, order: market(model.Sell, "5")},
+
+		{name: "open orders", limits: Limits{MaxOpenOrders: 2}, order: limit(model.Sell, "100", "1"), active: []model.Order{{ID: "1"}, {ID: "2"}}, want: MaxOpenOrders},
+		{name: "open orders below the limit", limits: Limits{MaxOpenOrders: 2}, order: limit(model.Sell, "100", "1"), active: []model.Order{{ID: "1"}}},
+
+		// holding 0.1, plus 0.15 left of a resting buy
+		{name: "position with resting buys", limits: Limits{MaxPosition: map[string]float64{"BTC": 0.5}}, order: limit(model.Buy, "100", "0.3"), active: []model.Order{{Side: model.Buy, Quantity: "0.2", QuantityExecuted: "0.05"}, {Side: model.Sell, Quantity: "1"}}, want: MaxPosition},
+		{name: "position within the limit", limits: Limits{MaxPosition: map[string]float64{"btc": 0.5}}, order: limit(model.Buy, "100", "0.2"), active: []model.Order{{Side: model.Buy, Quantity: "0.2", QuantityExecuted: "0.05"}}},
+		{name: "position limit spares sells", limits: Limits{MaxPosition: map[string]float64{"btc": 0.5}}, order: limit(model.Sell, "100", "3")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := &fakeExchange{}
+			ex.active = tt.active
+			g := NewGuard(ex, tt.limits, nil)
+			g.sent = tt.sent
+
+			_, err := g.CreateOrder(context.Background(), tt.order)
+			checkRule(t, err, tt.want)
+			if tt.want == "" && len(ex.placed) != 1 {
+				t.Errorf("placed %d orders, want 1", len(ex.placed))
+			}
+			if tt.want != "" && len(ex.placed) != 0 {
+				t.Errorf("placed %+v, want nothing", ex.placed)
+			}
+		})
+	}
+}
+
+func TestGuardDailyLoss(t *testing.T) {
+	today := time.Now().Format("2006-01-02")
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	tests := []struct {
+		name        string
+		saved       *day // opening equity saved by an earlier run
+		side        model.OrderSide
+		want        Rule
+		wantOpening day
+	}{
+		// the account is worth 1010 now: 1000 BRL and 0.1 BTC at 100
+		{name: "first order of the day", side: model.Buy, wantOpening: day{today, 1010}},
+		{name: "loss after a restart", saved: &day{today, 1200}, side: model.Buy, want: DailyLoss, wantOpening: day{today, 1200}},
+		{name: "loss within the limit", saved: &day{today, 1100}, side: model.Buy, wantOpening: day{today, 1100}},
+		{name: "sells are spared", saved: &day{today, 1200}, side: model.Sell},
+		{name: "new day", saved: &day{yesterday, 1200}, side: model.Buy, wantOpening: day{today, 1010}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := memStore{}
+			if tt.saved != nil {
+				st.Save("risk-day", tt.saved)
+			}
+			g := NewGuard(&fakeExchange{}, Limits{DailyLoss: 100}, st)
+
+			_, err := g.CreateOrder(context.Background(), model.Order{MarketSymbol: "BTCBRL", Side: tt.side, Type: model.Limit, Price: "100", Quantity: "1"})
+			checkRule(t, err, tt.want)
+			if tt.wantOpening == (day{}) {
+				return
+			}
+			var got day
+			if _, err := st.Load("risk-day", &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.wantOpening {
+				t.Errorf("opening equity %+v, want %+v", got, tt.wantOpening)
+			}
+		})
+	}
+}
+
+func TestGuardReservesSlotsWhileSending(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		want   Rule
+	}{
+		{name: "rate limit", limits: Limits{MaxOrdersPerMinute: 1}, want: RateLimit},
+		{name: "open orders", limits: Limits{MaxOpenOrders: 1}, want: MaxOpenOrders},
+		{name: "position", limits: Limits{MaxPosition: map[string]float64{"btc": 1.5}}, want: MaxPosition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := &fakeExchange{}
+			ex.block = make(chan error)
+			g := NewGuard(ex, tt.limits, nil)
+			o := model.Order{MarketSymbol: "BTCBRL", Side: model.Buy, Type: model.Limit, Price: "100", Quantity: "1"}
+
+			done := make(chan error)
+			go func() {
+				_, err := g.CreateOrder(context.Background(), o)
+				done <- err
+			}()
+			for ex.sending() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+
+			// checked while the first order is on its way, without
+			// waiting for it
+			_, err := g.CreateOrder(context.Background(), o)
+			checkRule(t, err, tt.want)
+
+			ex.block <- errors.New("connection reset")
+			if err := <-done; err == nil {
+				t.Fatal("the failed order went through")
+			}
+			ex.block = nil
+			if _, err := g.CreateOrder(context.Background(), o); err != nil {
+				t.Errorf("the failed order kept its slot: %v", err)
+			}
+		})
+	}
+}
+
+func checkRule(t *testing.T, err error, want Rule) {
+	t.Helper()
+	var v *Violation
+	switch {
+	case want == "" && err != nil:
+		t.Errorf("rejected: %v", err)
+	case want != "" && !errors.As(err, &v):
+		t.Errorf("err = %v, want a %s violation", err, want)
+	case want != "" && v.Rule != want:
+		t.Errorf("rule = %s, want %s", v.Rule, want)
+	}
+}
+
+// fakeExchange quotes BTCBRL at 99/101 for an account of 1000 BRL and
+// 0.1 BTC. When block is set, CreateOrder waits for the error to answer
+// with.
+type fakeExchange struct {
+	service.Exchange
+	active []model.Order
+	placed []model.Order
+	block  chan error
+
+	mu       sync.Mutex
+	inflight int
+}
+
+func (f *fakeExchange) GetMarkets(ctx context.Context) ([]model.Market, error) {
+	return []model.Market{{Symbol: "BTCBRL", Base: model.Asset{Symbol: "btc"}, Quote: model.Asset{Symbol: "brl"}}}, nil
+}
+
+func (f *fakeExchange) GetOrderBook(ctx context.Context, market string, depth int) (*model.OrderBook, error) {
+	return &model.OrderBook{Bids: [][]string{{"99", "10"}}, Asks: [][]string{{"101", "10"}}}, nil
+}
+
+func (f *fakeExchange) GetTicker(ctx context.Context, market string) (*model.Ticker, error) {
+	return &model.Ticker{MarketSymbol: market, Last: "100"}, nil
+}
+
+func (f *fakeExchange) GetBalances(ctx context.Context) ([]model.Balance, error) {
+	return []model.Balance{{Currency: "brl", Total: "1000"}, {Currency: "btc", Total: "0.1"}}, nil
+}
+
+func (f *fakeExchange) GetActiveOrders(ctx context.Context, market string) ([]model.Order, error) {
+	return f.active, nil
+}
+
+func (f *fakeExchange) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
+	if f.block != nil {
+		f.mu.Lock()
+		f.inflight++
+		f.mu.Unlock()
+		if err := <-f.block; err != nil {
+			return nil, err
+		}
+	}
+	f.placed = append(f.placed, o)
+	o.ID = "1"
+	return &o, nil
+}
+
+func (f *fakeExchange) sending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.inflight
+}
+
+// memStore is an in-memory service.StateStore.
+type memStore map[string][]byte
+
+func (m memStore) Load(key string, dest interface{}) (bool, error) {
+	b, ok := m[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(b, dest)
+}
+
+func (m memStore) Save(key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	m[key] = b
+	return err
+}
+
+func (m memStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m memStore) Keys(prefix string) ([]string, error) {
+	var keys []string
+	for k := range m {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
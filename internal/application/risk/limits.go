@@ -0,0 +1,92 @@
+package risk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Limits configures the pre-trade checks. A zero value disables a check.
+type Limits struct {
+	MaxNotional        float64            `json:"max_notional"`          // quote value of a single order
+	MaxPosition        map[string]float64 `json:"max_position"`          // largest holding per base asset, e.g. {"btc": 0.5}
+	PriceBand          float64            `json:"price_band"`            // largest distance from mid as a fraction, e.g. 0.05
+	MaxOpenOrders      int                `json:"max_open_orders"`       // active orders per market
+	DailyLoss          float64            `json:"daily_loss"`            // equity drop since midnight, in Currency
+	MaxOrdersPerMinute int                `json:"max_orders_per_minute"` // orders sent by this process
+	Currency           string             `json:"currency"`              // valuation currency, "brl" by default
+}
+
+// LoadLimits reads limits from a JSON file. A missing file yields
+// (nil, nil) so callers can run without a risk configuration.
+func LoadLimits(path string) (*Limits, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("risk: failed to read %s: %w", path, err)
+	}
+	var l Limits
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("risk: failed to decode %s: %w", path, err)
+	}
+	return &l, nil
+}
+
+// maxPosition returns the position limit for asset, 0 meaning none.
+func (l *Limits) maxPosition(asset string) float64 {
+	for a, max := range l.MaxPosition {
+		if strings.EqualFold(a, asset) {
+			return max
+		}
+	}
+	return 0
+}
+
+// Rule names a pre-trade check.
+type Rule string
+
+const (
+	MaxNotional   Rule = "MAX_NOTIONAL"
+	MaxPosition   Rule = "MAX_POSITION"
+	PriceBand     Rule = "PRICE_BAND"
+	MaxOpenOrders Rule = "MAX_OPEN_ORDERS"
+	DailyLoss     Rule = "DAILY_LOSS"
+	RateLimit     Rule = "RATE_LIMIT"
+)
+
+// Violation is returned when an order breaks a limit. Use errors.As to
+// tell it apart from exchange errors.
+type Violation struct {
+	Rule   Rule
+	Market string
+	Value  float64 // what the order would have reached
+	Limit  float64
+}
+
+func (v *Violation) Error() string {
+	var what string
+	switch v.Rule {
+	case MaxNotional:
+		what = "order notional"
+	case MaxPosition:
+		what = "resulting position"
+	case PriceBand:
+		what = "distance from mid"
+	case MaxOpenOrders:
+		what = "open orders"
+	case DailyLoss:
+		what = "loss today"
+	case RateLimit:
+		what = "orders in the last minute"
+	}
+	return fmt.Sprintf("risk: order on %s rejected by %s: %s %s exceeds the limit of %s",
+		v.Market, v.Rule, what, format(v.Value), format(v.Limit))
+}
+
+func format(f float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.8f", f), "0"), ".")
+}
@@ -0,0 +1,396 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"trading-bot/internal/application/killswitch"
+	"trading-bot/internal/application/risk"
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// DCAStatus tracks the outcome of one scheduled purchase.
+type DCAStatus string
+
+const (
+	DCAPending  DCAStatus = "PENDING"  // still working on the exchange
+	DCADone     DCAStatus = "DONE"     // something was bought (maybe less than Amount)
+	DCAUnfilled DCAStatus = "UNFILLED" // timed out without any execution
+	DCAFailed   DCAStatus = "FAILED"   // no order could be placed before the timeout
+	DCASkipped  DCAStatus = "SKIPPED"  // its last order could not be checked; never retried
+)
+
+// DCAConfig describes a recurring purchase.
+type DCAConfig struct {
+	Market    model.Market
+	Schedule  *Schedule
+	Amount    float64       // quote currency spent on every purchase
+	PostOnly  bool          // rest at the best bid instead of crossing at the best ask
+	Timeout   time.Duration // how long an order may rest before it is cancelled
+	Chase     bool          // after a timeout, re-price the unfilled remainder
+	MaxChases int           // how many times a purchase may be re-priced
+	Interval  time.Duration // how often the working order is polled
+}
+
+// DCABuy records one scheduled purchase. Quantity and Cost only include
+// orders that are finished; Active is the order still working, if any.
+// While Submitting, Sent is the order that may or may not have reached
+// the exchange.
+type DCABuy struct {
+	Slot       time.Time    `json:"slot"`
+	Status     DCAStatus    `json:"status"`
+	OrderIDs   []string     `json:"order_ids,omitempty"`
+	Active     string       `json:"active,omitempty"`
+	Submitting bool         `json:"submitting,omitempty"`
+	Sent       *model.Order `json:"sent,omitempty"`
+	SentAt     time.Time    `json:"sent_at,omitempty"`
+	Deadline   time.Time    `json:"deadline,omitempty"`
+	Quantity   float64      `json:"quantity"`
+	Cost       float64      `json:"cost"`
+}
+
+// DCAState is the purchase history persisted between runs.
+type DCAState struct {
+	Market string   `json:"market"`
+	Buys   []DCABuy `json:"buys"`
+}
+
+// Totals returns the base quantity bought and the quote amount spent.
+func (s DCAState) Totals() (qty, cost float64) {
+	for _, b := range s.Buys {
+		qty += b.Quantity
+		cost += b.Cost
+	}
+	return qty, cost
+}
+
+// AverageCost returns the quote paid per unit of base, or 0 before the
+// first execution.
+func (s DCAState) AverageCost() float64 {
+	qty, cost := s.Totals()
+	if qty == 0 {
+		return 0
+	}
+	return cost / qty
+}
+
+// DCA buys a fixed quote amount of a market on every schedule slot.
+// Each slot is recorded before any order is sent, so a restarted DCA never
+// buys the same slot twice; slots missed while the bot was down are not
+// made up for. An order whose submission failed without a definite
+// rejection, or was interrupted, is looked for among the open orders and
+// the trades of the market before anything is sent again.
+type DCA struct {
+	Place  *usecase.PlaceOrder
+	Cancel *usecase.CancelOrder
+	Get    *usecase.GetOrder
+	Book   *usecase.FetchOrderBook
+	List   *usecase.ListActiveOrders
+	Trades *usecase.FetchTrades
+	Store  service.StateStore
+	Config DCAConfig
+
+	// Rejected reports whether an error placing an order means the
+	// exchange refused it, so none was placed. When nil, only refusals by
+	// the risk limits and the kill switch count.
+	Rejected func(error) bool
+
+	// OnUpdate, when set, receives the purchase history whenever it is
+	// saved.
+	OnUpdate func(DCAState)
+
+	state DCAState
+}
+
+// State returns a copy of the purchase history.
+func (d *DCA) State() DCAState {
+	s := d.state
+	s.Buys = append([]DCABuy(nil), d.state.Buys...)
+	return s
+}
+
+// Load reads the saved purchase history without trading.
+func (d *DCA) Load() error {
+	_, err := d.Store.Load(d.key(), &d.state)
+	d.state.Market = d.Config.Market.Symbol
+	return err
+}
+
+// Run waits for every schedule slot and buys on it until ctx is cancelled.
+// A purchase still working from a previous run is resumed first.
+func (d *DCA) Run(ctx context.Context) error {
+	if d.Config.Schedule == nil || d.Config.Amount <= 0 {
+		return errors.New("dca: a schedule and a positive amount are required")
+	}
+	if err := d.Load(); err != nil {
+		return err
+	}
+	if n := len(d.state.Buys); n > 0 && d.state.Buys[n-1].Status == DCAPending {
+		d.work(ctx, n-1)
+	}
+
+	for ctx.Err() == nil {
+		from := time.Now()
+		if n := len(d.state.Buys); n > 0 && d.state.Buys[n-1].Slot.After(from) {
+			from = d.state.Buys[n-1].Slot
+		}
+		next := d.Config.Schedule.Next(from)
+		if next.IsZero() {
+			return fmt.Errorf("dca: schedule %q never fires", d.Config.Schedule)
+		}
+		slog.Info("dca: next purchase", "market", d.Config.Market.Symbol, "amount", d.Config.Amount, "at", next)
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		d.state.Buys = append(d.state.Buys, DCABuy{Slot: next, Status: DCAPending})
+		d.save()
+		d.work(ctx, len(d.state.Buys)-1)
+	}
+	return nil
+}
+
+// work drives purchase i until it is finished or ctx is cancelled, in which
+// case it stays pending and is resumed by the next Run.
+func (d *DCA) work(ctx context.Context, i int) {
+	b := &d.state.Buys[i]
+	if b.Submitting && b.Sent == nil {
+		// saved by a version that did not record the order being sent:
+		// it cannot be looked for, and buying again could double the
+		// purchase
+		d.skip(b)
+		return
+	}
+
+	interval := d.Config.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if b.Submitting {
+			if err := d.resolve(ctx, b); err != nil {
+				slog.Warn("dca: could not check whether the order was placed", "slot", b.Slot, "err", err)
+				if time.Since(b.Slot) > d.Config.Timeout {
+					d.skip(b)
+					return
+				}
+			}
+		} else if b.Active == "" {
+			done, err := d.submit(ctx, b)
+			if done {
+				d.finish(b)
+				return
+			}
+			if err != nil {
+				slog.Warn("dca: submit failed", "slot", b.Slot, "err", err)
+				if !b.Submitting && time.Since(b.Slot) > d.Config.Timeout {
+					if b.Quantity > 0 {
+						d.finish(b) // an earlier chase already bought part of it
+						return
+					}
+					b.Status = DCAFailed
+					d.save()
+					return
+				}
+			}
+		} else if finished, filled := d.poll(ctx, b); finished {
+			if filled || !d.Config.Chase || len(b.OrderIDs) > d.Config.MaxChases {
+				d.finish(b)
+				return
+			}
+			slog.Info("dca: chasing the unfilled remainder", "slot", b.Slot)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// submit places an order for whatever is left of the purchase amount.
+// It reports done when the remainder is below the market minimum. When
+// the order may have been placed despite an error, it is left
+// Submitting for resolve to look for.
+func (d *DCA) submit(ctx context.Context, b *DCABuy) (bool, error) {
+	m := d.Config.Market
+	ob, err := d.Book.Execute(ctx, m.Symbol, 1)
+	if err != nil {
+		return false, err
+	}
+	price := ob.BestAsk()
+	if d.Config.PostOnly {
+		price = ob.BestBid()
+	}
+	if price == 0 {
+		return false, fmt.Errorf("dca: order book for %s is empty", m.Symbol)
+	}
+	aligned := m.AlignPrice(price)
+	qty := m.AlignQuantity((d.Config.Amount - b.Cost) / parse(aligned))
+	if parse(qty) <= 0 || parse(qty) < parse(m.QuantityMin) {
+		return true, nil
+	}
+
+	req := model.Order{
+		MarketSymbol: m.Symbol,
+		Side:         model.Buy,
+		Type:         model.Limit,
+		Price:        aligned,
+		Quantity:     qty,
+		PostOnly:     d.Config.PostOnly,
+	}
+	b.Submitting, b.Sent, b.SentAt = true, &req, time.Now()
+	d.save()
+	o, err := d.Place.Execute(ctx, req)
+	if err != nil {
+		if d.rejected(err) {
+			b.Submitting, b.Sent = false, nil
+		}
+		d.save()
+		return false, err
+	}
+	d.adopt(b, o.ID)
+	slog.Info("dca: placed buy", "market", strings.ToUpper(m.Symbol), "quantity", qty, "price", aligned, "order", o.ID)
+	return false, nil
+}
+
+// resolve finds out whether the order of a failed or interrupted
+// submission reached the exchange. It is adopted when it is open or has
+// executed; otherwise the purchase goes back to submitting a new one.
+func (d *DCA) resolve(ctx context.Context, b *DCABuy) error {
+	symbol := d.Config.Market.Symbol
+	open, err := d.List.Execute(ctx, symbol)
+	if err != nil {
+		return err
+	}
+	for _, o := range open {
+		if o.Side == model.Buy && !b.known(o.ID) &&
+			parse(o.Price) == parse(b.Sent.Price) && parse(o.Quantity) == parse(b.Sent.Quantity) {
+			slog.Info("dca: found the order of an unconfirmed submission", "slot", b.Slot, "order", o.ID)
+			d.adopt(b, o.ID)
+			return nil
+		}
+	}
+	// it may have executed at once and no longer be open
+	trades, err := d.Trades.Execute(ctx, symbol, b.Slot, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, t := range trades {
+		if t.Side == model.Buy && !b.known(t.OrderID) && parse(t.Price) <= parse(b.Sent.Price) {
+			slog.Info("dca: found the order of an unconfirmed submission", "slot", b.Slot, "order", t.OrderID)
+			d.adopt(b, t.OrderID)
+			return nil
+		}
+	}
+	slog.Info("dca: the unconfirmed order was not placed", "slot", b.Slot)
+	b.Submitting, b.Sent = false, nil
+	d.save()
+	return nil
+}
+
+// adopt makes id the working order of the purchase.
+func (d *DCA) adopt(b *DCABuy, id string) {
+	b.OrderIDs = append(b.OrderIDs, id)
+	b.Active = id
+	b.Deadline = b.SentAt.Add(d.Config.Timeout)
+	b.Submitting, b.Sent = false, nil
+	d.save()
+}
+
+// skip gives up on a purchase whose last order could not be checked.
+func (d *DCA) skip(b *DCABuy) {
+	slog.Warn("dca: purchase was interrupted while submitting; check the exchange", "slot", b.Slot)
+	b.Submitting, b.Sent, b.Status = false, nil, DCASkipped
+	d.save()
+}
+
+// rejected reports whether err means no order was placed.
+func (d *DCA) rejected(err error) bool {
+	if d.Rejected != nil && d.Rejected(err) {
+		return true
+	}
+	var v *risk.Violation
+	return errors.Is(err, killswitch.ErrEngaged) || errors.As(err, &v)
+}
+
+// known reports whether id is one of the purchase's orders.
+func (b *DCABuy) known(id string) bool {
+	return slices.Contains(b.OrderIDs, id)
+}
+
+// poll checks the working order and cancels it once its deadline passes.
+// It reports finished when the order is over and its execution booked,
+// and filled when it executed completely.
+func (d *DCA) poll(ctx context.Context, b *DCABuy) (finished, filled bool) {
+	o, err := d.Get.Execute(ctx, b.Active)
+	if err != nil {
+		slog.Warn("dca: poll failed", "order", b.Active, "err", err)
+		return false, false
+	}
+	if !o.State.Final() {
+		if time.Now().Before(b.Deadline) {
+			return false, false
+		}
+		if err := d.Cancel.Execute(ctx, b.Active); err != nil {
+			slog.Warn("dca: cancel failed", "order", b.Active, "err", err)
+			return false, false
+		}
+		// read it back so executions that raced the cancel are counted
+		if o, err = d.Get.Execute(ctx, b.Active); err != nil {
+			slog.Warn("dca: poll failed", "order", b.Active, "err", err)
+			return false, false
+		}
+	}
+
+	qty := parse(o.QuantityExecuted)
+	price := parse(o.PriceAvg)
+	if price == 0 {
+		price = parse(o.Price)
+	}
+	b.Quantity += qty
+	b.Cost += qty * price
+	b.Active = ""
+	d.save()
+	return true, o.State == model.StateFilled
+}
+
+// finish closes the purchase according to what was executed.
+func (d *DCA) finish(b *DCABuy) {
+	b.Status = DCAUnfilled
+	if b.Quantity > 0 {
+		b.Status = DCADone
+	}
+	d.save()
+	slog.Info("dca: purchase finished", "slot", b.Slot, "status", b.Status, "quantity", b.Quantity, "cost", b.Cost)
+}
+
+// save persists the history, logging (not failing) on error.
+func (d *DCA) save() {
+	if err := d.Store.Save(d.key(), d.state); err != nil {
+		slog.Error("dca: failed to save the history", "err", err)
+	}
+	if d.OnUpdate != nil {
+		d.OnUpdate(d.State())
+	}
+}
+
+func (d *DCA) key() string {
+	return "dca-" + strings.ToLower(d.Config.Market.Symbol)
+}
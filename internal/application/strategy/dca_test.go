@@ -0,0 +1,224 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"trading-bot/internal/application/killswitch"
+	"trading-bot/internal/application/risk"
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+)
+
+var errRefused = errors.New("insufficient balance")
+
+func TestDCASubmitError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantSubmitting bool
+	}{
+		{name: "refused by the exchange", err: errRefused},
+		{name: "refused by the risk limits", err: &risk.Violation{Rule: risk.MaxNotional}},
+		{name: "refused by the kill switch", err: fmt.Errorf("place: %w", killswitch.ErrEngaged)},
+		{name: "timed out", err: context.DeadlineExceeded, wantSubmitting: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := newDCAExchange()
+			ex.createErr = tt.err
+			d := newDCA(ex)
+			b := &DCABuy{Slot: time.Now(), Status: DCAPending}
+
+			if _, err := d.submit(context.Background(), b); !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if b.Submitting != tt.wantSubmitting || (b.Sent != nil) != tt.wantSubmitting {
+				t.Errorf("submitting %v with %+v, want %v", b.Submitting, b.Sent, tt.wantSubmitting)
+			}
+		})
+	}
+}
+
+func TestDCAResolve(t *testing.T) {
+	sent := model.Order{MarketSymbol: "BTCBRL", Side: model.Buy, Type: model.Limit, Price: "100000", Quantity: "0.0020"}
+	tests := []struct {
+		name           string
+		open           []model.Order
+		trades         []model.Trade
+		listErr        error
+		wantActive     string
+		wantSubmitting bool
+	}{
+		{
+			name:       "still open",
+			open:       []model.Order{{ID: "old", Side: model.Buy, Price: "100000", Quantity: "0.0020"}, {ID: "7", Side: model.Buy, Price: "100000", Quantity: "0.002"}},
+			wantActive: "7",
+		},
+		{
+			name:       "executed at once",
+			open:       []model.Order{{ID: "9", Side: model.Buy, Price: "99000", Quantity: "0.0020"}},
+			trades:     []model.Trade{{OrderID: "old", Side: model.Buy, Price: "100000"}, {OrderID: "8", Side: model.Buy, Price: "99990"}},
+			wantActive: "8",
+		},
+		{name: "never placed", trades: []model.Trade{{OrderID: "5", Side: model.Sell, Price: "100000"}}},
+		{name: "exchange unreachable", listErr: errors.New("connection reset"), wantSubmitting: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := newDCAExchange()
+			ex.open, ex.trades, ex.listErr = tt.open, tt.trades, tt.listErr
+			d := newDCA(ex)
+			b := &DCABuy{Slot: time.Now(), Status: DCAPending, OrderIDs: []string{"old"}, Submitting: true, Sent: &sent, SentAt: time.Now()}
+
+			err := d.resolve(context.Background(), b)
+			if (err != nil) != (tt.listErr != nil) {
+				t.Fatalf("err = %v, want %v", err, tt.listErr)
+			}
+			if b.Active != tt.wantActive || b.Submitting != tt.wantSubmitting {
+				t.Errorf("active %q, submitting %v; want %q, %v", b.Active, b.Submitting, tt.wantActive, tt.wantSubmitting)
+			}
+			if tt.wantActive != "" && b.Deadline != b.SentAt.Add(d.Config.Timeout) {
+				t.Errorf("deadline %v, want the timeout after sending", b.Deadline)
+			}
+		})
+	}
+}
+
+func TestDCADoesNotBuyTwiceAfterAnUnconfirmedOrder(t *testing.T) {
+	// the order is placed and fills, but the answer is lost
+	ex := newDCAExchange()
+	ex.createErr = context.DeadlineExceeded
+	ex.fill = true
+	d := newDCA(ex)
+	d.state.Buys = []DCABuy{{Slot: time.Now(), Status: DCAPending}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	d.work(ctx, 0)
+
+	b := d.state.Buys[0]
+	if len(ex.placed) != 1 {
+		t.Errorf("placed %d orders, want 1", len(ex.placed))
+	}
+	if b.Status != DCADone || b.Quantity != 0.002 || b.Cost != 200 {
+		t.Errorf("purchase %+v, want done with 0.002 for 200", b)
+	}
+}
+
+func TestDCAResumesAnInterruptedSubmission(t *testing.T) {
+	tests := []struct {
+		name       string
+		sent       *model.Order
+		wantStatus DCAStatus
+		wantPlaced int
+	}{
+		{name: "order recorded", sent: &model.Order{Side: model.Buy, Price: "100000", Quantity: "0.0020"}, wantStatus: DCADone, wantPlaced: 1},
+		// saved before the order being sent was recorded
+		{name: "order unknown", wantStatus: DCASkipped},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := newDCAExchange()
+			ex.fill = true
+			d := newDCA(ex)
+			d.state.Buys = []DCABuy{{Slot: time.Now(), Status: DCAPending, Submitting: true, Sent: tt.sent, SentAt: time.Now()}}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			d.work(ctx, 0)
+
+			if b := d.state.Buys[0]; b.Status != tt.wantStatus || b.Submitting {
+				t.Errorf("purchase %+v, want %s", b, tt.wantStatus)
+			}
+			if len(ex.placed) != tt.wantPlaced {
+				t.Errorf("placed %d orders, want %d", len(ex.placed), tt.wantPlaced)
+			}
+		})
+	}
+}
+
+func TestDCAPollCancelsAfterTheDeadline(t *testing.T) {
+	ex := newDCAExchange()
+	ex.orders["1"] = model.Order{ID: "1", State: model.StateActive, Price: "100000", Quantity: "0.0020"}
+	ex.onCancel = model.Order{ID: "1", State: model.StateCancelled, Price: "100000", Quantity: "0.0020", QuantityExecuted: "0.0005", PriceAvg: "99000"}
+	d := newDCA(ex)
+	b := &DCABuy{Slot: time.Now(), Status: DCAPending, OrderIDs: []string{"1"}, Active: "1", Deadline: time.Now().Add(-time.Second)}
+
+	finished, filled := d.poll(context.Background(), b)
+	if !finished || filled {
+		t.Fatalf("finished %v, filled %v; want finished unfilled", finished, filled)
+	}
+	if b.Active != "" || b.Quantity != 0.0005 || abs(b.Cost-49.5) > 1e-9 {
+		t.Errorf("purchase %+v, want 0.0005 booked at 99000", b)
+	}
+}
+
+func newDCA(ex *dcaExchange) *DCA {
+	return &DCA{
+		Place:  &usecase.PlaceOrder{Ex: ex},
+		Cancel: &usecase.CancelOrder{Ex: ex},
+		Get:    &usecase.GetOrder{Ex: ex},
+		Book:   &usecase.FetchOrderBook{Ex: ex},
+		List:   &usecase.ListActiveOrders{Ex: ex},
+		Trades: &usecase.FetchTrades{Ex: ex},
+		Store:  memStore{},
+		Config: DCAConfig{
+			Market:   btcbrl,
+			Amount:   200,
+			Timeout:  time.Minute,
+			Interval: time.Millisecond,
+		},
+		Rejected: func(err error) bool { return errors.Is(err, errRefused) },
+	}
+}
+
+// dcaExchange places orders that fill at once when fill is set, even when
+// CreateOrder fails with createErr.
+type dcaExchange struct {
+	*fakeExchange
+	createErr error
+	fill      bool
+	open      []model.Order
+	trades    []model.Trade
+	listErr   error
+	onCancel  model.Order
+}
+
+func newDCAExchange() *dcaExchange {
+	return &dcaExchange{fakeExchange: &fakeExchange{
+		book:   model.OrderBook{Bids: [][]string{{"99000", "1"}}, Asks: [][]string{{"100000", "1"}}},
+		orders: map[string]model.Order{},
+	}}
+}
+
+func (f *dcaExchange) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
+	f.placed = append(f.placed, o)
+	o.ID = fmt.Sprint(len(f.placed))
+	o.State = model.StateActive
+	if f.fill {
+		o.State, o.QuantityExecuted, o.PriceAvg = model.StateFilled, o.Quantity, o.Price
+		f.trades = append(f.trades, model.Trade{OrderID: o.ID, Side: o.Side, Price: o.Price, Quantity: o.Quantity})
+	}
+	f.orders[o.ID] = o
+	if f.createErr != nil {
+		return nil, f.createErr
+	}
+	return &o, nil
+}
+
+func (f *dcaExchange) GetActiveOrders(ctx context.Context, market string) ([]model.Order, error) {
+	return f.open, f.listErr
+}
+
+func (f *dcaExchange) GetTrades(ctx context.Context, market string, start, end time.Time) ([]model.Trade, error) {
+	return f.trades, nil
+}
+
+func (f *dcaExchange) CancelOrder(ctx context.Context, id string) error {
+	f.orders[id] = f.onCancel
+	return nil
+}
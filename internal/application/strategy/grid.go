@@ -0,0 +1,387 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// GridSpacing selects how grid levels are distributed between the bounds.
+type GridSpacing string
+
+const (
+	// Arithmetic keeps the same price distance between adjacent levels.
+	Arithmetic GridSpacing = "ARITHMETIC"
+	// Geometric keeps the same percentage distance between adjacent levels.
+	Geometric GridSpacing = "GEOMETRIC"
+)
+
+// GridConfig describes the price grid to lay out.
+type GridConfig struct {
+	Market   model.Market
+	Lower    float64       // lowest grid price
+	Upper    float64       // highest grid price
+	Levels   int           // number of price levels, bounds included
+	Quantity string        // quantity of every grid order
+	Spacing  GridSpacing   // Arithmetic or Geometric
+	Interval time.Duration // how often resting orders are polled
+}
+
+// GridLevel is one price step of the grid.
+// Side is empty while the level is idle; a level with a Side and no OrderID
+// still has to be (re)placed on the exchange, for the grid quantity minus
+// what its earlier orders executed before being cancelled.
+type GridLevel struct {
+	Price      string          `json:"price"`
+	Side       model.OrderSide `json:"side,omitempty"`
+	OrderID    string          `json:"order_id,omitempty"`
+	EntryPrice string          `json:"entry_price,omitempty"` // fill that caused this order, if any
+	Filled     string          `json:"filled,omitempty"`      // executed by cancelled orders of this level
+}
+
+// GridState is the snapshot persisted after every change to the grid.
+type GridState struct {
+	Market         string      `json:"market"`
+	Lower          float64     `json:"lower"`
+	Upper          float64     `json:"upper"`
+	Spacing        GridSpacing `json:"spacing"`
+	Quantity       string      `json:"quantity"`
+	Levels         []GridLevel `json:"levels"`
+	RealizedProfit float64     `json:"realized_profit"`
+	RoundTrips     int         `json:"round_trips"`
+}
+
+// Grid keeps limit orders resting on every level of a price grid.
+// When a buy fills, a sell is placed one level above it; when a sell fills,
+// a buy is placed one level below. Fills alternate between opening and
+// closing a round trip, and every closing fill adds the distance between
+// the two levels (times the quantity) to the realized profit.
+type Grid struct {
+	Place  *usecase.PlaceOrder
+	Cancel *usecase.CancelOrder
+	Get    *usecase.GetOrder
+	Book   *usecase.FetchOrderBook
+	Store  service.StateStore
+	Config GridConfig
+
+	// OnUpdate, when set, receives the state after every poll.
+	OnUpdate func(GridState)
+
+	state GridState
+}
+
+// State returns a copy of the current grid snapshot.
+func (g *Grid) State() GridState {
+	s := g.state
+	s.Levels = append([]GridLevel(nil), g.state.Levels...)
+	return s
+}
+
+// Run lays out the grid, or resumes a previously saved one, and keeps it
+// alive until ctx is cancelled. Resting orders are left on the exchange
+// when Run returns so a later Run can pick them up again.
+func (g *Grid) Run(ctx context.Context) error {
+	if err := g.init(ctx); err != nil {
+		return err
+	}
+	interval := g.Config.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := g.step(ctx); err != nil {
+			slog.Warn("grid: step failed", "market", g.Config.Market.Symbol, "err", err)
+		}
+		if g.OnUpdate != nil {
+			g.OnUpdate(g.State())
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Teardown cancels every resting grid order and forgets the saved state.
+func (g *Grid) Teardown(ctx context.Context) error {
+	if len(g.state.Levels) == 0 {
+		if _, err := g.Store.Load(g.key(), &g.state); err != nil {
+			return err
+		}
+	}
+	var errs []error
+	for i := range g.state.Levels {
+		lvl := &g.state.Levels[i]
+		if lvl.OrderID == "" {
+			continue
+		}
+		if err := g.Cancel.Execute(ctx, lvl.OrderID); err != nil {
+			errs = append(errs, fmt.Errorf("cancel %s: %w", lvl.OrderID, err))
+			continue
+		}
+		lvl.OrderID, lvl.Side, lvl.EntryPrice, lvl.Filled = "", "", "", ""
+	}
+	if len(errs) > 0 {
+		g.save()
+		return fmt.Errorf("grid: teardown incomplete: %w", errors.Join(errs...))
+	}
+	return g.Store.Delete(g.key())
+}
+
+// init restores the saved grid or computes and lays out a new one.
+func (g *Grid) init(ctx context.Context) error {
+	prices, err := GridPrices(g.Config)
+	if err != nil {
+		return err
+	}
+	qty := g.Config.Market.AlignQuantity(parse(g.Config.Quantity))
+	if parse(qty) <= 0 || parse(qty) < parse(g.Config.Market.QuantityMin) {
+		return fmt.Errorf("grid: quantity %s is below the minimum of %s", g.Config.Quantity, g.Config.Market.Symbol)
+	}
+	found, err := g.Store.Load(g.key(), &g.state)
+	if err != nil {
+		return err
+	}
+	if found {
+		if !g.matches(prices, qty) {
+			return fmt.Errorf("grid: saved state for %s was created with different parameters; tear it down first",
+				g.Config.Market.Symbol)
+		}
+		slog.Info("grid: resuming", "market", g.state.Market, "levels", len(g.state.Levels),
+			"realized_profit", g.state.RealizedProfit)
+		return nil
+	}
+
+	ob, err := g.Book.Execute(ctx, g.Config.Market.Symbol, 1)
+	if err != nil {
+		return err
+	}
+	mid := ob.Mid()
+	if mid == 0 {
+		return fmt.Errorf("grid: order book for %s is empty", g.Config.Market.Symbol)
+	}
+
+	// The level closest to the current price stays idle: it is where the
+	// first counter order will go once a neighbouring level fills.
+	gap := 0
+	for i, p := range prices {
+		if math.Abs(parse(p)-mid) < math.Abs(parse(prices[gap])-mid) {
+			gap = i
+		}
+	}
+	g.state = GridState{
+		Market:   g.Config.Market.Symbol,
+		Lower:    g.Config.Lower,
+		Upper:    g.Config.Upper,
+		Spacing:  g.Config.Spacing,
+		Quantity: qty,
+		Levels:   make([]GridLevel, len(prices)),
+	}
+	for i, p := range prices {
+		g.state.Levels[i].Price = p
+		switch {
+		case i < gap:
+			g.state.Levels[i].Side = model.Buy
+		case i > gap:
+			g.state.Levels[i].Side = model.Sell
+		}
+	}
+	g.save()
+	return nil
+}
+
+// matches reports whether the saved state belongs to the configured grid.
+func (g *Grid) matches(prices []string, qty string) bool {
+	s := g.state
+	if !strings.EqualFold(s.Market, g.Config.Market.Symbol) || s.Spacing != g.Config.Spacing ||
+		parse(s.Quantity) != parse(qty) || len(s.Levels) != len(prices) {
+		return false
+	}
+	for i, p := range prices {
+		if s.Levels[i].Price != p {
+			return false
+		}
+	}
+	return true
+}
+
+// step polls every resting order, books fills, places the counter orders
+// and (re)places any level that is still missing its order.
+func (g *Grid) step(ctx context.Context) error {
+	type fill struct {
+		level int
+		side  model.OrderSide
+		entry string
+	}
+	var fills []fill
+	var errs []error
+
+	for i := range g.state.Levels {
+		lvl := &g.state.Levels[i]
+		if lvl.OrderID == "" {
+			continue
+		}
+		o, err := g.Get.Execute(ctx, lvl.OrderID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("get %s: %w", lvl.OrderID, err))
+			continue
+		}
+		if !o.State.Final() {
+			continue
+		}
+		if o.State != model.StateFilled {
+			// cancelled or expired behind our back: book what it executed,
+			// keep the intent and place the remainder again
+			executed := parse(o.QuantityExecuted)
+			if executed > 0 {
+				g.book(lvl, executed)
+				lvl.Filled = g.Config.Market.AlignQuantity(parse(lvl.Filled) + executed)
+			}
+			slog.Warn("grid: order closed externally, replacing", "order", lvl.OrderID, "price", lvl.Price,
+				"state", o.State, "executed", o.QuantityExecuted)
+			lvl.OrderID = ""
+			if rest := parse(g.remaining(*lvl)); rest > 0 && rest >= parse(g.Config.Market.QuantityMin) {
+				g.save()
+				continue
+			}
+			// what is left is too small to place: the level counts as filled
+		} else {
+			g.book(lvl, parse(g.remaining(*lvl)))
+		}
+		fills = append(fills, fill{level: i, side: lvl.Side, entry: lvl.EntryPrice})
+		slog.Info("grid: order filled", "market", g.state.Market, "side", lvl.Side, "quantity", g.state.Quantity, "price", lvl.Price)
+		lvl.Side, lvl.OrderID, lvl.EntryPrice, lvl.Filled = "", "", "", ""
+	}
+
+	for _, f := range fills {
+		price := g.state.Levels[f.level].Price
+		if f.entry != "" {
+			g.state.RoundTrips++
+		}
+
+		target, side := f.level+1, model.Sell
+		if f.side == model.Sell {
+			target, side = f.level-1, model.Buy
+		}
+		if target < 0 || target >= len(g.state.Levels) {
+			slog.Info("grid: price left the grid, no counter order placed", "price", price)
+			continue
+		}
+		if g.state.Levels[target].Side != "" {
+			slog.Info("grid: level already busy, skipping counter order", "price", g.state.Levels[target].Price, "side", side)
+			continue
+		}
+		g.state.Levels[target].Side = side
+		if f.entry == "" {
+			// an opening fill: its counter order closes the round trip
+			g.state.Levels[target].EntryPrice = price
+		}
+	}
+	if len(fills) > 0 {
+		// fills and their counter orders are saved together, so a crash
+		// cannot book a fill without also remembering what replaces it
+		g.save()
+	}
+
+	for i := range g.state.Levels {
+		lvl := &g.state.Levels[i]
+		if lvl.Side == "" || lvl.OrderID != "" {
+			continue
+		}
+		o, err := g.Place.Execute(ctx, model.Order{
+			MarketSymbol: g.state.Market,
+			Side:         lvl.Side,
+			Type:         model.Limit,
+			Price:        lvl.Price,
+			Quantity:     g.remaining(*lvl),
+			PostOnly:     true,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("place %s @ %s: %w", lvl.Side, lvl.Price, err))
+			continue
+		}
+		lvl.OrderID = o.ID
+		g.save()
+	}
+	return errors.Join(errs...)
+}
+
+// book adds to the realized profit what executing qty on lvl earned, when
+// lvl closes a round trip.
+func (g *Grid) book(lvl *GridLevel, qty float64) {
+	if lvl.EntryPrice == "" {
+		return
+	}
+	if lvl.Side == model.Sell {
+		g.state.RealizedProfit += (parse(lvl.Price) - parse(lvl.EntryPrice)) * qty
+	} else {
+		g.state.RealizedProfit += (parse(lvl.EntryPrice) - parse(lvl.Price)) * qty
+	}
+}
+
+// remaining returns the quantity lvl still has to trade, aligned to the
+// market's quantity increment.
+func (g *Grid) remaining(lvl GridLevel) string {
+	return g.Config.Market.AlignQuantity(parse(g.state.Quantity) - parse(lvl.Filled))
+}
+
+// save persists the current state, logging (not failing) on error so a
+// flaky disk never stops the grid from trading.
+func (g *Grid) save() {
+	if err := g.Store.Save(g.key(), g.state); err != nil {
+		slog.Error("grid: failed to save the state", "err", err)
+	}
+}
+
+func (g *Grid) key() string {
+	return "grid-" + strings.ToLower(g.Config.Market.Symbol)
+}
+
+// GridPrices computes the level prices of cfg, aligned to the market's
+// price increment, from the lowest to the highest.
+func GridPrices(cfg GridConfig) ([]string, error) {
+	if cfg.Levels < 2 {
+		return nil, errors.New("grid: at least 2 levels are required")
+	}
+	if cfg.Lower <= 0 || cfg.Upper <= cfg.Lower {
+		return nil, errors.New("grid: bounds must satisfy 0 < lower < upper")
+	}
+	prices := make([]string, cfg.Levels)
+	steps := float64(cfg.Levels - 1)
+	for i := range prices {
+		var p float64
+		switch cfg.Spacing {
+		case Geometric:
+			p = cfg.Lower * math.Pow(cfg.Upper/cfg.Lower, float64(i)/steps)
+		case Arithmetic, "":
+			p = cfg.Lower + (cfg.Upper-cfg.Lower)*float64(i)/steps
+		default:
+			return nil, fmt.Errorf("grid: unknown spacing %q", cfg.Spacing)
+		}
+		prices[i] = cfg.Market.AlignPrice(p)
+		if i > 0 && parse(prices[i]) <= parse(prices[i-1]) {
+			return nil, fmt.Errorf("grid: levels collapse at price increment %s, use fewer levels",
+				cfg.Market.PriceIncrement)
+		}
+	}
+	return prices, nil
+}
+
+// parse converts an exchange decimal string to float64, treating
+// malformed input as zero.
+func parse(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
@@ -0,0 +1,211 @@
+package strategy
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+var btcbrl = model.Market{
+	Symbol:            "BTCBRL",
+	PriceIncrement:    "1",
+	PricePrecision:    0,
+	QuantityMin:       "0.0002",
+	QuantityIncrement: "0.0001",
+	QuantityPrecision: 4,
+}
+
+func TestGridPrices(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     GridConfig
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "arithmetic",
+			cfg:  GridConfig{Market: btcbrl, Lower: 100, Upper: 200, Levels: 5},
+			want: []string{"100", "125", "150", "175", "200"},
+		},
+		{
+			name: "geometric",
+			cfg:  GridConfig{Market: btcbrl, Lower: 100, Upper: 400, Levels: 3, Spacing: Geometric},
+			want: []string{"100", "200", "400"},
+		},
+		{
+			name: "aligned to the increment",
+			cfg:  GridConfig{Market: btcbrl, Lower: 100, Upper: 110, Levels: 4},
+			want: []string{"100", "103", "107", "110"},
+		},
+		{
+			name:    "collapsing levels",
+			cfg:     GridConfig{Market: btcbrl, Lower: 100, Upper: 102, Levels: 5},
+			wantErr: "levels collapse",
+		},
+		{
+			name:    "one level",
+			cfg:     GridConfig{Market: btcbrl, Lower: 100, Upper: 200, Levels: 1},
+			wantErr: "at least 2 levels",
+		},
+		{
+			name:    "inverted bounds",
+			cfg:     GridConfig{Market: btcbrl, Lower: 200, Upper: 100, Levels: 3},
+			wantErr: "bounds",
+		},
+		{
+			name:    "unknown spacing",
+			cfg:     GridConfig{Market: btcbrl, Lower: 100, Upper: 200, Levels: 3, Spacing: "LOG"},
+			wantErr: "unknown spacing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GridPrices(tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("prices = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGridStepPartiallyCancelled(t *testing.T) {
+	tests := []struct {
+		name       string
+		executed   string
+		wantQty    string // quantity of the order placed again, empty if none
+		wantFilled string
+		wantProfit float64
+		wantCount  string // side of the counter order placed one level below
+	}{
+		{name: "nothing executed", executed: "0", wantQty: "0.0010"},
+		{name: "partly executed", executed: "0.0004", wantQty: "0.0006", wantFilled: "0.0004", wantProfit: 0.0004 * 10},
+		{name: "dust left", executed: "0.0009", wantFilled: "", wantProfit: 0.0009 * 10, wantCount: string(model.Buy)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := &fakeExchange{orders: map[string]model.Order{
+				"1": {ID: "1", State: model.StateCancelled, QuantityExecuted: tt.executed},
+			}}
+			g := &Grid{
+				Place:  &usecase.PlaceOrder{Ex: ex},
+				Get:    &usecase.GetOrder{Ex: ex},
+				Store:  memStore{},
+				Config: GridConfig{Market: btcbrl},
+				state: GridState{
+					Market:   "BTCBRL",
+					Quantity: "0.0010",
+					Levels: []GridLevel{
+						{Price: "100"},
+						{Price: "110", Side: model.Sell, OrderID: "1", EntryPrice: "100"},
+					},
+				},
+			}
+			if err := g.step(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			var placed []model.Order
+			for _, o := range ex.placed {
+				if o.Price == "110" {
+					placed = append(placed, o)
+				}
+			}
+			if tt.wantQty == "" && len(placed) > 0 {
+				t.Errorf("placed %+v, want nothing at 110", placed)
+			}
+			if tt.wantQty != "" && (len(placed) != 1 || placed[0].Quantity != tt.wantQty) {
+				t.Errorf("placed %+v, want one order of %s at 110", placed, tt.wantQty)
+			}
+			if got := g.state.Levels[1].Filled; got != tt.wantFilled {
+				t.Errorf("filled = %q, want %q", got, tt.wantFilled)
+			}
+			if got := g.state.RealizedProfit; abs(got-tt.wantProfit) > 1e-9 {
+				t.Errorf("realized profit = %v, want %v", got, tt.wantProfit)
+			}
+			if got := string(g.state.Levels[0].Side); got != tt.wantCount {
+				t.Errorf("level below side = %q, want %q", got, tt.wantCount)
+			}
+		})
+	}
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+// fakeExchange serves the orders it holds and records the ones placed.
+// The methods the tests do not need panic through the nil embedded port.
+type fakeExchange struct {
+	service.Exchange
+	book   model.OrderBook
+	orders map[string]model.Order
+	placed []model.Order
+}
+
+func (f *fakeExchange) GetOrderBook(ctx context.Context, market string, depth int) (*model.OrderBook, error) {
+	return &f.book, nil
+}
+
+func (f *fakeExchange) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
+	o, ok := f.orders[id]
+	if !ok {
+		return nil, service.ErrOrderNotFound
+	}
+	return &o, nil
+}
+
+func (f *fakeExchange) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
+	f.placed = append(f.placed, o)
+	o.ID = "placed-" + o.Price
+	o.State = model.StateActive
+	return &o, nil
+}
+
+// memStore is an in-memory service.StateStore.
+type memStore map[string][]byte
+
+func (m memStore) Load(key string, dest interface{}) (bool, error) {
+	b, ok := m[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(b, dest)
+}
+
+func (m memStore) Save(key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	m[key] = b
+	return err
+}
+
+func (m memStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m memStore) Keys(prefix string) ([]string, error) {
+	var keys []string
+	for k := range m {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,279 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+)
+
+// MarketMakerConfig describes how quotes are placed around the mid price.
+type MarketMakerConfig struct {
+	Market    model.Market
+	Spread    float64       // distance between bid and ask as a fraction of mid, e.g. 0.004
+	Size      string        // quantity of each quote
+	Threshold float64       // re-quote only when the target moves more than this fraction
+	Inventory float64       // base inventory held when the strategy starts
+	MaxLong   float64       // stop bidding once inventory reaches this (0 = no limit)
+	MaxShort  float64       // stop offering once inventory falls to -MaxShort (0 = no limit)
+	Skew      float64       // fraction of mid the quotes shift when inventory is at a limit
+	Interval  time.Duration // how often the book and the quotes are refreshed
+}
+
+// MarketMakerStats summarises what the market maker has done so far.
+type MarketMakerStats struct {
+	Inventory float64 // current base inventory
+	Bought    float64 // base bought by our bids
+	Sold      float64 // base sold by our asks
+	CashFlow  float64 // quote received minus quote paid
+	PnL       float64 // cash flow plus the inventory change valued at the last mid
+	Quotes    int     // orders placed
+	Cancels   int     // orders cancelled
+}
+
+// quote is the order currently resting on one side of the book.
+type quote struct {
+	id     string
+	price  float64
+	booked float64 // executed quantity already added to the stats
+}
+
+// MarketMaker quotes both sides around the order book mid with post-only
+// orders. Quotes are skewed against the current inventory — a long book
+// lowers both prices to sell more and buy less — and a side stops quoting
+// once its inventory limit is reached. A resting quote is only replaced
+// when its target price drifts beyond Threshold, which keeps the number of
+// API calls per refresh low.
+type MarketMaker struct {
+	Place  *usecase.PlaceOrder
+	Cancel *usecase.CancelOrder
+	Get    *usecase.GetOrder
+	List   *usecase.ListActiveOrders
+	Book   *usecase.FetchOrderBook
+	Config MarketMakerConfig
+
+	// OnUpdate, when set, receives the stats after every refresh.
+	OnUpdate func(MarketMakerStats)
+
+	bid, ask *quote
+	stats    MarketMakerStats
+}
+
+// Stats returns a snapshot of the strategy counters.
+func (mm *MarketMaker) Stats() MarketMakerStats {
+	return mm.stats
+}
+
+// Run quotes until ctx is cancelled and then pulls both quotes.
+func (mm *MarketMaker) Run(ctx context.Context) error {
+	if mm.Config.Spread <= 0 || parse(mm.Config.Size) <= 0 {
+		return errors.New("marketmaker: a positive spread and size are required")
+	}
+	mm.stats.Inventory = mm.Config.Inventory
+	interval := mm.Config.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := mm.step(ctx); err != nil {
+			slog.Warn("marketmaker: step failed", "market", mm.Config.Market.Symbol, "err", err)
+		}
+		if mm.OnUpdate != nil {
+			mm.OnUpdate(mm.stats)
+		}
+		select {
+		case <-ctx.Done():
+			// pulling the quotes must outlive ctx
+			return mm.pull(context.WithoutCancel(ctx))
+		case <-ticker.C:
+		}
+	}
+}
+
+// step books executions on the resting quotes and re-quotes if needed.
+func (mm *MarketMaker) step(ctx context.Context) error {
+	if err := mm.sync(ctx); err != nil {
+		return err
+	}
+	ob, err := mm.Book.Execute(ctx, mm.Config.Market.Symbol, 1)
+	if err != nil {
+		return err
+	}
+	if mid := ob.Mid(); mid > 0 {
+		mm.stats.PnL = mm.stats.CashFlow + (mm.stats.Inventory-mm.Config.Inventory)*mid
+	}
+	bid, ask, err := mm.targets(ob)
+	if err != nil {
+		return err
+	}
+	return errors.Join(
+		mm.requote(ctx, model.Buy, &mm.bid, bid),
+		mm.requote(ctx, model.Sell, &mm.ask, ask),
+	)
+}
+
+// targets returns the prices we want to quote, 0 meaning "do not quote".
+func (mm *MarketMaker) targets(ob *model.OrderBook) (bid, ask float64, err error) {
+	c := mm.Config
+	mid := ob.Mid()
+	if mid == 0 {
+		return 0, 0, fmt.Errorf("order book for %s is empty", c.Market.Symbol)
+	}
+
+	// inventory ratio in [-1, 1]: how close we are to the relevant limit
+	inv := mm.stats.Inventory
+	var ratio float64
+	switch {
+	case inv > 0 && c.MaxLong > 0:
+		ratio = math.Min(inv/c.MaxLong, 1)
+	case inv < 0 && c.MaxShort > 0:
+		ratio = math.Max(inv/c.MaxShort, -1)
+	}
+	center := mid * (1 - c.Skew*ratio)
+	bid = center * (1 - c.Spread/2)
+	ask = center * (1 + c.Spread/2)
+
+	// never cross the book: post-only orders would be rejected anyway
+	if best := ob.BestAsk(); best > 0 && bid >= best {
+		bid = best - parse(c.Market.PriceIncrement)
+	}
+	if best := ob.BestBid(); best > 0 && ask <= best {
+		ask = best + parse(c.Market.PriceIncrement)
+	}
+
+	size := parse(c.Size)
+	if c.MaxLong > 0 && inv+size > c.MaxLong {
+		bid = 0
+	}
+	if c.MaxShort > 0 && inv-size < -c.MaxShort {
+		ask = 0
+	}
+	return bid, ask, nil
+}
+
+// sync refreshes the resting quotes with a single listing of the active
+// orders, falling back to a lookup only for quotes that left the book.
+func (mm *MarketMaker) sync(ctx context.Context) error {
+	if mm.bid == nil && mm.ask == nil {
+		return nil
+	}
+	active, err := mm.List.Execute(ctx, mm.Config.Market.Symbol)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]model.Order, len(active))
+	for _, o := range active {
+		byID[o.ID] = o
+	}
+
+	var errs []error
+	for _, side := range []struct {
+		q    **quote
+		side model.OrderSide
+	}{{&mm.bid, model.Buy}, {&mm.ask, model.Sell}} {
+		q := *side.q
+		if q == nil {
+			continue
+		}
+		o, ok := byID[q.id]
+		if !ok {
+			got, err := mm.Get.Execute(ctx, q.id)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			o = *got
+		}
+		mm.book(side.side, q, o)
+		if !ok {
+			*side.q = nil // filled or cancelled: the side needs a new quote
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// book adds any new execution of quote q to the stats.
+func (mm *MarketMaker) book(side model.OrderSide, q *quote, o model.Order) {
+	executed := parse(o.QuantityExecuted)
+	delta := executed - q.booked
+	if delta <= 0 {
+		return
+	}
+	q.booked = executed
+	price := parse(o.PriceAvg)
+	if price == 0 {
+		price = q.price
+	}
+	if side == model.Buy {
+		mm.stats.Inventory += delta
+		mm.stats.Bought += delta
+		mm.stats.CashFlow -= delta * price
+	} else {
+		mm.stats.Inventory -= delta
+		mm.stats.Sold += delta
+		mm.stats.CashFlow += delta * price
+	}
+	slog.Info("marketmaker: quote filled", "side", side, "quantity", delta, "price", price, "inventory", mm.stats.Inventory)
+}
+
+// requote makes one side of the book match target with as few calls as
+// possible: nothing when the resting quote is close enough, a single place
+// when the side is empty, and cancel-then-place otherwise.
+func (mm *MarketMaker) requote(ctx context.Context, side model.OrderSide, slot **quote, target float64) error {
+	m := mm.Config.Market
+	q := *slot
+	if q != nil {
+		if target > 0 && math.Abs(target-q.price)/q.price <= mm.Config.Threshold {
+			return nil
+		}
+		if err := mm.Cancel.Execute(ctx, q.id); err != nil {
+			// keep tracking it: placing a replacement now could double our exposure
+			return fmt.Errorf("cancel %s: %w", q.id, err)
+		}
+		mm.stats.Cancels++
+		// book executions that raced the cancel
+		if o, err := mm.Get.Execute(ctx, q.id); err == nil {
+			mm.book(side, q, *o)
+		}
+		*slot = nil
+	}
+	if target <= 0 {
+		return nil
+	}
+
+	// round away from the spread: a bid rounded up (or an ask down) could
+	// cross the book and be rejected as post-only
+	price := m.AlignPriceDown(target)
+	if side == model.Sell {
+		price = m.AlignPriceUp(target)
+	}
+	o, err := mm.Place.Execute(ctx, model.Order{
+		MarketSymbol: m.Symbol,
+		Side:         side,
+		Type:         model.Limit,
+		Price:        price,
+		Quantity:     mm.Config.Size,
+		PostOnly:     true,
+	})
+	if err != nil {
+		return fmt.Errorf("place %s @ %s: %w", side, price, err)
+	}
+	mm.stats.Quotes++
+	*slot = &quote{id: o.ID, price: parse(price)}
+	return nil
+}
+
+// pull cancels both resting quotes.
+func (mm *MarketMaker) pull(ctx context.Context) error {
+	return errors.Join(
+		mm.requote(ctx, model.Buy, &mm.bid, 0),
+		mm.requote(ctx, model.Sell, &mm.ask, 0),
+	)
+}
@@ -0,0 +1,56 @@
+package strategy
+
+import (
+	"context"
+	"testing"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+)
+
+func TestMarketMakerQuotesNeverCross(t *testing.T) {
+	market := model.Market{Symbol: "BTCBRL", PriceIncrement: "0.5", PricePrecision: 1}
+	tests := []struct {
+		name             string
+		bid, ask         string  // best bid and ask of the book
+		inventory        float64 // with limits of 2 on both sides
+		wantBid, wantAsk string
+	}{
+		// short: quotes shift up and the bid lands at 99.79, which
+		// rounded to the nearest tick would be the best ask
+		{name: "bid skewed towards the ask", bid: "99", ask: "100", inventory: -1, wantBid: "99.5", wantAsk: "100.0"},
+		// long: the ask lands at 99.21, nearest the best bid
+		{name: "ask skewed towards the bid", bid: "99", ask: "100", inventory: 1, wantBid: "99.0", wantAsk: "99.5"},
+		{name: "flat", bid: "99", ask: "101", wantBid: "99.5", wantAsk: "100.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := &fakeExchange{book: model.OrderBook{
+				Bids: [][]string{{tt.bid, "1"}},
+				Asks: [][]string{{tt.ask, "1"}},
+			}}
+			mm := &MarketMaker{
+				Place: &usecase.PlaceOrder{Ex: ex},
+				Book:  &usecase.FetchOrderBook{Ex: ex},
+				Config: MarketMakerConfig{
+					Market: market, Spread: 0.0001, Size: "0.5",
+					MaxLong: 2, MaxShort: 2, Skew: 0.006,
+				},
+			}
+			mm.stats.Inventory = tt.inventory
+			if err := mm.step(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			prices := map[model.OrderSide]string{}
+			for _, o := range ex.placed {
+				prices[o.Side] = o.Price
+			}
+			if prices[model.Buy] != tt.wantBid || prices[model.Sell] != tt.wantAsk {
+				t.Errorf("quoted %s / %s, want %s / %s", prices[model.Buy], prices[model.Sell], tt.wantBid, tt.wantAsk)
+			}
+			if parse(prices[model.Buy]) >= parse(tt.ask) || parse(prices[model.Sell]) <= parse(tt.bid) {
+				t.Errorf("quotes %s / %s cross the book %s / %s", prices[model.Buy], prices[model.Sell], tt.bid, tt.ask)
+			}
+		})
+	}
+}
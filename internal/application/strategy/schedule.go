@@ -0,0 +1,163 @@
+package strategy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression with the classic five fields:
+// minute, hour, day of month, month and day of week.
+// Fields accept "*", lists ("1,15"), ranges ("1-5") and steps ("*/15");
+// months and weekdays also accept three-letter names ("jan", "mon").
+// The shortcuts @hourly, @daily, @weekly and @monthly are supported.
+type Schedule struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64 // bit sets of allowed values
+	domAny, dowAny                bool
+}
+
+var cronShortcuts = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// ParseSchedule parses a cron expression such as "0 9 * * mon".
+func ParseSchedule(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if s, ok := cronShortcuts[strings.ToLower(spec)]; ok {
+		spec = s
+	}
+	f := strings.Fields(spec)
+	if len(f) != 5 {
+		return nil, fmt.Errorf("schedule: %q must have 5 fields", expr)
+	}
+	s := &Schedule{expr: expr, domAny: f[2] == "*", dowAny: f[4] == "*"}
+	var err error
+	if s.minute, err = parseField(f[0], 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(f[1], 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(f[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(f[3], 1, 12, monthNames); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(f[4], 0, 7, dayNames); err != nil {
+		return nil, err
+	}
+	if s.dow&(1<<7) != 0 { // 7 is an alias for Sunday
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// String returns the expression the schedule was parsed from.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first minute strictly after t matching the schedule.
+// It returns the zero time if nothing matches within five years
+// (e.g. "0 0 30 feb *").
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches applies cron's rule that, when both day fields are
+// restricted, a day matching either of them is accepted.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// parseField converts one cron field into a bit set of allowed values.
+func parseField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rng = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("schedule: invalid step in %q", part)
+			}
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = fieldValue(bounds[0], names); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = fieldValue(bounds[1], names); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				hi = max // "5/15" means from 5 to the end in steps of 15
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("schedule: %q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// fieldValue parses a number or a month/weekday name.
+func fieldValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("schedule: invalid value %q", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,65 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// Wednesday 15 January 2025, 10:30
+	from := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2025, 1, 16, 10, 30, 0, 0, time.UTC)},
+		{"0 9 * * mon", time.Date(2025, 1, 20, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 mar *", time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"5/20 11 * * *", time.Date(2025, 1, 15, 11, 5, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		// both day fields restricted: either one matches
+		{"0 0 20 * fri", time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 feb *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := ParseSchedule(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"x * * * *",
+		"* * * foo *",
+	} {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want an error", expr)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package tax
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// WriteJSON writes the whole report as one JSON document.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes the operations, the monthly summary and the year-end
+// holdings to three CSV files in dir and returns their paths.
+func (r *Report) WriteCSV(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("tax: %w", err)
+	}
+	prefix := filepath.Join(dir, strconv.Itoa(r.Year)+"-")
+
+	ops := [][]string{{"date", "trade_id", "market", "asset", "kind", "quantity", "price_brl", "total_brl", "fee_brl", "cost_brl", "gain_brl"}}
+	for _, o := range r.Operations {
+		ops = append(ops, []string{
+			o.Date.In(Location).Format("2006-01-02 15:04:05"), o.TradeID, o.Market, strings.ToUpper(o.Asset), o.Kind,
+			num(o.Quantity, 8), num(o.Price, 2), num(o.Total, 2), num(o.Fee, 2), num(o.Cost, 2), num(o.Gain, 2),
+		})
+	}
+	months := [][]string{{"month", "sales_brl", "gains_brl", "losses_brl", "net_brl", "exempt", "taxable_brl", "tax_brl"}}
+	for _, m := range r.Months {
+		months = append(months, []string{
+			m.Month, num(m.Sales, 2), num(m.Gains, 2), num(m.Losses, 2), num(m.Net, 2),
+			strconv.FormatBool(m.Exempt), num(m.Taxable, 2), num(m.Tax, 2),
+		})
+	}
+	holdings := [][]string{{"asset", "quantity", "cost_brl", "average_cost_brl"}}
+	for _, h := range r.Holdings {
+		holdings = append(holdings, []string{strings.ToUpper(h.Asset), num(h.Quantity, 8), num(h.Cost, 2), num(h.AverageCost, 2)})
+	}
+
+	var paths []string
+	for _, f := range []struct {
+		name string
+		rows [][]string
+	}{{"operations", ops}, {"monthly", months}, {"holdings", holdings}} {
+		path := prefix + f.name + ".csv"
+		if err := writeCSV(path, f.rows); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func writeCSV(path string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("tax: %w", err)
+	}
+	w := csv.NewWriter(f)
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		f.Close()
+		return fmt.Errorf("tax: failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+func num(f float64, prec int) string {
+	return strconv.FormatFloat(f, 'f', prec, 64)
+}
@@ -0,0 +1,45 @@
+package tax
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"trading-bot/internal/application/usecase"
+)
+
+// CandleRates converts currencies to BRL with the daily close of their
+// BRL market on the day of the trade, the date in Brasília.
+type CandleRates struct {
+	Candles *usecase.FetchCandles
+
+	cache map[string]float64
+}
+
+// ToBRL implements Rates.
+func (c *CandleRates) ToBRL(ctx context.Context, currency string, at time.Time) (float64, error) {
+	currency = strings.ToLower(currency)
+	if currency == "brl" {
+		return 1, nil
+	}
+	// the bar of the trade's date in Brasília, whatever the date in UTC
+	y, m, d := at.In(Location).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	key := currency + "@" + day.Format("2006-01-02")
+	if rate, ok := c.cache[key]; ok {
+		return rate, nil
+	}
+	candles, err := c.Candles.Execute(ctx, currency+"brl", "1d", day, day.Add(24*time.Hour))
+	if err != nil {
+		return 0, fmt.Errorf("tax: no BRL rate for %s on %s: %w", currency, day.Format("2006-01-02"), err)
+	}
+	if len(candles) == 0 || parse(candles[len(candles)-1].Close) <= 0 {
+		return 0, fmt.Errorf("tax: no BRL rate for %s on %s", currency, day.Format("2006-01-02"))
+	}
+	if c.cache == nil {
+		c.cache = make(map[string]float64)
+	}
+	c.cache[key] = parse(candles[len(candles)-1].Close)
+	return c.cache[key], nil
+}
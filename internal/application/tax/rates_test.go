@@ -0,0 +1,70 @@
+package tax
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+func TestCandleRatesUseTheDateInBrasilia(t *testing.T) {
+	closes := map[string]string{"2024-03-10": "300000", "2024-03-11": "310000"}
+	tests := []struct {
+		name     string
+		currency string
+		at       time.Time
+		want     float64
+	}{
+		// already the 11th in UTC
+		{name: "22:00 in Brasília", currency: "BTC", at: time.Date(2024, 3, 10, 22, 0, 0, 0, Location), want: 300000},
+		{name: "early morning in Brasília", currency: "btc", at: time.Date(2024, 3, 11, 2, 0, 0, 0, Location), want: 310000},
+		{name: "same instant in UTC", currency: "btc", at: time.Date(2024, 3, 11, 1, 0, 0, 0, time.UTC), want: 300000},
+		{name: "BRL", currency: "BRL", at: time.Date(2024, 3, 10, 22, 0, 0, 0, Location), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := &fakeExchange{closes: closes}
+			r := &CandleRates{Candles: &usecase.FetchCandles{Ex: ex}}
+			for i := 0; i < 2; i++ {
+				got, err := r.ToBRL(context.Background(), tt.currency, tt.at)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want {
+					t.Errorf("ToBRL = %v, want %v", got, tt.want)
+				}
+			}
+			if ex.calls > 1 {
+				t.Errorf("fetched the candles %d times, want them cached", ex.calls)
+			}
+		})
+	}
+}
+
+func TestCandleRatesWithoutCandles(t *testing.T) {
+	r := &CandleRates{Candles: &usecase.FetchCandles{Ex: &fakeExchange{}}}
+	if _, err := r.ToBRL(context.Background(), "eth", time.Now()); err == nil {
+		t.Error("no error without a daily close")
+	}
+}
+
+// fakeExchange serves the daily bars in closes, keyed by their UTC date.
+type fakeExchange struct {
+	service.Exchange
+	closes map[string]string
+	calls  int
+}
+
+func (f *fakeExchange) GetCandles(ctx context.Context, market, interval string, start, end time.Time) ([]model.Candle, error) {
+	f.calls++
+	var out []model.Candle
+	for d := start; d.Before(end); d = d.Add(24 * time.Hour) {
+		if c, ok := f.closes[d.UTC().Format("2006-01-02")]; ok && d.Equal(d.Truncate(24*time.Hour)) {
+			out = append(out, model.Candle{OpenTime: d, Close: c})
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,324 @@
+// Package tax computes the monthly capital gains on crypto-asset sales
+// under the Brazilian rules and exports them for the accountant.
+//
+// The computation follows the usual reading of the rules for individuals
+// trading on a Brazilian exchange:
+//
+//   - acquisition cost is the weighted average cost (custo médio) of each
+//     asset, with purchase fees added to the cost;
+//   - every disposal counts as a sale, including swaps of one crypto-asset
+//     for another, valued in BRL at the time of the trade, and sale fees
+//     are deducted from the proceeds;
+//   - gains of a month are exempt when the month's sales total at most
+//     R$35,000; otherwise the net gain of the month is taxed at the
+//     progressive rates of Lei 13.259/2016 (DARF code 4600);
+//   - losses are not carried over to other months.
+//
+// It is an aid for the accountant, not tax advice.
+package tax
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"trading-bot/internal/domain/model"
+)
+
+// Exemption is the monthly sales total up to which gains are exempt.
+const Exemption = 35000.0
+
+// Location is the time zone of the Receita Federal: months and years
+// start at midnight in Brasília, whatever the zone of the machine.
+var Location = brasilia()
+
+func brasilia() *time.Location {
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		return time.FixedZone("BRT", -3*60*60) // no daylight saving since 2019
+	}
+	return loc
+}
+
+// brackets are the progressive capital gains rates, applied to the part
+// of the gain within each bracket.
+var brackets = []struct{ upTo, rate float64 }{
+	{5_000_000, 0.15},
+	{10_000_000, 0.175},
+	{30_000_000, 0.20},
+	{0, 0.225}, // above 30 million
+}
+
+// Operation is one acquisition or sale of an asset, in BRL.
+type Operation struct {
+	Date     time.Time `json:"date"`
+	TradeID  string    `json:"trade_id"`
+	Market   string    `json:"market"`
+	Asset    string    `json:"asset"`
+	Kind     string    `json:"kind"` // BUY or SELL
+	Quantity float64   `json:"quantity"`
+	Price    float64   `json:"price_brl"`
+	Total    float64   `json:"total_brl"` // gross value
+	Fee      float64   `json:"fee_brl"`
+	Cost     float64   `json:"cost_brl,omitempty"` // average cost of the quantity sold
+	Gain     float64   `json:"gain_brl,omitempty"` // proceeds net of fees minus cost
+}
+
+// Month summarises the sales of one calendar month.
+type Month struct {
+	Month   string  `json:"month"` // YYYY-MM
+	Sales   float64 `json:"sales_brl"`
+	Gains   float64 `json:"gains_brl"`
+	Losses  float64 `json:"losses_brl"`
+	Net     float64 `json:"net_brl"`
+	Exempt  bool    `json:"exempt"`
+	Taxable float64 `json:"taxable_brl"`
+	Tax     float64 `json:"tax_brl"`
+}
+
+// Holding is an asset held on December 31, as declared under "Bens e
+// Direitos" at its acquisition cost.
+type Holding struct {
+	Asset       string  `json:"asset"`
+	Quantity    float64 `json:"quantity"`
+	Cost        float64 `json:"cost_brl"`
+	AverageCost float64 `json:"average_cost_brl"`
+}
+
+// Report is the tax report of one calendar year.
+type Report struct {
+	Year       int         `json:"year"`
+	Operations []Operation `json:"operations"`
+	Months     []Month     `json:"months"`
+	Holdings   []Holding   `json:"holdings"`
+}
+
+// Rates converts an amount of a currency to BRL at a given time.
+type Rates interface {
+	ToBRL(ctx context.Context, currency string, at time.Time) (float64, error)
+}
+
+// position is the quantity and total cost of one asset.
+type position struct {
+	qty, cost float64
+}
+
+// Generate computes the report of year from the whole trade history,
+// oldest first. Trades before the year only build up the average costs.
+func Generate(ctx context.Context, trades []model.Trade, markets []model.Market, rates Rates, year int) (*Report, error) {
+	byID := make(map[string]model.Market, len(markets))
+	for _, m := range markets {
+		byID[strings.ToUpper(m.Symbol)] = m
+	}
+	end := time.Date(year+1, 1, 1, 0, 0, 0, 0, Location)
+	start := time.Date(year, 1, 1, 0, 0, 0, 0, Location)
+
+	r := &Report{Year: year}
+	positions := make(map[string]*position)
+	get := func(asset string) *position {
+		if positions[asset] == nil {
+			positions[asset] = &position{}
+		}
+		return positions[asset]
+	}
+	record := func(op Operation) {
+		if !op.Date.Before(start) {
+			r.Operations = append(r.Operations, op)
+		}
+	}
+	buy := func(op Operation, received float64) {
+		p := get(op.Asset)
+		p.qty += received
+		p.cost += op.Total + op.Fee
+		op.Quantity = received
+		record(op)
+	}
+	sell := func(op Operation) {
+		p := get(op.Asset)
+		if p.qty > 0 {
+			op.Cost = p.cost * minf(op.Quantity/p.qty, 1)
+		}
+		if op.Quantity > p.qty+1e-12 {
+			slog.Warn("tax: sold more than on record; the excess is costed at zero",
+				"date", op.Date.Format("2006-01-02"), "sold", op.Quantity, "asset", op.Asset, "held", p.qty)
+		}
+		p.cost -= op.Cost
+		p.qty -= op.Quantity
+		if p.qty <= 1e-12 {
+			p.qty, p.cost = 0, 0
+		}
+		op.Gain = op.Total - op.Fee - op.Cost
+		record(op)
+	}
+
+	for _, t := range trades {
+		if !t.CreatedAt.Before(end) {
+			break
+		}
+		m, ok := byID[strings.ToUpper(t.MarketSymbol)]
+		if !ok {
+			return nil, fmt.Errorf("tax: trade %s is in unknown market %s", t.ID, t.MarketSymbol)
+		}
+		base, quote := strings.ToLower(m.Base.Symbol), strings.ToLower(m.Quote.Symbol)
+		rate, err := rates.ToBRL(ctx, quote, t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		qty, price := parse(t.Quantity), parse(t.Price)
+		notional := qty * price
+
+		// fees paid in the base asset reduce what a purchase delivers,
+		// fees paid in the quote what a sale delivers; any other fee
+		// currency is converted and charged in BRL
+		var fee, feeBase, feeQuote float64
+		if f := parse(t.Fee); f > 0 {
+			fc := strings.ToLower(t.FeeCurrency)
+			if fc == "" {
+				fc = quote
+			}
+			switch fc {
+			case base:
+				feeBase = f
+				fee = f * price * rate
+			case quote:
+				feeQuote = f
+				fee = f * rate
+			default:
+				fr, err := rates.ToBRL(ctx, fc, t.CreatedAt)
+				if err != nil {
+					return nil, err
+				}
+				fee = f * fr
+			}
+		}
+
+		op := Operation{
+			Date:     t.CreatedAt,
+			TradeID:  t.ID,
+			Market:   m.Symbol,
+			Asset:    base,
+			Quantity: qty,
+			Price:    price * rate,
+			Total:    notional * rate,
+		}
+		counter := Operation{
+			Date:     t.CreatedAt,
+			TradeID:  t.ID,
+			Market:   m.Symbol,
+			Asset:    quote,
+			Quantity: notional,
+			Price:    rate,
+			Total:    notional * rate,
+		}
+		if t.Side == model.Buy {
+			op.Kind = "BUY"
+			if feeBase == 0 {
+				op.Fee = fee
+			}
+			buy(op, qty-feeBase)
+			if quote != "brl" {
+				// paying with a crypto-asset disposes of it, the fee too
+				counter.Kind = "SELL"
+				counter.Quantity += feeQuote
+				counter.Total += feeQuote * rate
+				sell(counter)
+			}
+		} else {
+			op.Kind = "SELL"
+			if feeBase > 0 {
+				op.Quantity += feeBase // the fee is sold along, at its cost
+			} else {
+				op.Fee = fee
+			}
+			sell(op)
+			if quote != "brl" {
+				counter.Kind = "BUY"
+				counter.Total -= feeQuote * rate
+				buy(counter, notional-feeQuote)
+			}
+		}
+	}
+
+	r.Months = months(r.Operations)
+	for asset, p := range positions {
+		if p.qty <= 0 || asset == "brl" {
+			continue
+		}
+		r.Holdings = append(r.Holdings, Holding{Asset: asset, Quantity: p.qty, Cost: p.cost, AverageCost: p.cost / p.qty})
+	}
+	sort.Slice(r.Holdings, func(i, j int) bool { return r.Holdings[i].Asset < r.Holdings[j].Asset })
+	return r, nil
+}
+
+// months groups the sales by calendar month and applies the exemption
+// and the progressive rates.
+func months(ops []Operation) []Month {
+	var out []Month
+	index := make(map[string]int)
+	for _, op := range ops {
+		if op.Kind != "SELL" {
+			continue
+		}
+		key := op.Date.In(Location).Format("2006-01")
+		i, ok := index[key]
+		if !ok {
+			i = len(out)
+			index[key] = i
+			out = append(out, Month{Month: key})
+		}
+		m := &out[i]
+		m.Sales += op.Total
+		if op.Gain >= 0 {
+			m.Gains += op.Gain
+		} else {
+			m.Losses -= op.Gain
+		}
+	}
+	for i := range out {
+		m := &out[i]
+		m.Net = m.Gains - m.Losses
+		m.Exempt = m.Sales <= Exemption
+		if !m.Exempt && m.Net > 0 {
+			m.Taxable = m.Net
+			m.Tax = progressive(m.Net)
+		}
+	}
+	return out
+}
+
+// progressive applies the capital gains brackets to gain.
+func progressive(gain float64) float64 {
+	var tax, floor float64
+	for _, b := range brackets {
+		top := gain
+		if b.upTo > 0 && b.upTo < gain {
+			top = b.upTo
+		}
+		if top > floor {
+			tax += (top - floor) * b.rate
+		}
+		if b.upTo == 0 || gain <= b.upTo {
+			break
+		}
+		floor = b.upTo
+	}
+	return tax
+}
+
+func minf(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// parse converts an exchange decimal string to float64, treating
+// malformed input as zero.
+func parse(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
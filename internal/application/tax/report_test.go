@@ -0,0 +1,148 @@
+package tax
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"trading-bot/internal/domain/model"
+)
+
+func TestProgressive(t *testing.T) {
+	tests := []struct {
+		gain, want float64
+	}{
+		{0, 0},
+		{1000, 150},
+		{5_000_000, 750_000},
+		{6_000_000, 750_000 + 175_000},
+		{10_000_000, 750_000 + 875_000},
+		{30_000_000, 750_000 + 875_000 + 4_000_000},
+		{40_000_000, 750_000 + 875_000 + 4_000_000 + 2_250_000},
+	}
+	for _, tt := range tests {
+		if got := progressive(tt.gain); !near(got, tt.want) {
+			t.Errorf("progressive(%v) = %v, want %v", tt.gain, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateExemption(t *testing.T) {
+	tests := []struct {
+		name       string
+		sale       string // BRL received for 1 BTC bought for 30000
+		wantExempt bool
+		wantTax    float64
+	}{
+		{name: "below the limit", sale: "34999.99", wantExempt: true},
+		{name: "exactly R$35k", sale: "35000", wantExempt: true},
+		{name: "just above", sale: "35000.01", wantTax: 5000.01 * 0.15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trades := []model.Trade{
+				trade("1", "BTCBRL", model.Buy, "30000", "1", at(2024, 3, 1)),
+				trade("2", "BTCBRL", model.Sell, tt.sale, "1", at(2024, 3, 10)),
+			}
+			r, err := Generate(context.Background(), trades, markets, rates{}, 2024)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(r.Months) != 1 {
+				t.Fatalf("months %+v, want March only", r.Months)
+			}
+			m := r.Months[0]
+			if m.Exempt != tt.wantExempt || !near(m.Tax, tt.wantTax) {
+				t.Errorf("exempt %v tax %v, want %v %v", m.Exempt, m.Tax, tt.wantExempt, tt.wantTax)
+			}
+		})
+	}
+}
+
+func TestGenerateMonthInBrasilia(t *testing.T) {
+	// 02:30 UTC on February 1 is still January 31 in Brasília
+	sold := time.Date(2024, 2, 1, 2, 30, 0, 0, time.UTC)
+	trades := []model.Trade{
+		trade("1", "BTCBRL", model.Buy, "100000", "1", at(2024, 1, 5)),
+		trade("2", "BTCBRL", model.Sell, "110000", "1", sold),
+	}
+	r, err := Generate(context.Background(), trades, markets, rates{}, 2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Months) != 1 || r.Months[0].Month != "2024-01" {
+		t.Errorf("months %+v, want the sale in 2024-01", r.Months)
+	}
+
+	// a sale at 01:00 UTC on January 1 belongs to the year before
+	trades[1].CreatedAt = time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+	if r, err = Generate(context.Background(), trades, markets, rates{}, 2025); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Months) != 0 {
+		t.Errorf("months %+v in 2025, want none", r.Months)
+	}
+}
+
+func TestGenerateQuoteFee(t *testing.T) {
+	// USDT bought at 5 BRL pays for BTC plus a 1 USDT fee
+	buy := trade("2", "BTCUSDT", model.Buy, "60000", "0.01", at(2024, 5, 2))
+	buy.Fee, buy.FeeCurrency = "1", "usdt"
+	trades := []model.Trade{
+		trade("1", "USDTBRL", model.Buy, "5", "1000", at(2024, 5, 1)),
+		buy,
+	}
+	r, err := Generate(context.Background(), trades, markets, rates{"usdt": 5}, 2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sale *Operation
+	for i, op := range r.Operations {
+		if op.Asset == "usdt" && op.Kind == "SELL" {
+			sale = &r.Operations[i]
+		}
+	}
+	if sale == nil || !near(sale.Quantity, 601) || !near(sale.Total, 3005) {
+		t.Fatalf("usdt sale %+v, want 601 USDT for R$3005", sale)
+	}
+	holdings := map[string]Holding{}
+	for _, h := range r.Holdings {
+		holdings[h.Asset] = h
+	}
+	if got := holdings["usdt"].Quantity; !near(got, 399) {
+		t.Errorf("usdt held %v, want 399", got)
+	}
+	if got := holdings["btc"].Cost; !near(got, 3005) {
+		t.Errorf("btc cost %v, want R$3005 including the fee", got)
+	}
+}
+
+var markets = []model.Market{
+	{Symbol: "BTCBRL", Base: model.Asset{Symbol: "btc"}, Quote: model.Asset{Symbol: "brl"}},
+	{Symbol: "USDTBRL", Base: model.Asset{Symbol: "usdt"}, Quote: model.Asset{Symbol: "brl"}},
+	{Symbol: "BTCUSDT", Base: model.Asset{Symbol: "btc"}, Quote: model.Asset{Symbol: "usdt"}},
+}
+
+// rates are fixed BRL rates; BRL itself is 1.
+type rates map[string]float64
+
+func (r rates) ToBRL(ctx context.Context, currency string, at time.Time) (float64, error) {
+	if rate, ok := r[strings.ToLower(currency)]; ok {
+		return rate, nil
+	}
+	return 1, nil
+}
+
+func trade(id, market string, side model.OrderSide, price, qty string, at time.Time) model.Trade {
+	return model.Trade{ID: id, MarketSymbol: market, Side: side, Price: price, Quantity: qty, CreatedAt: at}
+}
+
+func at(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 12, 0, 0, 0, Location)
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
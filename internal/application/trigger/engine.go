@@ -0,0 +1,319 @@
+package trigger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// keyPrefix namespaces triggers in the state store; each trigger is kept
+// under its own key so concurrent CLI invocations never overwrite each other.
+const keyPrefix = "trigger-"
+
+// maxAttempts is how many times a rejected child order is retried.
+const maxAttempts = 5
+
+// Engine persists triggers and fires them when the watched price reaches
+// their stop price.
+type Engine struct {
+	Place    *usecase.PlaceOrder
+	Ticker   *usecase.FetchTicker
+	Store    service.StateStore
+	Interval time.Duration // how often prices are checked
+
+	// GetOrder and CancelOrder are only needed by triggers paired with a
+	// resting order (see Trigger.OCO).
+	GetOrder    *usecase.GetOrder
+	CancelOrder *usecase.CancelOrder
+}
+
+// Add validates t, assigns it an ID and saves it as pending.
+func (e *Engine) Add(t Trigger) (*Trigger, error) {
+	t.Market = strings.ToUpper(t.Market)
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("trigger: failed to generate ID: %w", err)
+	}
+	t.ID = "trg-" + hex.EncodeToString(id)
+	t.Status = Pending
+	t.CreatedAt = time.Now()
+	return &t, e.save(&t)
+}
+
+// Get returns the trigger with the given ID.
+func (e *Engine) Get(id string) (*Trigger, error) {
+	var t Trigger
+	found, err := e.Store.Load(storeKey(id), &t)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("trigger: %q not found", id)
+	}
+	return &t, nil
+}
+
+// List returns every saved trigger, whatever its status.
+func (e *Engine) List() ([]Trigger, error) {
+	keys, err := e.Store.Keys(keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Trigger, 0, len(keys))
+	for _, k := range keys {
+		var t Trigger
+		if _, err := e.Store.Load(k, &t); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// Cancel stops a pending trigger from firing.
+func (e *Engine) Cancel(id string) error {
+	t, err := e.Get(id)
+	if err != nil {
+		return err
+	}
+	if t.Status != Pending {
+		return fmt.Errorf("trigger: %s is already %s", t.ID, t.Status)
+	}
+	t.Status = Cancelled
+	return e.save(t)
+}
+
+// DisarmAll cancels every pending trigger, recording reason, so that none
+// fires later. It returns the cancelled triggers as orders.
+func (e *Engine) DisarmAll(reason string) ([]model.Order, error) {
+	all, err := e.List()
+	if err != nil {
+		return nil, err
+	}
+	var out []model.Order
+	var errs []error
+	for _, t := range all {
+		if t.Status != Pending {
+			continue
+		}
+		changed, err := e.Update(t.ID, func(t *Trigger) bool {
+			t.Status, t.Error = Cancelled, "disarmed: "+reason
+			return true
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("trigger %s: %w", t.ID, err))
+			continue
+		}
+		if changed {
+			slog.Warn("trigger: disarmed", "trigger", t.ID, "reason", reason)
+			out = append(out, t.Order())
+		}
+	}
+	return out, errors.Join(errs...)
+}
+
+// Update applies change to trigger id and saves it if change reports that
+// something changed. It reports false, leaving the trigger alone, when the
+// trigger is no longer pending.
+func (e *Engine) Update(id string, change func(t *Trigger) bool) (bool, error) {
+	t, err := e.Get(id)
+	if err != nil || t.Status != Pending {
+		return false, err
+	}
+	if !change(t) {
+		return true, nil
+	}
+	return true, e.save(t)
+}
+
+// Run checks the pending triggers every Interval until ctx is cancelled.
+// Triggers added or cancelled by other processes are picked up on the
+// next check.
+func (e *Engine) Run(ctx context.Context) error {
+	interval := e.Interval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := e.Check(ctx); err != nil {
+			slog.Warn("trigger: check failed", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check fetches one ticker per market with pending triggers and fires the
+// triggers whose stop price was reached.
+func (e *Engine) Check(ctx context.Context) error {
+	all, err := e.List()
+	if err != nil {
+		return err
+	}
+	byMarket := make(map[string][]Trigger)
+	for _, t := range all {
+		if t.Status == Pending {
+			byMarket[t.Market] = append(byMarket[t.Market], t)
+		}
+	}
+
+	var errs []error
+	for market, ts := range byMarket {
+		tk, err := e.Ticker.Execute(ctx, market)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ticker %s: %w", market, err))
+			continue
+		}
+		for i := range ts {
+			price := ts[i].watched(tk)
+			if ts[i].Track(price) {
+				if err := e.track(ts[i].ID, price); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+			}
+			if ts[i].Fires(price) {
+				if err := e.fire(ctx, ts[i].ID, price); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// track saves the new extreme of trailing stop id, re-reading it first so a
+// concurrent cancel is not overwritten.
+func (e *Engine) track(id string, price float64) error {
+	t, err := e.Get(id)
+	if err != nil || t.Status != Pending {
+		return err
+	}
+	if t.Track(price) {
+		slog.Info("trigger: trailing stop moved", "trigger", t.ID, "stop", t.StopPrice, "extreme", t.Extreme)
+		return e.save(t)
+	}
+	return nil
+}
+
+// ActiveOrders returns the pending triggers of market (all markets when
+// empty) as orders, for listing next to the exchange's active orders.
+func (e *Engine) ActiveOrders(market string) ([]model.Order, error) {
+	all, err := e.List()
+	if err != nil {
+		return nil, err
+	}
+	var out []model.Order
+	for _, t := range all {
+		if t.Status == Pending && (market == "" || strings.EqualFold(t.Market, market)) {
+			out = append(out, t.Order())
+		}
+	}
+	return out, nil
+}
+
+// fire sends the child order of trigger id. The trigger is saved as
+// TRIGGERED before the order goes out, so a crash in between can never
+// send the child twice after a restart.
+func (e *Engine) fire(ctx context.Context, id string, price float64) error {
+	t, err := e.Get(id) // re-read: it may have been cancelled meanwhile
+	if err != nil || t.Status != Pending {
+		return err
+	}
+	t.Status, t.TriggeredAt = Triggered, time.Now()
+	if err := e.save(t); err != nil {
+		return err
+	}
+	slog.Info("trigger: fired", "trigger", t.ID, "type", t.Type, "market", t.Market, "price", price, "stop", t.StopPrice)
+
+	if t.OCO != "" {
+		left, err := e.cancelOCO(ctx, t)
+		if errors.Is(err, service.ErrOrderNotFound) {
+			// retrying cannot help, and what it executed is unknown
+			slog.Warn("trigger: paired order not found, cancelling", "trigger", t.ID, "order", t.OCO)
+			t.Status, t.Error = Cancelled, fmt.Sprintf("the paired order %s was not found: %v", t.OCO, err)
+			return e.save(t)
+		}
+		if err != nil {
+			t.Status = Pending // the paired order may still execute: do not send more
+			if serr := e.save(t); serr != nil {
+				return serr
+			}
+			return fmt.Errorf("trigger %s: %w", t.ID, err)
+		}
+		t.OCO, t.OCOExecuted = "", 0
+		if left <= 1e-12 {
+			slog.Info("trigger: paired order executed everything, nothing to send", "trigger", t.ID)
+			t.Status, t.Error = Cancelled, "the paired order executed the whole quantity"
+			return e.save(t)
+		}
+		t.Quantity = format(left)
+		if err := e.save(t); err != nil {
+			return err
+		}
+	}
+
+	o, err := e.Place.Execute(ctx, t.Child())
+	if err != nil {
+		t.Attempts++
+		t.Error = err.Error()
+		t.Status = Pending
+		if t.Attempts >= maxAttempts {
+			t.Status = Failed
+		}
+		if serr := e.save(t); serr != nil {
+			return serr
+		}
+		return fmt.Errorf("trigger %s: %w", t.ID, err)
+	}
+	t.OrderID, t.Error = o.ID, ""
+	return e.save(t)
+}
+
+// cancelOCO cancels the order t is paired with and returns what is left of
+// t's quantity once what that order executed since is taken off.
+func (e *Engine) cancelOCO(ctx context.Context, t *Trigger) (float64, error) {
+	if e.GetOrder == nil || e.CancelOrder == nil {
+		return 0, errors.New("no order access to cancel the paired order")
+	}
+	o, err := e.GetOrder.Execute(ctx, t.OCO)
+	if err != nil {
+		return 0, fmt.Errorf("get %s: %w", t.OCO, err)
+	}
+	if !o.State.Final() {
+		if err := e.CancelOrder.Execute(ctx, t.OCO); err != nil {
+			return 0, fmt.Errorf("cancel %s: %w", t.OCO, err)
+		}
+		// read it back: executions that raced the cancel are not lost
+		if o, err = e.GetOrder.Execute(ctx, t.OCO); err != nil {
+			return 0, fmt.Errorf("get %s: %w", t.OCO, err)
+		}
+	}
+	slog.Info("trigger: paired order cancelled", "trigger", t.ID, "order", t.OCO, "executed", o.QuantityExecuted)
+	return parse(t.Quantity) - (parse(o.QuantityExecuted) - t.OCOExecuted), nil
+}
+
+func (e *Engine) save(t *Trigger) error {
+	return e.Store.Save(storeKey(t.ID), t)
+}
+
+func storeKey(id string) string {
+	return keyPrefix + id
+}
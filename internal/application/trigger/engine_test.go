@@ -0,0 +1,208 @@
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+func TestEngineFire(t *testing.T) {
+	tests := []struct {
+		name        string
+		oco         *model.Order // the paired order, if any
+		cancelErr   error
+		placeErr    error
+		attempts    int
+		wantStatus  Status
+		wantPlaced  string // quantity of the child order, empty if none
+		wantError   string // in the saved trigger
+		wantErr     bool
+		wantOCOLeft bool // the paired order is still referenced
+	}{
+		{name: "no paired order", wantStatus: Triggered, wantPlaced: "1"},
+		{
+			name:       "paired order cancelled first",
+			oco:        &model.Order{ID: "tp", State: model.StateActive, QuantityExecuted: "0"},
+			wantStatus: Triggered, wantPlaced: "1",
+		},
+		{
+			name:       "paired order partly executed",
+			oco:        &model.Order{ID: "tp", State: model.StatePartiallyFilled, QuantityExecuted: "0.4"},
+			wantStatus: Triggered, wantPlaced: "0.6",
+		},
+		{
+			name:       "paired order filled",
+			oco:        &model.Order{ID: "tp", State: model.StateFilled, QuantityExecuted: "1"},
+			wantStatus: Cancelled, wantError: "executed the whole quantity",
+		},
+		{
+			name:        "paired order not found",
+			oco:         &model.Order{ID: "gone"},
+			wantStatus:  Cancelled,
+			wantError:   "gone was not found",
+			wantOCOLeft: true,
+		},
+		{
+			name:        "paired order will not cancel",
+			oco:         &model.Order{ID: "tp", State: model.StateActive},
+			cancelErr:   errors.New("exchange unavailable"),
+			wantStatus:  Pending,
+			wantErr:     true,
+			wantOCOLeft: true,
+		},
+		{name: "child rejected", placeErr: errors.New("insufficient balance"), wantStatus: Pending, wantError: "insufficient balance", wantErr: true},
+		{name: "child rejected too often", placeErr: errors.New("insufficient balance"), attempts: maxAttempts - 1, wantStatus: Failed, wantError: "insufficient balance", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := &fakeExchange{orders: map[string]model.Order{}, cancelErr: tt.cancelErr, placeErr: tt.placeErr}
+			e := newEngine(ex)
+			trg, err := e.Add(Trigger{Type: model.StopMarket, Market: "btcbrl", Side: model.Sell, Quantity: "1", StopPrice: "90", Source: Last})
+			if err != nil {
+				t.Fatal(err)
+			}
+			trg.Attempts = tt.attempts
+			if tt.oco != nil {
+				trg.OCO = tt.oco.ID
+				if tt.oco.ID != "gone" {
+					ex.orders[tt.oco.ID] = *tt.oco
+				}
+			}
+			if err := e.save(trg); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := e.fire(context.Background(), trg.ID, 89); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			got, err := e.Get(trg.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != tt.wantStatus || !strings.Contains(got.Error, tt.wantError) {
+				t.Errorf("status %s (%q), want %s (%q)", got.Status, got.Error, tt.wantStatus, tt.wantError)
+			}
+			if (got.OCO != "") != tt.wantOCOLeft {
+				t.Errorf("paired order %q, want it kept: %v", got.OCO, tt.wantOCOLeft)
+			}
+			switch {
+			case tt.wantPlaced == "" && len(ex.placed) > 0:
+				t.Errorf("placed %+v, want nothing", ex.placed)
+			case tt.wantPlaced != "" && (len(ex.placed) != 1 || ex.placed[0].Quantity != tt.wantPlaced):
+				t.Errorf("placed %+v, want %s", ex.placed, tt.wantPlaced)
+			case tt.wantPlaced != "" && (got.OrderID != "child" || got.Quantity != tt.wantPlaced):
+				t.Errorf("trigger %+v, want the child order of %s", got, tt.wantPlaced)
+			}
+			if o, ok := ex.orders["tp"]; ok && tt.cancelErr == nil && !o.State.Final() {
+				t.Errorf("the paired order is still %s", o.State)
+			}
+		})
+	}
+}
+
+func TestEngineFireSkipsCancelledTriggers(t *testing.T) {
+	ex := &fakeExchange{orders: map[string]model.Order{}}
+	e := newEngine(ex)
+	trg, err := e.Add(Trigger{Type: model.StopMarket, Market: "btcbrl", Side: model.Sell, Quantity: "1", StopPrice: "90", Source: Last})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Cancel(trg.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.fire(context.Background(), trg.ID, 89); err != nil {
+		t.Fatal(err)
+	}
+	if len(ex.placed) > 0 {
+		t.Errorf("placed %+v for a cancelled trigger", ex.placed)
+	}
+}
+
+func newEngine(ex *fakeExchange) *Engine {
+	return &Engine{
+		Place:       &usecase.PlaceOrder{Ex: ex},
+		Ticker:      &usecase.FetchTicker{Ex: ex},
+		Store:       memStore{},
+		GetOrder:    &usecase.GetOrder{Ex: ex},
+		CancelOrder: &usecase.CancelOrder{Ex: ex},
+	}
+}
+
+// fakeExchange holds the orders a trigger may be paired with and records
+// the child orders placed.
+type fakeExchange struct {
+	service.Exchange
+	orders    map[string]model.Order
+	placed    []model.Order
+	cancelErr error
+	placeErr  error
+}
+
+func (f *fakeExchange) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
+	if f.placeErr != nil {
+		return nil, f.placeErr
+	}
+	f.placed = append(f.placed, o)
+	o.ID = "child"
+	return &o, nil
+}
+
+func (f *fakeExchange) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
+	o, ok := f.orders[id]
+	if !ok {
+		return nil, service.ErrOrderNotFound
+	}
+	return &o, nil
+}
+
+func (f *fakeExchange) CancelOrder(ctx context.Context, id string) error {
+	if f.cancelErr != nil {
+		return f.cancelErr
+	}
+	o, ok := f.orders[id]
+	if !ok || o.State.Final() {
+		return fmt.Errorf("cannot cancel %s", id)
+	}
+	o.State = model.StateCancelled
+	f.orders[id] = o
+	return nil
+}
+
+// memStore is an in-memory service.StateStore.
+type memStore map[string][]byte
+
+func (m memStore) Load(key string, dest interface{}) (bool, error) {
+	b, ok := m[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(b, dest)
+}
+
+func (m memStore) Save(key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	m[key] = b
+	return err
+}
+
+func (m memStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m memStore) Keys(prefix string) ([]string, error) {
+	var keys []string
+	for k := range m {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,209 @@
+package trigger
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"trading-bot/internal/domain/model"
+)
+
+// PriceSource selects which price a trigger watches.
+type PriceSource string
+
+const (
+	Last PriceSource = "LAST" // price of the latest trade
+	Bid  PriceSource = "BID"  // best bid
+	Ask  PriceSource = "ASK"  // best ask
+)
+
+// Status is the lifecycle stage of a trigger.
+type Status string
+
+const (
+	Pending   Status = "PENDING"   // watching the price
+	Triggered Status = "TRIGGERED" // child order sent (or being sent)
+	Failed    Status = "FAILED"    // the child order was rejected too many times
+	Cancelled Status = "CANCELLED"
+)
+
+// Trigger is a conditional order held locally until its stop price is
+// reached, at which point its child order is sent to the exchange.
+type Trigger struct {
+	ID          string          `json:"id"`
+	Type        model.OrderType `json:"type"`
+	Market      string          `json:"market"`
+	Side        model.OrderSide `json:"side"`
+	Quantity    string          `json:"quantity"`
+	StopPrice   string          `json:"stop_price"`
+	LimitPrice  string          `json:"limit_price,omitempty"`
+	Source      PriceSource     `json:"source"`
+	Status      Status          `json:"status"`
+	OrderID     string          `json:"order_id,omitempty"`
+	Attempts    int             `json:"attempts,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	TriggeredAt time.Time       `json:"triggered_at,omitempty"`
+
+	// Trailing stops follow the market by TrailAmount (absolute) or
+	// TrailPercent once the price reaches ActivationPrice (immediately if
+	// empty). Extreme is the best price seen since activation, and
+	// StopPrice is kept at Extreme minus (or plus) the trailing distance.
+	TrailAmount     string  `json:"trail_amount,omitempty"`
+	TrailPercent    float64 `json:"trail_percent,omitempty"`
+	ActivationPrice string  `json:"activation_price,omitempty"`
+	Activated       bool    `json:"activated,omitempty"`
+	Extreme         string  `json:"extreme,omitempty"`
+
+	// A trigger protecting a position together with an order resting on
+	// the exchange, like the stop of an OCO, cancels that order before it
+	// fires and only sends what the order did not execute. OCOExecuted is
+	// what the order had executed when Quantity was last set. Parent is
+	// the composite order the trigger belongs to, if any.
+	Parent      string  `json:"parent,omitempty"`
+	OCO         string  `json:"oco,omitempty"`
+	OCOExecuted float64 `json:"oco_executed,omitempty"`
+}
+
+// Validate checks that t describes a complete conditional order.
+func (t *Trigger) Validate() error {
+	switch t.Type {
+	case model.StopMarket, model.TakeProfit:
+	case model.StopLimit:
+		if parse(t.LimitPrice) <= 0 {
+			return errors.New("trigger: a STOP_LIMIT needs a limit price")
+		}
+	case model.TrailingStop:
+		if (parse(t.TrailAmount) > 0) == (t.TrailPercent > 0) {
+			return errors.New("trigger: a TRAILING_STOP needs either a trail amount or a trail percent")
+		}
+		if t.TrailPercent >= 100 {
+			return errors.New("trigger: trail percent must be below 100")
+		}
+	default:
+		return fmt.Errorf("trigger: unsupported type %q", t.Type)
+	}
+	if t.Market == "" || parse(t.Quantity) <= 0 {
+		return errors.New("trigger: market and quantity are required")
+	}
+	if t.Type != model.TrailingStop && parse(t.StopPrice) <= 0 {
+		return errors.New("trigger: a stop price is required")
+	}
+	if t.Side != model.Buy && t.Side != model.Sell {
+		return fmt.Errorf("trigger: invalid side %q", t.Side)
+	}
+	switch t.Source {
+	case Last, Bid, Ask:
+	default:
+		return fmt.Errorf("trigger: invalid price source %q", t.Source)
+	}
+	return nil
+}
+
+// Fires reports whether price has reached the stop. Stops guard against
+// adverse moves — a sell stop fires when the price falls to the stop, a buy
+// stop when it rises to it — while take-profits fire the other way round.
+func (t *Trigger) Fires(price float64) bool {
+	if price <= 0 || (t.Type == model.TrailingStop && !t.Activated) {
+		return false
+	}
+	stop := parse(t.StopPrice)
+	falling := t.Side == model.Sell
+	if t.Type == model.TakeProfit {
+		falling = !falling
+	}
+	if falling {
+		return price <= stop
+	}
+	return price >= stop
+}
+
+// Track moves a trailing stop along with price: it activates the trigger
+// once ActivationPrice is reached, then raises (for a sell) or lowers (for
+// a buy) the stop whenever the price sets a new extreme. It reports whether
+// anything changed and should be saved.
+func (t *Trigger) Track(price float64) bool {
+	if t.Type != model.TrailingStop || price <= 0 {
+		return false
+	}
+	sell := t.Side == model.Sell
+	if !t.Activated {
+		act := parse(t.ActivationPrice)
+		if act > 0 && ((sell && price < act) || (!sell && price > act)) {
+			return false
+		}
+		t.Activated = true
+	} else if extreme := parse(t.Extreme); (sell && price <= extreme) || (!sell && price >= extreme) {
+		return false
+	}
+
+	t.Extreme = format(price)
+	dist := parse(t.TrailAmount)
+	if t.TrailPercent > 0 {
+		dist = price * t.TrailPercent / 100
+	}
+	if sell {
+		t.StopPrice = format(price - dist)
+	} else {
+		t.StopPrice = format(price + dist)
+	}
+	return true
+}
+
+// Order presents a pending trigger as an order not sent yet, so it can be
+// listed next to the orders resting on the exchange.
+func (t *Trigger) Order() model.Order {
+	return model.Order{
+		ID:           t.ID,
+		MarketSymbol: t.Market,
+		Side:         t.Side,
+		Type:         t.Type,
+		Price:        t.LimitPrice,
+		Quantity:     t.Quantity,
+		StopPrice:    t.StopPrice,
+		State:        model.StateNew,
+	}
+}
+
+// Child returns the order sent to the exchange when t fires: a market order
+// for STOP_MARKET and TRAILING_STOP, a limit order for STOP_LIMIT, and
+// either one for TAKE_PROFIT depending on whether a limit price was given.
+func (t *Trigger) Child() model.Order {
+	o := model.Order{
+		MarketSymbol: t.Market,
+		Side:         t.Side,
+		Type:         model.MarketOrder,
+		Quantity:     t.Quantity,
+	}
+	if t.Type == model.StopLimit || (t.Type == model.TakeProfit && t.LimitPrice != "") {
+		o.Type = model.Limit
+		o.Price = t.LimitPrice
+	}
+	return o
+}
+
+// watched returns the price t watches from the market ticker.
+func (t *Trigger) watched(tk *model.Ticker) float64 {
+	switch t.Source {
+	case Bid:
+		return parse(tk.Bid)
+	case Ask:
+		return parse(tk.Ask)
+	default:
+		return parse(tk.Last)
+	}
+}
+
+// format renders a computed price with at most 8 decimals.
+func format(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e8)/1e8, 'f', -1, 64)
+}
+
+// parse converts an exchange decimal string to float64, treating
+// malformed input as zero.
+func parse(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
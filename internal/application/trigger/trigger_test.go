@@ -0,0 +1,89 @@
+package trigger
+
+import (
+	"testing"
+
+	"trading-bot/internal/domain/model"
+)
+
+func TestFires(t *testing.T) {
+	tests := []struct {
+		name  string
+		trg   Trigger
+		price float64
+		want  bool
+	}{
+		{name: "sell stop above the stop", trg: Trigger{Type: model.StopMarket, Side: model.Sell, StopPrice: "100"}, price: 101},
+		{name: "sell stop at the stop", trg: Trigger{Type: model.StopMarket, Side: model.Sell, StopPrice: "100"}, price: 100, want: true},
+		{name: "sell stop below the stop", trg: Trigger{Type: model.StopLimit, Side: model.Sell, StopPrice: "100"}, price: 99, want: true},
+		{name: "buy stop below the stop", trg: Trigger{Type: model.StopMarket, Side: model.Buy, StopPrice: "100"}, price: 99},
+		{name: "buy stop above the stop", trg: Trigger{Type: model.StopMarket, Side: model.Buy, StopPrice: "100"}, price: 101, want: true},
+		{name: "sell take-profit below the target", trg: Trigger{Type: model.TakeProfit, Side: model.Sell, StopPrice: "100"}, price: 99},
+		{name: "sell take-profit at the target", trg: Trigger{Type: model.TakeProfit, Side: model.Sell, StopPrice: "100"}, price: 100, want: true},
+		{name: "buy take-profit below the target", trg: Trigger{Type: model.TakeProfit, Side: model.Buy, StopPrice: "100"}, price: 99, want: true},
+		{name: "no price", trg: Trigger{Type: model.StopMarket, Side: model.Sell, StopPrice: "100"}, price: 0},
+		{name: "trailing stop not activated", trg: Trigger{Type: model.TrailingStop, Side: model.Sell, StopPrice: "100"}, price: 90},
+		{name: "trailing stop activated", trg: Trigger{Type: model.TrailingStop, Side: model.Sell, StopPrice: "100", Activated: true}, price: 90, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.trg.Fires(tt.price); got != tt.want {
+				t.Errorf("Fires(%v) = %v, want %v", tt.price, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrack(t *testing.T) {
+	sellAmount := Trigger{Type: model.TrailingStop, Side: model.Sell, TrailAmount: "5"}
+	tests := []struct {
+		name        string
+		trg         Trigger
+		prices      []float64
+		wantChanged bool // on the last price
+		wantStop    string
+		wantExtreme string
+		wantActive  bool
+	}{
+		{name: "activates at once", trg: sellAmount, prices: []float64{100}, wantChanged: true, wantStop: "95", wantExtreme: "100", wantActive: true},
+		{name: "follows a rise", trg: sellAmount, prices: []float64{100, 110}, wantChanged: true, wantStop: "105", wantExtreme: "110", wantActive: true},
+		{name: "holds on a fall", trg: sellAmount, prices: []float64{100, 110, 104}, wantStop: "105", wantExtreme: "110", wantActive: true},
+		{
+			name:        "buy follows a fall by a percent",
+			trg:         Trigger{Type: model.TrailingStop, Side: model.Buy, TrailPercent: 10},
+			prices:      []float64{100, 80, 90},
+			wantStop:    "88",
+			wantExtreme: "80",
+			wantActive:  true,
+		},
+		{
+			name:   "waits for the activation price",
+			trg:    Trigger{Type: model.TrailingStop, Side: model.Sell, TrailAmount: "5", ActivationPrice: "120"},
+			prices: []float64{100, 119},
+		},
+		{
+			name:        "activates at the activation price",
+			trg:         Trigger{Type: model.TrailingStop, Side: model.Sell, TrailAmount: "5", ActivationPrice: "120"},
+			prices:      []float64{100, 121},
+			wantChanged: true, wantStop: "116", wantExtreme: "121", wantActive: true,
+		},
+		{name: "ignores other types", trg: Trigger{Type: model.StopMarket, Side: model.Sell, StopPrice: "100"}, prices: []float64{120}, wantStop: "100"},
+		{name: "ignores a missing price", trg: sellAmount, prices: []float64{100, 0}, wantStop: "95", wantExtreme: "100", wantActive: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trg := tt.trg
+			var changed bool
+			for _, p := range tt.prices {
+				changed = trg.Track(p)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if trg.StopPrice != tt.wantStop || trg.Extreme != tt.wantExtreme || trg.Activated != tt.wantActive {
+				t.Errorf("stop %q, extreme %q, activated %v; want %q, %q, %v",
+					trg.StopPrice, trg.Extreme, trg.Activated, tt.wantStop, tt.wantExtreme, tt.wantActive)
+			}
+		})
+	}
+}
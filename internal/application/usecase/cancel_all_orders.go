@@ -0,0 +1,20 @@
+package usecase
+
+import (
+	"context"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// CancelAllOrders cancels every active order of a market, optionally
+// only those on one side.
+type CancelAllOrders struct {
+	Ex service.Exchange
+}
+
+// Execute returns the cancelled orders; an empty market means all markets
+// and an empty side both sides.
+func (u *CancelAllOrders) Execute(ctx context.Context, market string, side model.OrderSide) ([]model.OrderResult, error) {
+	return u.Ex.CancelAllOrders(ctx, market, side)
+}
@@ -1,6 +1,10 @@
 package usecase
 
-import "trading-bot/internal/domain/service"
+import (
+	"context"
+
+	"trading-bot/internal/domain/service"
+)
 
 // CancelOrder instructs the exchange to cancel an existing order by ID.
 type CancelOrder struct {
@@ -8,6 +12,6 @@ type CancelOrder struct {
 }
 
 // Execute cancels the order and returns an error if the operation fails.
-func (u *CancelOrder) Execute(id string) error {
-	return u.Ex.CancelOrder(id)
+func (u *CancelOrder) Execute(ctx context.Context, id string) error {
+	return u.Ex.CancelOrder(ctx, id)
 }
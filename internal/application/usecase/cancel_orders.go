@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// CancelOrders cancels several orders by ID in one go.
+type CancelOrders struct {
+	Ex service.Exchange
+}
+
+// Execute returns one result per ID, in the same order.
+func (u *CancelOrders) Execute(ctx context.Context, ids []string) []model.OrderResult {
+	return u.Ex.CancelOrders(ctx, ids)
+}
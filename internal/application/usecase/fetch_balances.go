@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// FetchBalances retrieves the account balances.
+type FetchBalances struct {
+	Ex service.Exchange
+}
+
+// Execute returns one balance per currency.
+func (u *FetchBalances) Execute(ctx context.Context) ([]model.Balance, error) {
+	return u.Ex.GetBalances(ctx)
+}
@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// FetchCandles retrieves historical OHLCV bars for a market.
+type FetchCandles struct {
+	Ex service.Exchange
+}
+
+// Execute returns the bars of the given interval opened between start and end.
+func (u *FetchCandles) Execute(ctx context.Context, market, interval string, start, end time.Time) ([]model.Candle, error) {
+	return u.Ex.GetCandles(ctx, market, interval, start, end)
+}
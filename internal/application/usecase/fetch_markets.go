@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"context"
+
 	"trading-bot/internal/domain/model"
 	"trading-bot/internal/domain/service"
 )
@@ -11,6 +13,6 @@ type FetchMarkets struct {
 }
 
 // Execute returns a slice of Market models or an error.
-func (u *FetchMarkets) Execute() ([]model.Market, error) {
-	return u.Ex.GetMarkets()
+func (u *FetchMarkets) Execute(ctx context.Context) ([]model.Market, error) {
+	return u.Ex.GetMarkets(ctx)
 }
@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"context"
+
 	"trading-bot/internal/domain/model"
 	"trading-bot/internal/domain/service"
 )
@@ -11,6 +13,6 @@ type FetchOrderBook struct {
 }
 
 // Execute returns the OrderBook for the given market and depth.
-func (u *FetchOrderBook) Execute(market string, depth int) (*model.OrderBook, error) {
-	return u.Ex.GetOrderBook(market, depth)
+func (u *FetchOrderBook) Execute(ctx context.Context, market string, depth int) (*model.OrderBook, error) {
+	return u.Ex.GetOrderBook(ctx, market, depth)
 }
@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// FetchTicker retrieves the latest trade and top-of-book prices of a market.
+type FetchTicker struct {
+	Ex service.Exchange
+}
+
+// Execute returns the Ticker for the given market.
+func (u *FetchTicker) Execute(ctx context.Context, market string) (*model.Ticker, error) {
+	return u.Ex.GetTicker(ctx, market)
+}
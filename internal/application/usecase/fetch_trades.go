@@ -0,0 +1,20 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// FetchTrades retrieves the account's own executions.
+type FetchTrades struct {
+	Ex service.Exchange
+}
+
+// Execute returns the trades in market (all markets when empty) between
+// start and end, oldest first.
+func (u *FetchTrades) Execute(ctx context.Context, market string, start, end time.Time) ([]model.Trade, error) {
+	return u.Ex.GetTrades(ctx, market, start, end)
+}
@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"context"
+
 	"trading-bot/internal/domain/model"
 	"trading-bot/internal/domain/service"
 )
@@ -11,6 +13,6 @@ type GetOrder struct {
 }
 
 // Execute returns the Order or an error if not found.
-func (u *GetOrder) Execute(id string) (*model.Order, error) {
-	return u.Ex.GetOrderByID(id)
+func (u *GetOrder) Execute(ctx context.Context, id string) (*model.Order, error) {
+	return u.Ex.GetOrderByID(ctx, id)
 }
@@ -1,16 +1,34 @@
 package usecase
 
 import (
+	"context"
+
 	"trading-bot/internal/domain/model"
 	"trading-bot/internal/domain/service"
 )
 
+// LocalOrders lists orders held client-side instead of on the exchange,
+// such as pending stop and trailing-stop triggers.
+type LocalOrders interface {
+	ActiveOrders(market string) ([]model.Order, error)
+}
+
 // ListActiveOrders returns all active orders for a market.
+// When Local is set, its orders are listed after the exchange's.
 type ListActiveOrders struct {
-	Ex service.Exchange
+	Ex    service.Exchange
+	Local LocalOrders
 }
 
 // Execute returns a slice of active Orders or an error.
-func (u *ListActiveOrders) Execute(market string) ([]model.Order, error) {
-	return u.Ex.GetActiveOrders(market)
+func (u *ListActiveOrders) Execute(ctx context.Context, market string) ([]model.Order, error) {
+	orders, err := u.Ex.GetActiveOrders(ctx, market)
+	if err != nil || u.Local == nil {
+		return orders, err
+	}
+	local, err := u.Local.ActiveOrders(market)
+	if err != nil {
+		return nil, err
+	}
+	return append(orders, local...), nil
 }
@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"context"
+
 	"trading-bot/internal/domain/model"
 	"trading-bot/internal/domain/service"
 )
@@ -12,6 +14,6 @@ type PlaceOrder struct {
 }
 
 // Execute sends the order request to the exchange and returns the filled Order.
-func (u *PlaceOrder) Execute(req model.Order) (*model.Order, error) {
-	return u.Ex.CreateOrder(req)
+func (u *PlaceOrder) Execute(ctx context.Context, req model.Order) (*model.Order, error) {
+	return u.Ex.CreateOrder(ctx, req)
 }
@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// PlaceOrders sends several orders in one go.
+type PlaceOrders struct {
+	Ex service.Exchange
+}
+
+// Execute returns one result per order, in the same order.
+func (u *PlaceOrders) Execute(ctx context.Context, orders []model.Order) []model.OrderResult {
+	return u.Ex.PlaceOrders(ctx, orders)
+}
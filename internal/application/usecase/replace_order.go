@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// ErrNothingToReplace is returned when the order finished, or executed at
+// least the new quantity, before it could be replaced.
+var ErrNothingToReplace = errors.New("replace: the order has nothing left to replace")
+
+// Replacement is the outcome of ReplaceOrder.
+type Replacement struct {
+	Old *model.Order // final state of the cancelled order
+	New *model.Order
+}
+
+// ReplaceOrder changes the price and/or quantity of an order. Foxbit has no
+// amend endpoint, so the change is emulated: the order is cancelled, the
+// cancel is confirmed, and only then the replacement is sent for the new
+// quantity minus what the old order executed. There is never more than one
+// order working, and a failed cancel places nothing.
+type ReplaceOrder struct {
+	Ex service.Exchange
+	// Wait is how long to wait for the exchange to confirm the cancel
+	// before giving up; 2s when zero.
+	Wait time.Duration
+}
+
+// Execute replaces order id. An empty price or quantity keeps the current
+// one; quantity is the new total size, including what already executed.
+// postOnly applies to LIMIT replacements only: the exchange does not
+// report it back, so it cannot be copied from the old order.
+func (u *ReplaceOrder) Execute(ctx context.Context, id, price, quantity string, postOnly bool) (*Replacement, error) {
+	old, err := u.Ex.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if old.State.Final() {
+		return nil, fmt.Errorf("%w: %s is %s", ErrNothingToReplace, id, old.State)
+	}
+	if price == "" {
+		price = old.Price
+	}
+	if quantity == "" {
+		quantity = old.Quantity
+	}
+
+	if err := u.Ex.CancelOrder(ctx, id); err != nil {
+		return nil, fmt.Errorf("replace: cancel %s: %w", id, err)
+	}
+	if old, err = u.confirmCancel(ctx, id); err != nil {
+		return nil, err
+	}
+
+	executed, _ := strconv.ParseFloat(old.QuantityExecuted, 64)
+	total, _ := strconv.ParseFloat(quantity, 64)
+	left := total - executed
+	prec := decimals(quantity, old.Quantity, old.QuantityExecuted)
+	if left < math.Pow10(-prec)/2 {
+		return &Replacement{Old: old}, fmt.Errorf("%w: %s already executed %s", ErrNothingToReplace, id, old.QuantityExecuted)
+	}
+
+	placed, err := u.Ex.CreateOrder(ctx, model.Order{
+		MarketSymbol: old.MarketSymbol,
+		Side:         old.Side,
+		Type:         old.Type,
+		Price:        price,
+		Quantity:     strconv.FormatFloat(left, 'f', prec, 64),
+		PostOnly:     old.Type == model.Limit && postOnly,
+	})
+	if err != nil {
+		return &Replacement{Old: old}, fmt.Errorf("replace: %s was cancelled but the new order failed: %w", id, err)
+	}
+	return &Replacement{Old: old, New: placed}, nil
+}
+
+// confirmPoll is how often confirmCancel asks for the order.
+const confirmPoll = 200 * time.Millisecond
+
+// confirmCancel polls order id until the exchange reports it finished and
+// returns its final state. It gives up when ctx is cancelled.
+func (u *ReplaceOrder) confirmCancel(ctx context.Context, id string) (*model.Order, error) {
+	wait := u.Wait
+	if wait <= 0 {
+		wait = 2 * time.Second
+	}
+	deadline := time.Now().Add(wait)
+	timer := time.NewTimer(confirmPoll)
+	defer timer.Stop()
+	for {
+		o, err := u.Ex.GetOrderByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("replace: confirm cancel of %s: %w", id, err)
+		}
+		if o.State.Final() {
+			return o, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("replace: %s is still %s after cancelling, nothing was placed", id, o.State)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("replace: confirm cancel of %s, nothing was placed: %w", id, ctx.Err())
+		case <-timer.C:
+			timer.Reset(confirmPoll)
+		}
+	}
+}
+
+// decimals returns the largest number of decimal places among values, so
+// the remaining quantity is formatted like the quantities it came from.
+func decimals(values ...string) int {
+	max := 0
+	for _, v := range values {
+		if i := strings.IndexByte(v, '.'); i >= 0 && len(v)-i-1 > max {
+			max = len(v) - i - 1
+		}
+	}
+	return max
+}
@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+func TestReplaceOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     model.OrderState // of the order when asked to replace it
+		cancelErr error
+		afterCxl  model.Order // what the exchange reports once cancelled
+		quantity  string
+		wantErr   error
+		wantNew   string // quantity of the replacement, empty if none
+	}{
+		{
+			name:     "nothing executed",
+			state:    model.StateActive,
+			afterCxl: model.Order{State: model.StateCancelled, QuantityExecuted: "0"},
+			wantNew:  "1.000",
+		},
+		{
+			name:     "partial fill leaves a remainder",
+			state:    model.StatePartiallyFilled,
+			afterCxl: model.Order{State: model.StateCancelled, QuantityExecuted: "0.3"},
+			wantNew:  "0.700",
+		},
+		{
+			name:     "remainder of a larger size",
+			state:    model.StatePartiallyFilled,
+			afterCxl: model.Order{State: model.StateCancelled, QuantityExecuted: "0.3"},
+			quantity: "2",
+			wantNew:  "1.700",
+		},
+		{
+			name:     "cancel lost to a fill",
+			state:    model.StateActive,
+			afterCxl: model.Order{State: model.StateFilled, QuantityExecuted: "1.000"},
+			wantErr:  ErrNothingToReplace,
+		},
+		{
+			name:     "smaller than what executed",
+			state:    model.StatePartiallyFilled,
+			afterCxl: model.Order{State: model.StateCancelled, QuantityExecuted: "0.6"},
+			quantity: "0.5",
+			wantErr:  ErrNothingToReplace,
+		},
+		{name: "cancel refused", state: model.StateActive, cancelErr: errors.New("order is being matched")},
+		{name: "already finished", state: model.StateFilled, wantErr: ErrNothingToReplace},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := &fakeExchange{
+				order:     model.Order{ID: "1", MarketSymbol: "BTCBRL", Side: model.Buy, Type: model.Limit, Price: "100", Quantity: "1.000", State: tt.state},
+				cancelErr: tt.cancelErr,
+				afterCxl:  tt.afterCxl,
+			}
+			u := &ReplaceOrder{Ex: ex, Wait: time.Second}
+
+			r, err := u.Execute(context.Background(), "1", "101", tt.quantity, true)
+			switch {
+			case tt.cancelErr != nil && !errors.Is(err, tt.cancelErr):
+				t.Fatalf("err = %v, want the cancel error", err)
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			case tt.cancelErr == nil && tt.wantErr == nil && err != nil:
+				t.Fatal(err)
+			}
+			if tt.wantNew == "" {
+				if len(ex.placed) > 0 {
+					t.Errorf("placed %+v, want nothing", ex.placed)
+				}
+				return
+			}
+			want := model.Order{MarketSymbol: "BTCBRL", Side: model.Buy, Type: model.Limit, Price: "101", Quantity: tt.wantNew, PostOnly: true}
+			if len(ex.placed) != 1 || ex.placed[0] != want {
+				t.Errorf("placed %+v, want %+v", ex.placed, want)
+			}
+			if r.Old.State != tt.afterCxl.State || r.New == nil {
+				t.Errorf("replacement %+v", r)
+			}
+		})
+	}
+}
+
+func TestReplaceOrderConfirmTimeout(t *testing.T) {
+	// the exchange accepts the cancel but keeps reporting the order active
+	ex := &fakeExchange{
+		order:    model.Order{ID: "1", MarketSymbol: "BTCBRL", Side: model.Sell, Type: model.Limit, Price: "100", Quantity: "1", State: model.StateActive},
+		afterCxl: model.Order{State: model.StateActive},
+	}
+	u := &ReplaceOrder{Ex: ex, Wait: time.Millisecond}
+
+	if _, err := u.Execute(context.Background(), "1", "99", "", false); err == nil {
+		t.Fatal("no error while the order is still active")
+	}
+	if len(ex.placed) > 0 {
+		t.Errorf("placed %+v, want nothing", ex.placed)
+	}
+}
+
+func TestReplaceOrderStopsWaitingWhenCancelled(t *testing.T) {
+	ex := &fakeExchange{
+		order:    model.Order{ID: "1", MarketSymbol: "BTCBRL", Side: model.Sell, Type: model.Limit, Price: "100", Quantity: "1", State: model.StateActive},
+		afterCxl: model.Order{State: model.StateActive},
+	}
+	u := &ReplaceOrder{Ex: ex, Wait: time.Minute}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := u.Execute(ctx, "1", "99", "", false)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the context's", err)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("waited %v after the context was done", waited)
+	}
+	if len(ex.placed) > 0 {
+		t.Errorf("placed %+v, want nothing", ex.placed)
+	}
+}
+
+// fakeExchange holds a single order, which takes the state and execution
+// of afterCxl once cancelled.
+type fakeExchange struct {
+	service.Exchange
+	order     model.Order
+	cancelErr error
+	afterCxl  model.Order
+	placed    []model.Order
+}
+
+func (f *fakeExchange) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
+	if id != f.order.ID {
+		return nil, service.ErrOrderNotFound
+	}
+	o := f.order
+	return &o, nil
+}
+
+func (f *fakeExchange) CancelOrder(ctx context.Context, id string) error {
+	if f.cancelErr != nil {
+		return f.cancelErr
+	}
+	f.order.State, f.order.QuantityExecuted = f.afterCxl.State, f.afterCxl.QuantityExecuted
+	return nil
+}
+
+func (f *fakeExchange) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
+	f.placed = append(f.placed, o)
+	o.ID = "2"
+	o.State = model.StateActive
+	return &o, nil
+}
@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// WatchOrderBook polls the order book of a market and passes it on each
+// time it changes.
+type WatchOrderBook struct {
+	Ex       service.Exchange
+	Interval time.Duration // between polls; zero means one second
+}
+
+// Execute sends the current book, then every change, until ctx is done or
+// send or the exchange fails.
+func (u *WatchOrderBook) Execute(ctx context.Context, market string, depth int, send func(*model.OrderBook) error) error {
+	tick := time.NewTicker(interval(u.Interval))
+	defer tick.Stop()
+	var last *model.OrderBook
+	for {
+		ob, err := u.Ex.GetOrderBook(ctx, market, depth)
+		if err != nil {
+			return err
+		}
+		if last == nil || !reflect.DeepEqual(ob, last) {
+			if err := send(ob); err != nil {
+				return err
+			}
+			last = ob
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick.C:
+		}
+	}
+}
+
+func interval(d time.Duration) time.Duration {
+	if d <= 0 {
+		return time.Second
+	}
+	return d
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// WatchOrders polls the active orders of a market and passes on every
+// order that appears or changes, and its final state once it leaves the
+// active list.
+type WatchOrders struct {
+	Ex       service.Exchange
+	Interval time.Duration // between polls; zero means one second
+}
+
+// Execute sends the active orders, then every update, until ctx is done or
+// send or the exchange fails.
+func (u *WatchOrders) Execute(ctx context.Context, market string, send func(model.Order) error) error {
+	tick := time.NewTicker(interval(u.Interval))
+	defer tick.Stop()
+	prev := map[string]model.Order{}
+	for {
+		active, err := u.Ex.GetActiveOrders(ctx, market)
+		if err != nil {
+			return err
+		}
+		cur := make(map[string]model.Order, len(active))
+		for _, o := range active {
+			cur[o.ID] = o
+			p, ok := prev[o.ID]
+			if ok && p.State == o.State && p.QuantityExecuted == o.QuantityExecuted {
+				continue
+			}
+			if err := send(o); err != nil {
+				return err
+			}
+		}
+		for id := range prev {
+			if _, ok := cur[id]; ok {
+				continue
+			}
+			o, err := u.Ex.GetOrderByID(ctx, id)
+			if errors.Is(err, service.ErrOrderNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := send(*o); err != nil {
+				return err
+			}
+		}
+		prev = cur
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick.C:
+		}
+	}
+}
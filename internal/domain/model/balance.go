@@ -0,0 +1,9 @@
+package model
+
+// Balance is the amount of one currency held in the account.
+type Balance struct {
+	Currency  string `json:"currency_symbol"`
+	Total     string `json:"balance"`
+	Available string `json:"balance_available"`
+	Locked    string `json:"balance_locked"` // reserved by open orders
+}
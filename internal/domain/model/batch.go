@@ -0,0 +1,9 @@
+package model
+
+// OrderResult is the outcome of one item of a batch operation. Order is
+// the order as sent (for cancels, only its ID), with the ID assigned by
+// the exchange once placed.
+type OrderResult struct {
+	Order Order
+	Err   error
+}
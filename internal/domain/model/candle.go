@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Candle is one OHLCV bar of a market's trade history.
+// Prices and volumes are strings to preserve exchange-specific formats.
+type Candle struct {
+	OpenTime    time.Time `json:"open_time"`
+	CloseTime   time.Time `json:"close_time"`
+	Open        string    `json:"open"`
+	High        string    `json:"high"`
+	Low         string    `json:"low"`
+	Close       string    `json:"close"`
+	Volume      string    `json:"volume"`       // traded base quantity
+	QuoteVolume string    `json:"quote_volume"` // traded quote amount
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// JournalKind is the type of event recorded in the order journal.
+type JournalKind string
+
+const (
+	JournalRequest  JournalKind = "REQUEST"  // order about to be sent
+	JournalAccepted JournalKind = "ACCEPTED" // exchange assigned an ID
+	JournalRejected JournalKind = "REJECTED" // exchange refused the order
+	JournalCancel   JournalKind = "CANCEL"   // cancel requested (Error set if it failed)
+	JournalState    JournalKind = "STATE"    // order state changed
+	JournalFill     JournalKind = "FILL"     // order executed more quantity
+	JournalAdopted  JournalKind = "ADOPTED"  // order found on the exchange that was never journaled
+)
+
+// JournalEntry is one event of the local order journal.
+type JournalEntry struct {
+	Seq     uint64      `json:"seq"`
+	Time    time.Time   `json:"time"`
+	Kind    JournalKind `json:"kind"`
+	OrderID string      `json:"order_id,omitempty"`
+	Market  string      `json:"market,omitempty"`
+	Order   *Order      `json:"order,omitempty"`  // snapshot of the order at that moment
+	Filled  string      `json:"filled,omitempty"` // FILL: quantity executed since the previous snapshot
+	Error   string      `json:"error,omitempty"`
+}
+
+// JournalFilter selects journal entries; zero fields match everything.
+type JournalFilter struct {
+	OrderID string
+	Market  string
+	Kinds   []JournalKind
+	Since   time.Time
+	Until   time.Time
+	Limit   int // newest entries only, 0 = all
+}
@@ -1,5 +1,10 @@
 package model
 
+import (
+	"math"
+	"strconv"
+)
+
 // Market represents a trading pair and its precision/increment rules.
 type Market struct {
 	Symbol            string `json:"symbol"`
@@ -9,4 +14,50 @@ type Market struct {
 	QuantityMin       string `json:"quantity_min"`
 	QuantityIncrement string `json:"quantity_increment"`
 	QuantityPrecision int    `json:"quantity_precision"`
+	Base              Asset  `json:"base"`
+	Quote             Asset  `json:"quote"`
+}
+
+// Asset identifies one side of a trading pair, e.g. "btc" in BTCBRL.
+type Asset struct {
+	Symbol string `json:"symbol"`
+}
+
+// AlignPrice rounds p to the nearest PriceIncrement and formats it with
+// PricePrecision decimals, ready to be sent as an order price.
+func (m Market) AlignPrice(p float64) string {
+	return m.alignPrice(p, math.Round)
+}
+
+// AlignPriceDown is AlignPrice rounding down, so a bid placed under a
+// price never ends up above it.
+func (m Market) AlignPriceDown(p float64) string {
+	// the small epsilon absorbs float noise such as 0.3/0.1 = 2.9999…
+	return m.alignPrice(p, func(x float64) float64 { return math.Floor(x + 1e-9) })
+}
+
+// AlignPriceUp is AlignPrice rounding up, so an ask placed over a price
+// never ends up below it.
+func (m Market) AlignPriceUp(p float64) string {
+	return m.alignPrice(p, func(x float64) float64 { return math.Ceil(x - 1e-9) })
+}
+
+func (m Market) alignPrice(p float64, round func(float64) float64) string {
+	inc, _ := strconv.ParseFloat(m.PriceIncrement, 64)
+	if inc > 0 {
+		p = round(p/inc) * inc
+	}
+	return strconv.FormatFloat(p, 'f', m.PricePrecision, 64)
+}
+
+// AlignQuantity truncates q down to a multiple of QuantityIncrement and
+// formats it with QuantityPrecision decimals. Truncating (instead of
+// rounding) guarantees we never send more than the caller asked for.
+func (m Market) AlignQuantity(q float64) string {
+	inc, _ := strconv.ParseFloat(m.QuantityIncrement, 64)
+	if inc > 0 {
+		// the small epsilon absorbs float noise such as 0.3/0.1 = 2.9999…
+		q = math.Floor(q/inc+1e-9) * inc
+	}
+	return strconv.FormatFloat(q, 'f', m.QuantityPrecision, 64)
 }
@@ -0,0 +1,27 @@
+package model
+
+import "testing"
+
+func TestMarketAlign(t *testing.T) {
+	m := Market{PriceIncrement: "0.5", PricePrecision: 1, QuantityIncrement: "0.1", QuantityPrecision: 1}
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"price nearest down", m.AlignPrice(100.2), "100.0"},
+		{"price nearest up", m.AlignPrice(100.3), "100.5"},
+		{"price down", m.AlignPriceDown(100.45), "100.0"},
+		{"price down on the increment", m.AlignPriceDown(100.5), "100.5"},
+		{"price up", m.AlignPriceUp(100.05), "100.5"},
+		{"price up on the increment", m.AlignPriceUp(100.5), "100.5"},
+		{"quantity truncated", m.AlignQuantity(0.39), "0.3"},
+		{"quantity float noise", m.AlignQuantity(0.3), "0.3"},
+		{"no increment", Market{PricePrecision: 2}.AlignPrice(1.234), "1.23"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
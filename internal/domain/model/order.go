@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // OrderSide indicates whether the order is a buy or sell.
 type OrderSide string
 
@@ -12,16 +14,34 @@ const (
 
 	Limit       OrderType = "LIMIT"
 	MarketOrder OrderType = "MARKET"
+
+	// Conditional types are held locally by the trigger engine and
+	// become a MARKET or LIMIT order once their stop price is reached.
+	StopMarket   OrderType = "STOP_MARKET"
+	StopLimit    OrderType = "STOP_LIMIT"
+	TakeProfit   OrderType = "TAKE_PROFIT"
+	TrailingStop OrderType = "TRAILING_STOP"
 )
 
 // Order is the domain entity for a trading order.
 // Price and Quantity are strings to preserve exchange-specific formats.
+// PostOnly limit orders are rejected instead of taking liquidity;
+// State, QuantityExecuted, QuantityRemaining and PriceAvg (the average
+// execution price) are reported back by the exchange.
+// StopPrice is only set on conditional orders held client-side.
+// CreatedAt is when the exchange accepted the order, zero if unknown.
 type Order struct {
-	ID           string    `json:"id,omitempty"`
-	MarketSymbol string    `json:"market_symbol"`
-	Side         OrderSide `json:"side"`
-	Type         OrderType `json:"type"`
-	Price        string    `json:"price,omitempty"`
-	Quantity     string    `json:"quantity,omitempty"`
-	State        string    `json:"state,omitempty"`
+	ID                string     `json:"id,omitempty"`
+	MarketSymbol      string     `json:"market_symbol"`
+	Side              OrderSide  `json:"side"`
+	Type              OrderType  `json:"type"`
+	Price             string     `json:"price,omitempty"`
+	Quantity          string     `json:"quantity,omitempty"`
+	PostOnly          bool       `json:"post_only"`
+	StopPrice         string     `json:"stop_price,omitempty"`
+	State             OrderState `json:"state,omitempty"`
+	QuantityExecuted  string     `json:"quantity_executed,omitempty"`
+	QuantityRemaining string     `json:"quantity_remaining,omitempty"`
+	PriceAvg          string     `json:"price_avg,omitempty"`
+	CreatedAt         time.Time  `json:"created_at,omitempty"`
 }
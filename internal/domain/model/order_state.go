@@ -0,0 +1,100 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OrderState is the lifecycle state of an order. Adapters map each
+// exchange's raw states onto these.
+type OrderState string
+
+const (
+	StateNew             OrderState = "NEW"            // built locally, not sent yet
+	StatePendingSubmit   OrderState = "PENDING_SUBMIT" // sent, no answer yet
+	StateActive          OrderState = "ACTIVE"         // resting on the book, nothing executed
+	StatePartiallyFilled OrderState = "PARTIALLY_FILLED"
+	StateFilled          OrderState = "FILLED"
+	StatePendingCancel   OrderState = "PENDING_CANCEL" // cancel requested, not confirmed
+	StateCancelled       OrderState = "CANCELLED"      // possibly after partial executions
+	StateRejected        OrderState = "REJECTED"
+	StateExpired         OrderState = "EXPIRED"
+)
+
+// transitions lists where each non-final state may go next. Staying in
+// the same state is always allowed, as partial fills do.
+var transitions = map[OrderState][]OrderState{
+	// an order seen for the first time may already be in any state
+	StateNew: {StatePendingSubmit, StateActive, StatePartiallyFilled, StateFilled,
+		StatePendingCancel, StateCancelled, StateRejected, StateExpired},
+	StatePendingSubmit: {StateActive, StatePartiallyFilled, StateFilled,
+		StatePendingCancel, StateCancelled, StateRejected, StateExpired},
+	StateActive:          {StatePartiallyFilled, StateFilled, StatePendingCancel, StateCancelled, StateExpired},
+	StatePartiallyFilled: {StateFilled, StatePendingCancel, StateCancelled, StateExpired},
+	// the cancel may be refused, or lose the race against a fill
+	StatePendingCancel: {StateActive, StatePartiallyFilled, StateFilled, StateCancelled, StateExpired},
+}
+
+// Final reports whether the order can no longer execute.
+func (s OrderState) Final() bool {
+	switch s {
+	case StateFilled, StateCancelled, StateRejected, StateExpired:
+		return true
+	}
+	return false
+}
+
+// Working reports whether the order is on the exchange and may still
+// execute.
+func (s OrderState) Working() bool {
+	switch s {
+	case StateActive, StatePartiallyFilled, StatePendingCancel:
+		return true
+	}
+	return false
+}
+
+// ErrInvalidTransition is wrapped by the errors of CheckTransition.
+var ErrInvalidTransition = errors.New("invalid order state transition")
+
+// CheckTransition returns an error wrapping ErrInvalidTransition unless
+// an order may go from state from to state to. The empty state is
+// treated as NEW.
+func CheckTransition(from, to OrderState) error {
+	if from == "" {
+		from = StateNew
+	}
+	if to == "" {
+		to = StateNew
+	}
+	if from == to {
+		return nil
+	}
+	for _, s := range transitions[from] {
+		if s == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, from, to)
+}
+
+// OrderEvent records that an order changed state.
+type OrderEvent struct {
+	OrderID string
+	Market  string
+	From    OrderState
+	To      OrderState
+	Time    time.Time
+}
+
+// Transition moves o to state to and returns the resulting event. The
+// order is left unchanged when the transition is not allowed.
+func (o *Order) Transition(to OrderState) (OrderEvent, error) {
+	ev := OrderEvent{OrderID: o.ID, Market: o.MarketSymbol, From: o.State, To: to, Time: time.Now()}
+	if err := CheckTransition(o.State, to); err != nil {
+		return ev, fmt.Errorf("order %s: %w", o.ID, err)
+	}
+	o.State = to
+	return ev, nil
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		from, to OrderState
+		ok       bool
+	}{
+		{"", StateFilled, true}, // seen for the first time
+		{StateNew, StatePendingSubmit, true},
+		{StatePendingSubmit, StateFilled, true}, // market order
+		{StatePendingSubmit, StateRejected, true},
+		{StateActive, StatePartiallyFilled, true},
+		{StateActive, StatePendingCancel, true},
+		{StatePartiallyFilled, StatePartiallyFilled, true},
+		{StatePartiallyFilled, StateCancelled, true},
+		{StatePendingCancel, StateCancelled, true},
+		{StatePendingCancel, StateFilled, true}, // the fill won the race
+		{StatePendingCancel, StateActive, true}, // the cancel was refused
+		{StateActive, StateRejected, false},
+		{StatePartiallyFilled, StateActive, false},
+		{StateFilled, StateCancelled, false},
+		{StateCancelled, StateActive, false},
+		{StateRejected, StateActive, false},
+		{StateExpired, StateFilled, false},
+		{StateFilled, StateFilled, true},
+		{StateActive, "", false},
+	}
+	for _, tt := range tests {
+		err := CheckTransition(tt.from, tt.to)
+		if tt.ok && err != nil {
+			t.Errorf("%q to %q: %v", tt.from, tt.to, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("%q to %q: err = %v, want ErrInvalidTransition", tt.from, tt.to, err)
+		}
+	}
+}
+
+func TestOrderTransition(t *testing.T) {
+	o := Order{ID: "1", State: StateFilled}
+	if _, err := o.Transition(StateCancelled); err == nil || o.State != StateFilled {
+		t.Errorf("state %s, err %v: want FILLED kept and an error", o.State, err)
+	}
+	ev, err := (&Order{ID: "2", State: StateActive}).Transition(StatePendingCancel)
+	if err != nil || ev.From != StateActive || ev.To != StatePendingCancel {
+		t.Errorf("event %+v, err %v", ev, err)
+	}
+}
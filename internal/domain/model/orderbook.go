@@ -1,7 +1,42 @@
 package model
 
+import "strconv"
+
 // OrderBook holds the top-of-book bids and asks as price/quantity pairs.
 type OrderBook struct {
 	Bids [][]string `json:"bids"`
 	Asks [][]string `json:"asks"`
 }
+
+// BestBid returns the highest bid price, or 0 when there are no bids.
+func (ob *OrderBook) BestBid() float64 {
+	return topPrice(ob.Bids)
+}
+
+// BestAsk returns the lowest ask price, or 0 when there are no asks.
+func (ob *OrderBook) BestAsk() float64 {
+	return topPrice(ob.Asks)
+}
+
+// Mid returns the midpoint between the best bid and ask. When one side of
+// the book is empty the price of the other side is returned instead.
+func (ob *OrderBook) Mid() float64 {
+	bid, ask := ob.BestBid(), ob.BestAsk()
+	switch {
+	case bid == 0:
+		return ask
+	case ask == 0:
+		return bid
+	default:
+		return (bid + ask) / 2
+	}
+}
+
+// topPrice parses the price of the first level of one side of the book.
+func topPrice(levels [][]string) float64 {
+	if len(levels) == 0 || len(levels[0]) == 0 {
+		return 0
+	}
+	p, _ := strconv.ParseFloat(levels[0][0], 64)
+	return p
+}
@@ -0,0 +1,9 @@
+package model
+
+// Ticker is the latest trade and top-of-book prices of a market.
+type Ticker struct {
+	MarketSymbol string `json:"market_symbol"`
+	Last         string `json:"last"`
+	Bid          string `json:"bid"`
+	Ask          string `json:"ask"`
+}
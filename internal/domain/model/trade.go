@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Trade is one execution of one of our orders.
+type Trade struct {
+	ID           string    `json:"id"`
+	OrderID      string    `json:"order_id"`
+	MarketSymbol string    `json:"market_symbol"`
+	Side         OrderSide `json:"side"`
+	Price        string    `json:"price"`
+	Quantity     string    `json:"quantity"`
+	Fee          string    `json:"fee"`
+	FeeCurrency  string    `json:"fee_currency_symbol"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -1,17 +1,49 @@
 package service
 
-import "trading-bot/internal/domain/model"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"trading-bot/internal/domain/model"
+)
+
+// ErrOrderNotFound is returned, possibly wrapped, by GetOrderByID when the
+// exchange does not know the order.
+var ErrOrderNotFound = errors.New("order not found")
 
 // Exchange defines the port that any trading exchange adapter must implement.
 // This is the “driven” interface in Hexagonal/DDD architecture.
+//
+// ctx is the context of the command or API request the call is made for:
+// requests to the exchange are cancelled with it and logged with its
+// request ID.
 type Exchange interface {
 	// Market data
-	GetMarkets() ([]model.Market, error)
-	GetOrderBook(market string, depth int) (*model.OrderBook, error)
+	GetMarkets(ctx context.Context) ([]model.Market, error)
+	GetOrderBook(ctx context.Context, market string, depth int) (*model.OrderBook, error)
+	// GetCandles returns the bars of the given interval ("1m", "1h", "1d"…)
+	// opened between start and end, oldest first.
+	GetCandles(ctx context.Context, market, interval string, start, end time.Time) ([]model.Candle, error)
+	GetTicker(ctx context.Context, market string) (*model.Ticker, error)
 
 	// Order management
-	CreateOrder(o model.Order) (*model.Order, error)
-	GetActiveOrders(market string) ([]model.Order, error)
-	GetOrderByID(id string) (*model.Order, error)
-	CancelOrder(id string) error
+	CreateOrder(ctx context.Context, o model.Order) (*model.Order, error)
+	GetActiveOrders(ctx context.Context, market string) ([]model.Order, error)
+	GetOrderByID(ctx context.Context, id string) (*model.Order, error)
+	CancelOrder(ctx context.Context, id string) error
+
+	// Batch order management. Results are returned in input order and a
+	// failed item does not stop the others.
+	PlaceOrders(ctx context.Context, orders []model.Order) []model.OrderResult
+	CancelOrders(ctx context.Context, ids []string) []model.OrderResult
+	// CancelAllOrders cancels every active order of market (all markets
+	// when empty), only on side when it is not empty.
+	CancelAllOrders(ctx context.Context, market string, side model.OrderSide) ([]model.OrderResult, error)
+
+	// Account
+	GetBalances(ctx context.Context) ([]model.Balance, error)
+	// GetTrades returns our own executions in market (all markets when
+	// empty) between start and end, oldest first.
+	GetTrades(ctx context.Context, market string, start, end time.Time) ([]model.Trade, error)
 }
@@ -0,0 +1,19 @@
+package service
+
+import "trading-bot/internal/domain/model"
+
+// Journal is the port for the local, append-only history of everything
+// that happened to our orders.
+type Journal interface {
+	// Append records entries in order, assigning their sequence numbers.
+	Append(entries ...model.JournalEntry) error
+	// Query returns the matching entries, oldest first.
+	Query(f model.JournalFilter) ([]model.JournalEntry, error)
+	// Last returns the latest snapshot of an order, or nil if it was
+	// never journaled.
+	Last(orderID string) (*model.Order, error)
+	// Open returns the journaled orders of market (all markets when empty)
+	// whose latest snapshot is not final, including the ones whose cancel
+	// was accepted but not confirmed yet (PENDING_CANCEL).
+	Open(market string) ([]model.Order, error)
+}
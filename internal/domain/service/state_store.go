@@ -0,0 +1,19 @@
+package service
+
+// StateStore defines the port used by long-running strategies to persist
+// their progress, so a restarted bot resumes where it stopped instead of
+// placing its orders a second time.
+type StateStore interface {
+	// Load decodes the snapshot saved under key into dest.
+	// It reports false (and no error) when nothing was saved yet.
+	Load(key string, dest interface{}) (bool, error)
+
+	// Save replaces the snapshot stored under key with v.
+	Save(key string, v interface{}) error
+
+	// Delete removes the snapshot stored under key, if any.
+	Delete(key string) error
+
+	// Keys lists the saved keys starting with prefix, sorted.
+	Keys(prefix string) ([]string, error)
+}
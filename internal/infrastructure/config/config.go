@@ -0,0 +1,158 @@
+// Package config reads the CLI configuration file, which holds named
+// profiles, one per exchange account.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileEnv selects the profile when the -profile flag is not given.
+const ProfileEnv = "TRADING_BOT_PROFILE"
+
+// Config is the content of the configuration file.
+type Config struct {
+	DefaultProfile string              `yaml:"default_profile"`
+	Profiles       map[string]*Profile `yaml:"profiles"`
+}
+
+// Profile is one exchange account and the defaults used with it.
+//
+// Credentials may be written in the file or, preferably, read from the
+// environment variables named by APIKeyEnv and APISecretEnv, which take
+// precedence over the file when set.
+type Profile struct {
+	Name         string `yaml:"-"`
+	Exchange     string `yaml:"exchange"`
+	APIKey       string `yaml:"api_key"`
+	APISecret    string `yaml:"api_secret"`
+	APIKeyEnv    string `yaml:"api_key_env"`
+	APISecretEnv string `yaml:"api_secret_env"`
+	Market       string `yaml:"market"`     // default -market of the commands that take one
+	Output       string `yaml:"output"`     // default -output
+	LogLevel     string `yaml:"log_level"`  // default -log-level
+	LogFormat    string `yaml:"log_format"` // default -log-format
+}
+
+// Load reads the configuration at path. It returns nil, nil when the
+// file does not exist, since the file is optional.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: failed to read %s: %w", path, err)
+	}
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("config: failed to parse %s: %w", path, err)
+	}
+	for name, p := range c.Profiles {
+		if p == nil {
+			p = &Profile{}
+			c.Profiles[name] = p
+		}
+		p.Name = name
+	}
+	return &c, nil
+}
+
+// Names returns the profile names, sorted.
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for n := range c.Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Select returns the profile called name, or when name is empty the one
+// named by $TRADING_BOT_PROFILE, or else the default profile. It returns
+// nil when no profile is selected at all.
+func (c *Config) Select(name string) (*Profile, error) {
+	if name == "" {
+		name = os.Getenv(ProfileEnv)
+	}
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		return nil, nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("config: unknown profile %q", name)
+	}
+	return p, nil
+}
+
+// Validate checks the settings of the file that do not depend on the
+// exchange adapters, and that the default profile exists.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.DefaultProfile != "" && c.Profiles[c.DefaultProfile] == nil {
+		errs = append(errs, fmt.Errorf("config: default profile %q is not defined", c.DefaultProfile))
+	}
+	for _, n := range c.Names() {
+		if err := c.Profiles[n].Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks that p names an exchange and that its credentials are
+// either complete or absent, in which case they must come from elsewhere.
+func (p *Profile) Validate() error {
+	if p.Exchange == "" {
+		return fmt.Errorf("config: profile %q: exchange is required", p.Name)
+	}
+	if key, secret := p.Credentials(); (key == "") != (secret == "") {
+		return fmt.Errorf("config: profile %q: incomplete credentials (%s)", p.Name, p.CredentialSource())
+	}
+	return nil
+}
+
+// Credentials returns the API key and secret of p, the environment taking
+// precedence over the file.
+func (p *Profile) Credentials() (key, secret string) {
+	return lookup(p.APIKeyEnv, p.APIKey), lookup(p.APISecretEnv, p.APISecret)
+}
+
+// CredentialSource describes where the credentials of p come from, without
+// revealing them.
+func (p *Profile) CredentialSource() string {
+	var parts []string
+	for _, c := range []struct{ env, value, what string }{
+		{p.APIKeyEnv, p.APIKey, "key"},
+		{p.APISecretEnv, p.APISecret, "secret"},
+	} {
+		switch {
+		case c.env != "" && os.Getenv(c.env) != "":
+			parts = append(parts, c.what+" from $"+c.env)
+		case c.value != "":
+			parts = append(parts, c.what+" from file")
+		case c.env != "":
+			parts = append(parts, c.what+": $"+c.env+" is not set")
+		default:
+			parts = append(parts, c.what+" not set")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func lookup(env, fallback string) string {
+	if env != "" {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+	}
+	return fallback
+}
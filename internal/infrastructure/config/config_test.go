@@ -0,0 +1,183 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := `default_profile: main
+profiles:
+  main:
+    exchange: foxbit
+    market: btcbrl
+  empty:
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DefaultProfile != "main" || c.Profiles["main"].Market != "btcbrl" || c.Profiles["main"].Name != "main" {
+		t.Errorf("loaded %+v", c)
+	}
+	// a profile written without settings is usable, not nil
+	if p := c.Profiles["empty"]; p == nil || p.Name != "empty" {
+		t.Errorf("empty profile %+v", p)
+	}
+	if got := strings.Join(c.Names(), ","); got != "empty,main" {
+		t.Errorf("names %s, want empty,main", got)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), "none.yaml"))
+	if c != nil || err != nil {
+		t.Errorf("Load = %+v, %v; want nil, nil", c, err)
+	}
+}
+
+func TestLoadMalformedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("profiles: [\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("err = %v, want a parse error naming the file", err)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	c := &Config{
+		DefaultProfile: "main",
+		Profiles:       map[string]*Profile{"main": {Name: "main"}, "env": {Name: "env"}, "flag": {Name: "flag"}},
+	}
+	tests := []struct {
+		name    string
+		flag    string
+		env     string
+		dflt    string
+		want    string // empty when nothing is selected
+		wantErr bool
+	}{
+		{name: "flag first", flag: "flag", env: "env", dflt: "main", want: "flag"},
+		{name: "then the environment", env: "env", dflt: "main", want: "env"},
+		{name: "then the default", dflt: "main", want: "main"},
+		{name: "nothing", want: ""},
+		{name: "unknown flag", flag: "nope", env: "env", wantErr: true},
+		{name: "unknown in the environment", env: "nope", dflt: "main", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ProfileEnv, tt.env)
+			c.DefaultProfile = tt.dflt
+			p, err := c.Select(tt.flag)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("selected %+v, want an error", p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if p != nil {
+				got = p.Name
+			}
+			if got != tt.want {
+				t.Errorf("selected %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	tests := []struct {
+		name       string
+		profile    Profile
+		env        map[string]string
+		wantKey    string
+		wantSecret string
+		wantErr    string
+	}{
+		{name: "from the file", profile: Profile{APIKey: "k", APISecret: "s"}, wantKey: "k", wantSecret: "s"},
+		{
+			name:    "environment over the file",
+			profile: Profile{APIKey: "k", APISecret: "s", APIKeyEnv: "TEST_KEY", APISecretEnv: "TEST_SECRET"},
+			env:     map[string]string{"TEST_KEY": "ek", "TEST_SECRET": "es"},
+			wantKey: "ek", wantSecret: "es",
+		},
+		{
+			name:    "unset variable falls back to the file",
+			profile: Profile{APIKey: "k", APISecret: "s", APIKeyEnv: "TEST_KEY"},
+			wantKey: "k", wantSecret: "s",
+		},
+		{name: "none", profile: Profile{}},
+		{
+			name:    "key without a secret",
+			profile: Profile{APIKey: "k"},
+			wantKey: "k",
+			wantErr: "incomplete credentials (key from file, secret not set)",
+		},
+		{
+			name:       "secret variable not set",
+			profile:    Profile{APIKeyEnv: "TEST_KEY", APISecretEnv: "TEST_SECRET"},
+			env:        map[string]string{"TEST_SECRET": "es"},
+			wantSecret: "es",
+			wantErr:    "incomplete credentials (key: $TEST_KEY is not set, secret from $TEST_SECRET)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_KEY", "")
+			t.Setenv("TEST_SECRET", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			p := tt.profile
+			p.Name, p.Exchange = "main", "foxbit"
+
+			key, secret := p.Credentials()
+			if key != tt.wantKey || secret != tt.wantSecret {
+				t.Errorf("credentials %q, %q; want %q, %q", key, secret, tt.wantKey, tt.wantSecret)
+			}
+			err := p.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := &Config{
+		DefaultProfile: "missing",
+		Profiles: map[string]*Profile{
+			"ok":         {Name: "ok", Exchange: "foxbit"},
+			"noexchange": {Name: "noexchange"},
+		},
+	}
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("no error")
+	}
+	for _, want := range []string{`default profile "missing" is not defined`, `profile "noexchange": exchange is required`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), `"ok"`) {
+		t.Errorf("err = %v, blames the valid profile", err)
+	}
+}
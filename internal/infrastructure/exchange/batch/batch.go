@@ -0,0 +1,80 @@
+// Package batch implements the batch operations of service.Exchange on top
+// of the single-order calls, for exchanges without bulk endpoints.
+package batch
+
+import (
+	"context"
+	"sync"
+
+	"trading-bot/internal/domain/model"
+)
+
+// Workers is how many requests are in flight at once. It stays small to
+// keep clear of the exchanges' rate limits.
+const Workers = 4
+
+// Place sends every order with create, Workers at a time.
+func Place(ctx context.Context, create func(context.Context, model.Order) (*model.Order, error),
+	orders []model.Order) []model.OrderResult {
+	results := make([]model.OrderResult, len(orders))
+	run(len(orders), func(i int) {
+		results[i].Order = orders[i]
+		o, err := create(ctx, orders[i])
+		if err != nil {
+			results[i].Err = err
+			return
+		}
+		results[i].Order = *o
+	})
+	return results
+}
+
+// Cancel cancels every order ID with cancel, Workers at a time.
+func Cancel(ctx context.Context, cancel func(context.Context, string) error, ids []string) []model.OrderResult {
+	results := make([]model.OrderResult, len(ids))
+	run(len(ids), func(i int) {
+		results[i] = model.OrderResult{Order: model.Order{ID: ids[i]}, Err: cancel(ctx, ids[i])}
+	})
+	return results
+}
+
+// CancelAll cancels the active orders of market on side (any side when
+// empty) found with list.
+func CancelAll(ctx context.Context, list func(context.Context, string) ([]model.Order, error),
+	cancel func(context.Context, string) error, market string, side model.OrderSide) ([]model.OrderResult, error) {
+	active, err := list(ctx, market)
+	if err != nil {
+		return nil, err
+	}
+	var matching []model.Order
+	for _, o := range active {
+		if side == "" || o.Side == side {
+			matching = append(matching, o)
+		}
+	}
+	results := make([]model.OrderResult, len(matching))
+	run(len(matching), func(i int) {
+		results[i] = model.OrderResult{Order: matching[i], Err: cancel(ctx, matching[i].ID)}
+	})
+	return results, nil
+}
+
+// run calls fn for 0..n-1 on at most Workers goroutines.
+func run(n int, fn func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < Workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
@@ -0,0 +1,143 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"trading-bot/internal/domain/model"
+)
+
+func TestPlaceKeepsInputOrder(t *testing.T) {
+	orders := make([]model.Order, 10)
+	for i := range orders {
+		orders[i] = model.Order{MarketSymbol: "BTCBRL", Side: model.Buy, Price: fmt.Sprint(100 + i), Quantity: fmt.Sprint(i)}
+	}
+	var c concurrency
+	create := func(ctx context.Context, o model.Order) (*model.Order, error) {
+		defer c.enter()()
+		// the first orders answer last
+		i, _ := strconv.Atoi(o.Quantity)
+		time.Sleep(time.Duration(len(orders)-i) * time.Millisecond)
+		if o.Price == "103" || o.Price == "107" {
+			return nil, errors.New("insufficient balance")
+		}
+		o.ID = "id-" + o.Price
+		return &o, nil
+	}
+
+	results := Place(context.Background(), create, orders)
+	if len(results) != len(orders) {
+		t.Fatalf("%d results for %d orders", len(results), len(orders))
+	}
+	for i, r := range results {
+		failed := i == 3 || i == 7
+		if r.Order.Price != orders[i].Price || (r.Err != nil) != failed {
+			t.Errorf("result %d = %+v (%v), want order at %s failed %v", i, r.Order, r.Err, orders[i].Price, failed)
+		}
+		if !failed && r.Order.ID != "id-"+orders[i].Price {
+			t.Errorf("result %d has ID %q", i, r.Order.ID)
+		}
+	}
+	if c.max > Workers {
+		t.Errorf("%d requests at once, want at most %d", c.max, Workers)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ids := []string{"1", "2", "3", "4", "5", "6"}
+	var c concurrency
+	cancel := func(ctx context.Context, id string) error {
+		defer c.enter()()
+		time.Sleep(time.Duration(len(ids)-int(id[0]-'0')) * time.Millisecond)
+		if id == "2" {
+			return errors.New("not found")
+		}
+		return nil
+	}
+
+	results := Cancel(context.Background(), cancel, ids)
+	for i, r := range results {
+		if r.Order.ID != ids[i] || (r.Err != nil) != (ids[i] == "2") {
+			t.Errorf("result %d = %+v (%v)", i, r.Order, r.Err)
+		}
+	}
+	if c.max > Workers {
+		t.Errorf("%d requests at once, want at most %d", c.max, Workers)
+	}
+}
+
+func TestCancelAll(t *testing.T) {
+	active := []model.Order{
+		{ID: "1", Side: model.Buy},
+		{ID: "2", Side: model.Sell},
+		{ID: "3", Side: model.Buy},
+	}
+	tests := []struct {
+		name    string
+		side    model.OrderSide
+		listErr error
+		want    []string
+	}{
+		{name: "both sides", want: []string{"1", "2", "3"}},
+		{name: "buys", side: model.Buy, want: []string{"1", "3"}},
+		{name: "sells", side: model.Sell, want: []string{"2"}},
+		{name: "listing fails", listErr: errors.New("unavailable")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var cancelled []string
+			list := func(ctx context.Context, market string) ([]model.Order, error) {
+				return active, tt.listErr
+			}
+			cancel := func(ctx context.Context, id string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				cancelled = append(cancelled, id)
+				return nil
+			}
+
+			results, err := CancelAll(context.Background(), list, cancel, "BTCBRL", tt.side)
+			if tt.listErr != nil {
+				if !errors.Is(err, tt.listErr) || len(cancelled) > 0 {
+					t.Errorf("err = %v after cancelling %v, want the listing error and nothing cancelled", err, cancelled)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != len(tt.want) || len(cancelled) != len(tt.want) {
+				t.Fatalf("results %+v, cancelled %v; want %v", results, cancelled, tt.want)
+			}
+			for i, r := range results {
+				if r.Order.ID != tt.want[i] || r.Err != nil {
+					t.Errorf("result %d = %+v (%v), want %s", i, r.Order, r.Err, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// concurrency records the most calls in flight at once.
+type concurrency struct {
+	mu       sync.Mutex
+	now, max int
+}
+
+func (c *concurrency) enter() (leave func()) {
+	c.mu.Lock()
+	c.now++
+	c.max = max(c.max, c.now)
+	c.mu.Unlock()
+	return func() {
+		c.mu.Lock()
+		c.now--
+		c.mu.Unlock()
+	}
+}
@@ -1,15 +1,25 @@
 package foxbit
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"time"
 	"trading-bot/internal/domain/model"
 	"trading-bot/internal/domain/service"
+	"trading-bot/internal/infrastructure/exchange/batch"
 	"trading-bot/internal/infrastructure/httputil"
 )
 
+// getRetries is how many times a read is sent again after a network
+// error, a 5xx, or a 429 that says when to retry (see httputil.DoRequest).
+// Writes are never retried: a timed out order may have been placed.
+const getRetries = 2
+
 // FoxbitAdapter implements service.Exchange using Foxbit REST v3.
 type FoxbitAdapter struct {
 	apiKey     string
@@ -29,11 +39,11 @@ func New(apiKey, secret string) service.Exchange {
 }
 
 // GetMarkets implements Exchange.GetMarkets.
-func (f *FoxbitAdapter) GetMarkets() ([]model.Market, error) {
+func (f *FoxbitAdapter) GetMarkets(ctx context.Context) ([]model.Market, error) {
 	var reply struct {
 		Data []model.Market `json:"data"`
 	}
-	err := httputil.DoRequest(f.httpClient, httputil.RequestParams{
+	err := httputil.DoRequest(ctx, f.httpClient, httputil.RequestParams{
 		Method:     http.MethodGet,
 		BaseURL:    f.baseURL,
 		Path:       "/rest/v3/markets",
@@ -42,6 +52,7 @@ func (f *FoxbitAdapter) GetMarkets() ([]model.Market, error) {
 		APIKey:     f.apiKey,
 		Secret:     f.secret,
 		ResultDest: &reply,
+		Retries:    getRetries,
 	})
 	if err != nil {
 		return nil, err
@@ -50,19 +61,21 @@ func (f *FoxbitAdapter) GetMarkets() ([]model.Market, error) {
 }
 
 // GetOrderBook implements Exchange.GetOrderBook.
-func (f *FoxbitAdapter) GetOrderBook(market string, depth int) (*model.OrderBook, error) {
+func (f *FoxbitAdapter) GetOrderBook(ctx context.Context, market string, depth int) (*model.OrderBook, error) {
 	params := map[string]string{"depth": strconv.Itoa(depth)}
 	var ob model.OrderBook
 	path := "/rest/v3/markets/" + url.PathEscape(market) + "/orderbook"
-	err := httputil.DoRequest(f.httpClient, httputil.RequestParams{
+	err := httputil.DoRequest(ctx, f.httpClient, httputil.RequestParams{
 		Method:     http.MethodGet,
 		BaseURL:    f.baseURL,
 		Path:       path,
+		Endpoint:   "/rest/v3/markets/{market}/orderbook",
 		Query:      params,
 		Body:       nil,
 		APIKey:     f.apiKey,
 		Secret:     f.secret,
 		ResultDest: &ob,
+		Retries:    getRetries,
 	})
 	if err != nil {
 		return nil, err
@@ -70,8 +83,97 @@ func (f *FoxbitAdapter) GetOrderBook(market string, depth int) (*model.OrderBook
 	return &ob, nil
 }
 
+// GetCandles implements Exchange.GetCandles.
+// Foxbit answers with one array per bar:
+// [open_time, open, high, low, close, close_time, base_volume, quote_volume, …].
+func (f *FoxbitAdapter) GetCandles(ctx context.Context, market, interval string, start, end time.Time) ([]model.Candle, error) {
+	params := map[string]string{
+		"interval":   interval,
+		"start_time": start.UTC().Format(time.RFC3339),
+		"end_time":   end.UTC().Format(time.RFC3339),
+		"limit":      "500",
+	}
+	var rows [][]interface{}
+	path := "/rest/v3/markets/" + url.PathEscape(market) + "/candlesticks"
+	err := httputil.DoRequest(ctx, f.httpClient, httputil.RequestParams{
+		Method:     http.MethodGet,
+		BaseURL:    f.baseURL,
+		Path:       path,
+		Endpoint:   "/rest/v3/markets/{market}/candlesticks",
+		Query:      params,
+		Body:       nil,
+		APIKey:     f.apiKey,
+		Secret:     f.secret,
+		ResultDest: &rows,
+		Retries:    getRetries,
+	})
+	if err != nil {
+		return nil, err
+	}
+	candles := make([]model.Candle, 0, len(rows))
+	for _, r := range rows {
+		if len(r) < 8 {
+			continue
+		}
+		candles = append(candles, model.Candle{
+			OpenTime:    millis(r[0]),
+			Open:        fmt.Sprint(r[1]),
+			High:        fmt.Sprint(r[2]),
+			Low:         fmt.Sprint(r[3]),
+			Close:       fmt.Sprint(r[4]),
+			CloseTime:   millis(r[5]),
+			Volume:      fmt.Sprint(r[6]),
+			QuoteVolume: fmt.Sprint(r[7]),
+		})
+	}
+	return candles, nil
+}
+
+// GetTicker implements Exchange.GetTicker.
+func (f *FoxbitAdapter) GetTicker(ctx context.Context, market string) (*model.Ticker, error) {
+	type level struct {
+		Price string `json:"price"`
+	}
+	var reply struct {
+		Data []struct {
+			MarketSymbol string `json:"market_symbol"`
+			LastTrade    level  `json:"last_trade"`
+			Best         struct {
+				Ask level `json:"ask"`
+				Bid level `json:"bid"`
+			} `json:"best"`
+		} `json:"data"`
+	}
+	path := "/rest/v3/markets/" + url.PathEscape(market) + "/ticker/24hr"
+	err := httputil.DoRequest(ctx, f.httpClient, httputil.RequestParams{
+		Method:     http.MethodGet,
+		BaseURL:    f.baseURL,
+		Path:       path,
+		Endpoint:   "/rest/v3/markets/{market}/ticker/24hr",
+		Query:      nil,
+		Body:       nil,
+		APIKey:     f.apiKey,
+		Secret:     f.secret,
+		ResultDest: &reply,
+		Retries:    getRetries,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(reply.Data) == 0 {
+		return nil, fmt.Errorf("foxbit: no ticker for %s", market)
+	}
+	d := reply.Data[0]
+	return &model.Ticker{
+		MarketSymbol: d.MarketSymbol,
+		Last:         d.LastTrade.Price,
+		Bid:          d.Best.Bid.Price,
+		Ask:          d.Best.Ask.Price,
+	}, nil
+}
+
 // CreateOrder implements Exchange.CreateOrder.
-func (f *FoxbitAdapter) CreateOrder(o model.Order) (*model.Order, error) {
+func (f *FoxbitAdapter) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
 	// parse price/quantity
 
 	payload := map[string]interface{}{
@@ -79,14 +181,17 @@ func (f *FoxbitAdapter) CreateOrder(o model.Order) (*model.Order, error) {
 		"type":          o.Type,
 		"market_symbol": o.MarketSymbol,
 		"quantity":      o.Quantity,
-		"price":         o.Price,
-		"post_only":     true,
-		"time_in_force": "GTC",
+	}
+	// market orders take whatever price the book offers
+	if o.Type != model.MarketOrder {
+		payload["price"] = o.Price
+		payload["post_only"] = o.PostOnly
+		payload["time_in_force"] = "GTC"
 	}
 	var resp struct {
 		ID string `json:"id"`
 	}
-	err := httputil.DoRequest(f.httpClient, httputil.RequestParams{
+	err := httputil.DoRequest(ctx, f.httpClient, httputil.RequestParams{
 		Method:     http.MethodPost,
 		BaseURL:    f.baseURL,
 		Path:       "/rest/v3/orders",
@@ -99,62 +204,115 @@ func (f *FoxbitAdapter) CreateOrder(o model.Order) (*model.Order, error) {
 	if err != nil {
 		return nil, err
 	}
+	// only the ID comes back: a limit order rests until seen otherwise,
+	// a market order executes at once, but how is only known once fetched
 	o.ID = resp.ID
+	o.State = model.StateActive
+	if o.Type == model.MarketOrder {
+		o.State = model.StatePendingSubmit
+	}
 	return &o, nil
 }
 
-// GetActiveOrders implements Exchange.GetActiveOrders.
-func (f *FoxbitAdapter) GetActiveOrders(market string) ([]model.Order, error) {
-	params := map[string]string{"state": "ACTIVE"}
+// workingStates are the raw states of the orders still resting on the
+// book: Foxbit reports an order that executed in part as PARTIALLY_FILLED,
+// not ACTIVE, and lists each state separately.
+var workingStates = []string{"ACTIVE", "PARTIALLY_FILLED"}
+
+// GetActiveOrders implements Exchange.GetActiveOrders, listing the orders
+// in every working state.
+func (f *FoxbitAdapter) GetActiveOrders(ctx context.Context, market string) ([]model.Order, error) {
+	var orders []model.Order
+	seen := make(map[string]bool)
+	for _, state := range workingStates {
+		list, err := f.listOrders(ctx, state, market)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range list {
+			// an order filling between the two listings shows up in both
+			if !seen[o.ID] {
+				seen[o.ID] = true
+				orders = append(orders, o)
+			}
+		}
+	}
+	return orders, nil
+}
+
+// listOrders returns the orders in state, following the pages of
+// /rest/v3/orders until a short one.
+func (f *FoxbitAdapter) listOrders(ctx context.Context, state, market string) ([]model.Order, error) {
+	const pageSize = 100
+	params := map[string]string{"state": state, "page_size": strconv.Itoa(pageSize)}
 	if market != "" {
 		params["market_symbol"] = market
 	}
-	var reply struct {
-		Data []model.Order `json:"data"`
-	}
-	err := httputil.DoRequest(f.httpClient, httputil.RequestParams{
-		Method:     http.MethodGet,
-		BaseURL:    f.baseURL,
-		Path:       "/rest/v3/orders",
-		Query:      params,
-		Body:       nil,
-		APIKey:     f.apiKey,
-		Secret:     f.secret,
-		ResultDest: &reply,
-	})
-	if err != nil {
-		return nil, err
+	var orders []model.Order
+	for page := 1; ; page++ {
+		params["page"] = strconv.Itoa(page)
+		var reply struct {
+			Data []model.Order `json:"data"`
+		}
+		err := httputil.DoRequest(ctx, f.httpClient, httputil.RequestParams{
+			Method:     http.MethodGet,
+			BaseURL:    f.baseURL,
+			Path:       "/rest/v3/orders",
+			Query:      params,
+			Body:       nil,
+			APIKey:     f.apiKey,
+			Secret:     f.secret,
+			ResultDest: &reply,
+			Retries:    getRetries,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for i := range reply.Data {
+			normalize(&reply.Data[i])
+		}
+		orders = append(orders, reply.Data...)
+		if len(reply.Data) < pageSize {
+			break
+		}
 	}
-	return reply.Data, nil
+	return orders, nil
 }
 
 // GetOrderByID implements Exchange.GetOrderByID.
-func (f *FoxbitAdapter) GetOrderByID(id string) (*model.Order, error) {
+func (f *FoxbitAdapter) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
 	var o model.Order
 	path := "/rest/v3/orders/by-order-id/" + url.PathEscape(id)
-	err := httputil.DoRequest(f.httpClient, httputil.RequestParams{
+	err := httputil.DoRequest(ctx, f.httpClient, httputil.RequestParams{
 		Method:     http.MethodGet,
 		BaseURL:    f.baseURL,
 		Path:       path,
+		Endpoint:   "/rest/v3/orders/by-order-id/{id}",
 		Query:      nil,
 		Body:       nil,
 		APIKey:     f.apiKey,
 		Secret:     f.secret,
 		ResultDest: &o,
+		Retries:    getRetries,
 	})
+	var se *httputil.StatusError
+	if errors.As(err, &se) && se.Code == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s: %w", service.ErrOrderNotFound, id, err)
+	}
 	if err != nil {
 		return nil, err
 	}
+	normalize(&o)
 	return &o, nil
 }
 
 // CancelOrder implements Exchange.CancelOrder.
-func (f *FoxbitAdapter) CancelOrder(id string) error {
+func (f *FoxbitAdapter) CancelOrder(ctx context.Context, id string) error {
 	payload := map[string]interface{}{
 		"type": "ID",
 		"id":   id,
 	}
-	return httputil.DoRequest(f.httpClient, httputil.RequestParams{
+	return httputil.DoRequest(ctx, f.httpClient, httputil.RequestParams{
 		Method:     http.MethodPut,
 		BaseURL:    f.baseURL,
 		Path:       "/rest/v3/orders/cancel",
@@ -165,3 +323,130 @@ func (f *FoxbitAdapter) CancelOrder(id string) error {
 		ResultDest: nil,
 	})
 }
+
+// PlaceOrders implements Exchange.PlaceOrders. Foxbit has no batch
+// create endpoint, so the orders are sent concurrently.
+func (f *FoxbitAdapter) PlaceOrders(ctx context.Context, orders []model.Order) []model.OrderResult {
+	return batch.Place(ctx, f.CreateOrder, orders)
+}
+
+// CancelOrders implements Exchange.CancelOrders.
+func (f *FoxbitAdapter) CancelOrders(ctx context.Context, ids []string) []model.OrderResult {
+	return batch.Cancel(ctx, f.CancelOrder, ids)
+}
+
+// CancelAllOrders implements Exchange.CancelAllOrders with the bulk cancel
+// endpoint. It cannot filter by side, so cancelling one side falls back to
+// cancelling the listed orders one by one.
+func (f *FoxbitAdapter) CancelAllOrders(ctx context.Context, market string, side model.OrderSide) ([]model.OrderResult, error) {
+	if side != "" {
+		return batch.CancelAll(ctx, f.GetActiveOrders, f.CancelOrder, market, side)
+	}
+	payload := map[string]interface{}{"type": "ALL"}
+	if market != "" {
+		payload["type"] = "MARKET"
+		payload["market_symbol"] = market
+	}
+	var reply struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err := httputil.DoRequest(ctx, f.httpClient, httputil.RequestParams{
+		Method:     http.MethodPut,
+		BaseURL:    f.baseURL,
+		Path:       "/rest/v3/orders/cancel",
+		Query:      nil,
+		Body:       payload,
+		APIKey:     f.apiKey,
+		Secret:     f.secret,
+		ResultDest: &reply,
+	})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]model.OrderResult, len(reply.Data))
+	for i, d := range reply.Data {
+		results[i].Order = model.Order{ID: d.ID, MarketSymbol: market}
+	}
+	return results, nil
+}
+
+// GetBalances implements Exchange.GetBalances.
+func (f *FoxbitAdapter) GetBalances(ctx context.Context) ([]model.Balance, error) {
+	var reply struct {
+		Data []model.Balance `json:"data"`
+	}
+	err := httputil.DoRequest(ctx, f.httpClient, httputil.RequestParams{
+		Method:     http.MethodGet,
+		BaseURL:    f.baseURL,
+		Path:       "/rest/v3/accounts",
+		Query:      nil,
+		Body:       nil,
+		APIKey:     f.apiKey,
+		Secret:     f.secret,
+		ResultDest: &reply,
+		Retries:    getRetries,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Data, nil
+}
+
+// GetTrades implements Exchange.GetTrades, following the pages of
+// /rest/v3/trades until a short one.
+func (f *FoxbitAdapter) GetTrades(ctx context.Context, market string, start, end time.Time) ([]model.Trade, error) {
+	const pageSize = 100
+	params := map[string]string{
+		"start_time": start.UTC().Format(time.RFC3339),
+		"end_time":   end.UTC().Format(time.RFC3339),
+		"page_size":  strconv.Itoa(pageSize),
+	}
+	if market != "" {
+		params["market_symbol"] = market
+	}
+	var trades []model.Trade
+	for page := 1; ; page++ {
+		params["page"] = strconv.Itoa(page)
+		var reply struct {
+			Data []model.Trade `json:"data"`
+		}
+		err := httputil.DoRequest(ctx, f.httpClient, httputil.RequestParams{
+			Method:     http.MethodGet,
+			BaseURL:    f.baseURL,
+			Path:       "/rest/v3/trades",
+			Query:      params,
+			Body:       nil,
+			APIKey:     f.apiKey,
+			Secret:     f.secret,
+			ResultDest: &reply,
+			Retries:    getRetries,
+		})
+		if err != nil {
+			return nil, err
+		}
+		trades = append(trades, reply.Data...)
+		if len(reply.Data) < pageSize {
+			break
+		}
+	}
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].CreatedAt.Before(trades[j].CreatedAt)
+	})
+	return trades, nil
+}
+
+// millis converts a Unix millisecond timestamp, sent either as a JSON
+// string or number, to time.Time.
+func millis(v interface{}) time.Time {
+	switch t := v.(type) {
+	case float64:
+		return time.UnixMilli(int64(t))
+	case string:
+		ms, _ := strconv.ParseInt(t, 10, 64)
+		return time.UnixMilli(ms)
+	default:
+		return time.Time{}
+	}
+}
@@ -0,0 +1,34 @@
+package foxbit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"trading-bot/internal/domain/model"
+)
+
+func TestGetActiveOrdersListsPartiallyFilledOrders(t *testing.T) {
+	byState := map[string][]model.Order{
+		"ACTIVE":           {{ID: "1", State: "ACTIVE", Quantity: "1"}},
+		"PARTIALLY_FILLED": {{ID: "2", State: "PARTIALLY_FILLED", Quantity: "1", QuantityExecuted: "0.4"}, {ID: "1", State: "ACTIVE"}},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"data": byState[r.URL.Query().Get("state")]})
+	}))
+	defer srv.Close()
+	f := &FoxbitAdapter{baseURL: srv.URL, httpClient: srv.Client()}
+
+	orders, err := f.GetActiveOrders(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 2 {
+		t.Fatalf("orders %+v, want 1 and 2 once each", orders)
+	}
+	if o := orders[1]; o.ID != "2" || o.State != model.StatePartiallyFilled || o.QuantityRemaining != "0.6" {
+		t.Errorf("order %+v, want 2 partially filled with 0.6 left", o)
+	}
+}
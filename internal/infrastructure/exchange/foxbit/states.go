@@ -0,0 +1,47 @@
+package foxbit
+
+import (
+	"strconv"
+	"strings"
+
+	"trading-bot/internal/domain/model"
+)
+
+// states maps Foxbit's raw order states onto the domain lifecycle.
+var states = map[string]model.OrderState{
+	"ACTIVE":             model.StateActive,
+	"PARTIALLY_FILLED":   model.StatePartiallyFilled,
+	"FILLED":             model.StateFilled,
+	"PENDING_CANCEL":     model.StatePendingCancel,
+	"CANCELED":           model.StateCancelled,
+	"PARTIALLY_CANCELED": model.StateCancelled, // cancelled after some executions
+	"REJECTED":           model.StateRejected,
+	"EXPIRED":            model.StateExpired,
+}
+
+// normalize converts an order decoded from Foxbit to the domain model:
+// it maps the raw state and derives the remaining quantity. Unknown
+// states are kept as they are.
+func normalize(o *model.Order) {
+	if s, ok := states[strings.ToUpper(string(o.State))]; ok {
+		o.State = s
+	}
+	if o.Quantity == "" {
+		return
+	}
+	qty, _ := strconv.ParseFloat(o.Quantity, 64)
+	executed, _ := strconv.ParseFloat(o.QuantityExecuted, 64)
+	remaining := qty - executed
+	if remaining < 0 {
+		remaining = 0
+	}
+	o.QuantityRemaining = strconv.FormatFloat(remaining, 'f', max(decimals(o.Quantity), decimals(o.QuantityExecuted)), 64)
+}
+
+// decimals returns the number of digits after the decimal point of s.
+func decimals(s string) int {
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
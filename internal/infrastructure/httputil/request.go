@@ -2,16 +2,32 @@ package httputil
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"sort"
+	"strconv"
 	"time"
+
+	"trading-bot/internal/infrastructure/logging"
+	"trading-bot/internal/infrastructure/metrics"
+)
+
+var (
+	requestDuration = metrics.Histogram("http_request_duration_seconds",
+		"Latency of the requests to the exchange, per attempt.", nil, "method", "endpoint")
+	requests = metrics.Counter("http_requests_total",
+		"Requests to the exchange by status code, \"error\" when no response came back.", "method", "endpoint", "code")
+	retries = metrics.Counter("http_retries_total",
+		"Requests to the exchange sent again after a failed attempt.", "method", "endpoint")
 )
 
 // RequestParams holds all inputs needed to build, sign and send an HTTP request.
@@ -19,15 +35,37 @@ type RequestParams struct {
 	Method     string            // "GET", "POST", etc.
 	BaseURL    string            // e.g. "https://api.foxbit.com.br"
 	Path       string            // e.g. "/rest/v3/orders"
+	Endpoint   string            // Path with its IDs as placeholders, for metrics; Path when empty
 	Query      map[string]string // URL query parameters
 	Body       interface{}       // will be JSON-marshaled if non-nil
 	APIKey     string            // exchange API key
 	Secret     string            // exchange secret for HMAC
 	ResultDest interface{}       // pointer to struct for JSON unmarshal
+	Retries    int               // times a GET is sent again after a transient failure, see backoff
+}
+
+// maxRetryAfter is the longest DoRequest waits before sending a read
+// again. A server asking for longer gets the error back at once.
+const maxRetryAfter = 5 * time.Second
+
+// StatusError is returned by DoRequest when the server answers with an
+// HTTP error status.
+type StatusError struct {
+	Code       int
+	Body       string
+	RetryAfter time.Duration // from the Retry-After header, zero when absent
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("httputil: status %d: %s", e.Code, e.Body)
 }
 
 // DoRequest builds, signs, sends the HTTP request and optionally decodes JSON into ResultDest.
-func DoRequest(client *http.Client, p RequestParams) error {
+// Every attempt is logged with the request ID of ctx, a new one when ctx
+// carries none, so the exchange requests of a command or API call can be
+// told apart and traced back to it. Reads are cancelled with ctx; writes
+// are not, since the exchange may act on one we stopped waiting for.
+func DoRequest(ctx context.Context, client *http.Client, p RequestParams) error {
 	// 1) build query string in alphabetical order (for deterministic signing)
 	var queryString string
 	if len(p.Query) > 0 {
@@ -53,60 +91,187 @@ func DoRequest(client *http.Client, p RequestParams) error {
 		}
 	}
 
-	// 3) compute timestamp and HMAC signature
+	// 3) send, retrying idempotent requests on transient failures
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = p.Path
+	}
+	if logging.RequestID(ctx) == "" {
+		ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+	}
+	if p.Method != http.MethodGet {
+		ctx = context.WithoutCancel(ctx)
+	}
+	var data []byte
+	var err error
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		var status int
+		status, data, err = send(ctx, client, p, queryString, bodyBytes)
+		elapsed := time.Since(start)
+		requestDuration.WithLabelValues(p.Method, endpoint).Observe(elapsed.Seconds())
+		requests.WithLabelValues(p.Method, endpoint, code(status)).Inc()
+		level := slog.LevelDebug
+		if transient(err) {
+			level = slog.LevelWarn
+		}
+		attrs := []any{"method", p.Method, "path", p.Path, "status", status,
+			"duration", elapsed, "attempt", attempt + 1}
+		if err != nil {
+			attrs = append(attrs, "err", err)
+		}
+		slog.Log(ctx, level, "httputil: request", attrs...)
+		if attempt >= p.Retries || p.Method != http.MethodGet {
+			break
+		}
+		wait, ok := backoff(err, attempt)
+		if !ok {
+			break
+		}
+		retries.WithLabelValues(p.Method, endpoint).Inc()
+		if !sleep(ctx, wait) {
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// 4) unmarshal if destination provided
+	if p.ResultDest != nil {
+		if err := json.Unmarshal(data, p.ResultDest); err != nil {
+			return fmt.Errorf("httputil: failed to unmarshal response: %w", err)
+		}
+	}
+	return nil
+}
+
+// send signs and sends one attempt of the request and returns the status
+// code, 0 when no response came back, and the body of a successful one.
+func send(ctx context.Context, client *http.Client, p RequestParams, queryString string, bodyBytes []byte) (int, []byte, error) {
+	// compute timestamp and HMAC signature
 	timestamp := fmt.Sprintf("%d", time.Now().UnixMilli())
 	preHash := timestamp + p.Method + p.Path + queryString + string(bodyBytes)
 	mac := hmac.New(sha256.New, []byte(p.Secret))
 	mac.Write([]byte(preHash))
 	signature := hex.EncodeToString(mac.Sum(nil))
 
-	// 4) build full URL
+	// build full URL
 	fullURL := p.BaseURL + p.Path
 	if queryString != "" {
 		fullURL += "?" + queryString
 	}
 
-	// 5) create HTTP request
+	// create HTTP request
 	var req *http.Request
 	var err error
 	if p.Body != nil {
-		req, err = http.NewRequest(p.Method, fullURL, bytes.NewReader(bodyBytes))
+		req, err = http.NewRequestWithContext(ctx, p.Method, fullURL, bytes.NewReader(bodyBytes))
 	} else {
-		req, err = http.NewRequest(p.Method, fullURL, nil)
+		req, err = http.NewRequestWithContext(ctx, p.Method, fullURL, nil)
 	}
 	if err != nil {
-		return fmt.Errorf("httputil: failed to create request: %w", err)
+		return 0, nil, fmt.Errorf("httputil: failed to create request: %w", err)
 	}
 
-	// 6) set headers
+	// set headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-FB-ACCESS-KEY", p.APIKey)
 	req.Header.Set("X-FB-ACCESS-TIMESTAMP", timestamp)
 	req.Header.Set("X-FB-ACCESS-SIGNATURE", signature)
 
-	// 7) send
+	// send
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("httputil: request failed: %w", err)
+		return 0, nil, fmt.Errorf("httputil: request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// 8) read body
+	// read body
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("httputil: failed to read response: %w", err)
+		return resp.StatusCode, nil, fmt.Errorf("httputil: failed to read response: %w", err)
 	}
 
-	// 9) handle HTTP errors
+	// handle HTTP errors
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("httputil: status %d: %s", resp.StatusCode, string(data))
+		return resp.StatusCode, nil, &StatusError{Code: resp.StatusCode, Body: string(data),
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After"))}
 	}
+	return resp.StatusCode, data, nil
+}
 
-	// 10) unmarshal if destination provided
-	if p.ResultDest != nil {
-		if err := json.Unmarshal(data, p.ResultDest); err != nil {
-			return fmt.Errorf("httputil: failed to unmarshal response: %w", err)
-		}
+// code is the status code label of an attempt.
+func code(status int) string {
+	if status == 0 {
+		return "error"
 	}
-	return nil
+	return strconv.Itoa(status)
+}
+
+// sleep waits for d, and reports false when ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as an HTTP
+// date.
+func retryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// backoff returns how long to wait before sending again a read whose
+// attempt failed with err, and false when it must not be sent again.
+//
+// Network errors and 5xx are retried with an exponential backoff, or
+// after the Retry-After of the server. A 429 is only retried when the
+// server says when: sending again blindly only prolongs the rate limit.
+// Nothing is retried when the server asks to wait over maxRetryAfter.
+func backoff(err error, attempt int) (time.Duration, bool) {
+	if !transient(err) {
+		return 0, false
+	}
+	wait := time.Duration(200<<attempt) * time.Millisecond
+	var se *StatusError
+	if !errors.As(err, &se) {
+		return wait, true
+	}
+	switch {
+	case se.RetryAfter > maxRetryAfter:
+		return 0, false
+	case se.RetryAfter > 0:
+		return se.RetryAfter, true
+	case se.Code == http.StatusTooManyRequests:
+		return 0, false
+	}
+	return wait, true
+}
+
+// transient reports whether an attempt that failed with err may succeed
+// when sent again.
+func transient(err error) bool {
+	if err == nil {
+		return false
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Code == http.StatusTooManyRequests || se.Code >= 500
+	}
+	return true
 }
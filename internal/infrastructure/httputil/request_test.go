@@ -0,0 +1,98 @@
+package httputil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"trading-bot/internal/infrastructure/logging"
+)
+
+func TestDoRequestRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		method     string
+		attempts   int
+	}{
+		{name: "429 without Retry-After", status: http.StatusTooManyRequests, method: http.MethodGet, attempts: 1},
+		{name: "429 with Retry-After", status: http.StatusTooManyRequests, retryAfter: "1", method: http.MethodGet, attempts: 2},
+		{name: "429 asking to wait too long", status: http.StatusTooManyRequests, retryAfter: "60", method: http.MethodGet, attempts: 1},
+		{name: "503 asking to wait too long", status: http.StatusServiceUnavailable, retryAfter: "60", method: http.MethodGet, attempts: 1},
+		{name: "500", status: http.StatusInternalServerError, method: http.MethodGet, attempts: 2},
+		{name: "write", status: http.StatusInternalServerError, method: http.MethodPost, attempts: 1},
+		{name: "400", status: http.StatusBadRequest, method: http.MethodGet, attempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if attempts > 1 {
+					w.Write([]byte(`{}`))
+					return
+				}
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				http.Error(w, "try later", tt.status)
+			}))
+			defer srv.Close()
+
+			err := DoRequest(context.Background(), srv.Client(), RequestParams{Method: tt.method, BaseURL: srv.URL, Path: "/x", Retries: 1})
+			if attempts != tt.attempts {
+				t.Errorf("%d attempts, want %d", attempts, tt.attempts)
+			}
+			var se *StatusError
+			if failed := errors.As(err, &se); failed != (tt.attempts == 1) {
+				t.Errorf("err = %v", err)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if got := retryAfter("3"); got != 3*time.Second {
+		t.Errorf("seconds: %v", got)
+	}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := retryAfter(date); got < 58*time.Second || got > time.Minute {
+		t.Errorf("date: %v", got)
+	}
+	for _, v := range []string{"", "soon", "-1"} {
+		if got := retryAfter(v); got != 0 {
+			t.Errorf("retryAfter(%q) = %v, want 0", v, got)
+		}
+	}
+}
+
+func TestDoRequestContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	if err := logging.Setup(&logs, logging.Options{Level: "debug"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(logging.WithRequestID(context.Background(), "req-1"))
+	cancel()
+
+	if err := DoRequest(ctx, srv.Client(), RequestParams{Method: http.MethodGet, BaseURL: srv.URL, Path: "/x"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("read with a cancelled context: %v", err)
+	}
+	if err := DoRequest(ctx, srv.Client(), RequestParams{Method: http.MethodPost, BaseURL: srv.URL, Path: "/x", Body: struct{}{}}); err != nil {
+		t.Errorf("write abandoned with its context: %v", err)
+	}
+	if n := strings.Count(logs.String(), "request_id=req-1"); n != 2 {
+		t.Errorf("%d requests logged with the request ID of the context, want 2:\n%s", n, &logs)
+	}
+}
@@ -0,0 +1,98 @@
+package keystore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrNoAgent is returned by FromAgent when no agent is listening.
+var ErrNoAgent = errors.New("keystore: no agent running")
+
+type agentRequest struct {
+	Name string `json:"name"`
+}
+
+type agentReply struct {
+	Credentials *Credentials `json:"credentials,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// Serve keeps the entries of k in memory and hands them out on the unix
+// socket at path until ctx is cancelled, so other processes of the same
+// user need not ask for the passphrase. The socket is only accessible to
+// its owner.
+func Serve(ctx context.Context, path string, k *Keystore) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("keystore: %w", err)
+	}
+	if _, err := FromAgent(path, ""); !errors.Is(err, ErrNoAgent) {
+		return fmt.Errorf("keystore: an agent is already listening on %s", path)
+	}
+	os.Remove(path) // left behind by an agent that crashed
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("keystore: %w", err)
+	}
+	defer l.Close()
+	if err := os.Chmod(path, 0o600); err != nil {
+		return fmt.Errorf("keystore: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("keystore: %w", err)
+		}
+		go k.answer(conn)
+	}
+}
+
+func (k *Keystore) answer(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	var req agentRequest
+	var reply agentReply
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		reply.Error = err.Error()
+	} else if c, ok := k.Get(req.Name); ok {
+		reply.Credentials = &c
+	} else {
+		reply.Error = fmt.Sprintf("no credentials for %q", req.Name)
+	}
+	json.NewEncoder(conn).Encode(reply)
+}
+
+// FromAgent asks the agent listening at path for the credentials stored
+// under name. It returns ErrNoAgent when there is no agent.
+func FromAgent(path, name string) (Credentials, error) {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return Credentials{}, ErrNoAgent
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if err := json.NewEncoder(conn).Encode(agentRequest{Name: name}); err != nil {
+		return Credentials{}, fmt.Errorf("keystore: agent: %w", err)
+	}
+	var reply agentReply
+	if err := json.NewDecoder(conn).Decode(&reply); err != nil {
+		return Credentials{}, fmt.Errorf("keystore: agent: %w", err)
+	}
+	if reply.Credentials == nil {
+		return Credentials{}, fmt.Errorf("keystore: agent: %s", reply.Error)
+	}
+	return *reply.Credentials, nil
+}
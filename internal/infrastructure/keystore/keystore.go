@@ -0,0 +1,275 @@
+// Package keystore keeps exchange credentials in a file encrypted with a
+// passphrase.
+//
+// The key is derived from the passphrase with scrypt and the content is
+// sealed with AES-256-GCM. The KDF parameters are authenticated along with
+// the content, so a tampered header fails like a wrong passphrase.
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrWrongPassphrase is returned when the file cannot be decrypted.
+var ErrWrongPassphrase = errors.New("keystore: wrong passphrase or corrupted file")
+
+// DefaultEntry holds the credentials used when no profile is selected.
+const DefaultEntry = "default"
+
+// scrypt parameters for new keystores (about 100ms on a laptop).
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+	keyLen  = 32
+)
+
+// Bounds on the scrypt parameters read from a file, so a corrupted or
+// hostile header cannot make Open allocate gigabytes or spin for hours.
+const (
+	maxN      = 1 << 20
+	maxR      = 32
+	maxP      = 16
+	maxMemory = 1 << 30 // 128·N·R bytes
+)
+
+// Credentials are the API key pair of one profile.
+type Credentials struct {
+	Key       string    `json:"key"`
+	Secret    string    `json:"secret"`
+	AddedAt   time.Time `json:"added_at"`
+	RotatedAt time.Time `json:"rotated_at"`
+}
+
+// header is the unencrypted part of the file. Entry names are kept in
+// clear so commands can tell whether to ask for the passphrase at all.
+type header struct {
+	Version int      `json:"version"`
+	KDF     string   `json:"kdf"`
+	Salt    []byte   `json:"salt"`
+	N       int      `json:"n"`
+	R       int      `json:"r"`
+	P       int      `json:"p"`
+	Entries []string `json:"entries"`
+}
+
+type file struct {
+	header
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Keystore is an unlocked keystore file.
+type Keystore struct {
+	path    string
+	header  header
+	key     []byte
+	entries map[string]Credentials
+}
+
+// Exists reports whether there is a keystore at path.
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Has reports whether the keystore at path holds credentials under name,
+// without decrypting it.
+func Has(path, name string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var h header
+	if json.Unmarshal(data, &h) != nil {
+		return false
+	}
+	for _, e := range h.Entries {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Create makes an empty keystore at path protected by passphrase. It
+// fails if the file already exists.
+func Create(path string, passphrase []byte) (*Keystore, error) {
+	if Exists(path) {
+		return nil, fmt.Errorf("keystore: %s already exists", path)
+	}
+	k := &Keystore{path: path, entries: make(map[string]Credentials)}
+	if err := k.derive(passphrase); err != nil {
+		return nil, err
+	}
+	return k, k.Save()
+}
+
+// Open decrypts the keystore at path.
+func Open(path string, passphrase []byte) (*Keystore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("keystore: failed to parse %s: %w", path, err)
+	}
+	if f.Version != 1 || f.KDF != "scrypt" {
+		return nil, fmt.Errorf("keystore: unsupported format %d/%s", f.Version, f.KDF)
+	}
+	if !validKDF(f.header) {
+		return nil, ErrWrongPassphrase
+	}
+	key, err := scrypt.Key(passphrase, f.Salt, f.N, f.R, f.P, keyLen)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Nonce) != aead.NonceSize() {
+		return nil, ErrWrongPassphrase // aead.Open panics on any other size
+	}
+	ad, _ := json.Marshal(f.header)
+	plain, err := aead.Open(nil, f.Nonce, f.Ciphertext, ad)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	k := &Keystore{path: path, header: f.header, key: key}
+	if err := json.Unmarshal(plain, &k.entries); err != nil {
+		return nil, fmt.Errorf("keystore: failed to decode entries: %w", err)
+	}
+	if k.entries == nil {
+		k.entries = make(map[string]Credentials)
+	}
+	return k, nil
+}
+
+// Names returns the names of the stored credentials, sorted.
+func (k *Keystore) Names() []string {
+	names := make([]string, 0, len(k.entries))
+	for n := range k.entries {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the credentials stored under name.
+func (k *Keystore) Get(name string) (Credentials, bool) {
+	c, ok := k.entries[name]
+	return c, ok
+}
+
+// Put stores c under name, replacing what was there. Call Save to write
+// the change.
+func (k *Keystore) Put(name string, c Credentials) {
+	k.entries[name] = c
+}
+
+// Delete removes name and reports whether it existed. Call Save to write
+// the change.
+func (k *Keystore) Delete(name string) bool {
+	_, ok := k.entries[name]
+	delete(k.entries, name)
+	return ok
+}
+
+// ChangePassphrase re-encrypts the keystore under a new passphrase and a
+// new salt, and saves it.
+func (k *Keystore) ChangePassphrase(passphrase []byte) error {
+	if err := k.derive(passphrase); err != nil {
+		return err
+	}
+	return k.Save()
+}
+
+// Save encrypts the entries with a fresh nonce and replaces the file.
+func (k *Keystore) Save() error {
+	aead, err := newAEAD(k.key)
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(k.entries)
+	if err != nil {
+		return fmt.Errorf("keystore: %w", err)
+	}
+	k.header.Entries = k.Names()
+	f := file{header: k.header, Nonce: make([]byte, aead.NonceSize())}
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return fmt.Errorf("keystore: %w", err)
+	}
+	ad, _ := json.Marshal(k.header)
+	f.Ciphertext = aead.Seal(nil, f.Nonce, plain, ad)
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("keystore: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(k.path), 0o700); err != nil {
+		return fmt.Errorf("keystore: %w", err)
+	}
+	tmp := k.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("keystore: failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, k.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("keystore: failed to replace %s: %w", k.path, err)
+	}
+	return nil
+}
+
+// derive sets a new salt and the key derived from passphrase.
+func (k *Keystore) derive(passphrase []byte) error {
+	if len(passphrase) == 0 {
+		return errors.New("keystore: the passphrase must not be empty")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("keystore: %w", err)
+	}
+	key, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, keyLen)
+	if err != nil {
+		return fmt.Errorf("keystore: %w", err)
+	}
+	k.header = header{Version: 1, KDF: "scrypt", Salt: salt, N: scryptN, R: scryptR, P: scryptP}
+	k.key = key
+	return nil
+}
+
+// validKDF reports whether the scrypt parameters of h are within bounds.
+func validKDF(h header) bool {
+	if h.N < 2 || h.N > maxN || h.N&(h.N-1) != 0 {
+		return false
+	}
+	if h.R < 1 || h.R > maxR || h.P < 1 || h.P > maxP {
+		return false
+	}
+	return 128*h.N*h.R <= maxMemory
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	return aead, nil
+}
@@ -0,0 +1,63 @@
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenTamperedHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	k, err := Create(path, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Put(DefaultEntry, Credentials{Key: "k", Secret: "s"})
+	if err := k.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path, []byte("secret")); err != nil {
+		t.Fatalf("untouched file: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(f *file)
+	}{
+		{"short nonce", func(f *file) { f.Nonce = f.Nonce[:4] }},
+		{"no nonce", func(f *file) { f.Nonce = nil }},
+		{"huge N", func(f *file) { f.N = 1 << 30 }},
+		{"N not a power of two", func(f *file) { f.N = 3 << 10 }},
+		{"zero N", func(f *file) { f.N = 0 }},
+		{"zero R", func(f *file) { f.R = 0 }},
+		{"huge R", func(f *file) { f.R = 1 << 20 }},
+		{"huge P", func(f *file) { f.P = 1 << 20 }},
+		{"too much memory", func(f *file) { f.N, f.R = maxN, maxR }},
+		{"lower N", func(f *file) { f.N = 1 << 10 }}, // in bounds, but authenticated
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f file
+			if err := json.Unmarshal(data, &f); err != nil {
+				t.Fatal(err)
+			}
+			tt.tamper(&f)
+			b, err := json.Marshal(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, b, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Open(path, []byte("secret")); !errors.Is(err, ErrWrongPassphrase) {
+				t.Errorf("err = %v, want ErrWrongPassphrase", err)
+			}
+		})
+	}
+}
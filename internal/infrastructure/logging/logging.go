@@ -0,0 +1,85 @@
+// Package logging sets up the structured logger of the bot: leveled, as
+// text or JSON, tagged with the request ID of the context, and with
+// credentials redacted from every record before it is written.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// Format is how records are written.
+type Format string
+
+const (
+	Text Format = "text" // key=value pairs
+	JSON Format = "json" // one object per line
+)
+
+// Options configure Setup.
+type Options struct {
+	Level  string // debug, info, warn or error; empty means info
+	Format Format // empty means Text
+}
+
+// Setup makes a redacting logger writing to w the default slog logger.
+// The standard log package, still used by dependencies, is routed through
+// it at info level.
+func Setup(w io.Writer, o Options) error {
+	level, err := ParseLevel(o.Level)
+	if err != nil {
+		return err
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	switch strings.ToLower(string(o.Format)) {
+	case "", string(Text):
+		h = slog.NewTextHandler(w, opts)
+	case string(JSON):
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("logging: unknown format %q, use text or json", o.Format)
+	}
+	slog.SetDefault(slog.New(&redactor{next: h}))
+	log.SetFlags(0) // slog adds its own time
+	return nil
+}
+
+// ParseLevel parses a level name, empty meaning info.
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return l, fmt.Errorf("logging: unknown level %q, use debug, info, warn or error", s)
+	}
+	return l, nil
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns ctx carrying id, which is added to every record
+// logged with it.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random request ID.
+func NewRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,146 @@
+package logging
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces whatever was removed from a record.
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are the fragments of attribute keys whose values are
+// never written, whatever they hold.
+var sensitiveKeys = []string{
+	"secret", "password", "passphrase", "token", "signature",
+	"authorization", "apikey", "api_key", "access_key", "private_key", "credential",
+}
+
+// patterns catch credentials in free text that were not registered with
+// Secret: bearer tokens, request signatures and key=value pairs.
+var patterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(bearer\s+)[^\s"',]+`),
+	regexp.MustCompile(`(?i)(sha256=)[0-9a-f]+`),
+	regexp.MustCompile(`(?i)((?:secret|password|passphrase|token|signature|api[_-]?key|access[_-]?key)["']?\s*[:=]\s*["']?)[^\s"',&}]+`),
+}
+
+// minSecret is the shortest value Secret accepts: anything shorter would
+// mangle ordinary text.
+const minSecret = 6
+
+var (
+	mu       sync.RWMutex
+	secrets  = map[string]bool{}
+	replacer = strings.NewReplacer()
+)
+
+// Secret registers values, such as an API secret or a bearer token, that
+// must never appear in a log record. They are replaced wherever they
+// show up, in messages, attributes and errors alike.
+func Secret(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range values {
+		if len(v) >= minSecret {
+			secrets[v] = true
+		}
+	}
+	// longest first: the replacer tries them in order, and a secret that
+	// is a prefix of another must not leave the rest of it behind
+	list := make([]string, 0, len(secrets))
+	for s := range secrets {
+		list = append(list, s)
+	}
+	slices.SortFunc(list, func(a, b string) int { return cmp.Or(len(b)-len(a), strings.Compare(a, b)) })
+	pairs := make([]string, 0, 2*len(list))
+	for _, s := range list {
+		pairs = append(pairs, s, Redacted)
+	}
+	replacer = strings.NewReplacer(pairs...)
+}
+
+// Scrub removes the registered secrets and anything that looks like a
+// credential from s.
+func Scrub(s string) string {
+	mu.RLock()
+	r := replacer
+	mu.RUnlock()
+	s = r.Replace(s)
+	for _, p := range patterns {
+		s = p.ReplaceAllString(s, "${1}"+Redacted)
+	}
+	return s
+}
+
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactor is a slog.Handler that scrubs every record before handing it
+// to next, and tags it with the request ID of its context.
+type redactor struct {
+	next slog.Handler
+}
+
+func (h *redactor) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.next.Enabled(ctx, l)
+}
+
+func (h *redactor) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, Scrub(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redact(a))
+		return true
+	})
+	if id := RequestID(ctx); id != "" {
+		out.AddAttrs(slog.String("request_id", id))
+	}
+	return h.next.Handle(ctx, out)
+}
+
+func (h *redactor) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		clean[i] = redact(a)
+	}
+	return &redactor{next: h.next.WithAttrs(clean)}
+}
+
+func (h *redactor) WithGroup(name string) slog.Handler {
+	return &redactor{next: h.next.WithGroup(name)}
+}
+
+// redact returns a with its value removed when the key is sensitive, and
+// scrubbed otherwise. Values that are not plain numbers, booleans or
+// times are written as scrubbed text.
+func redact(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if sensitive(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Scrub(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		clean := make([]slog.Attr, len(group))
+		for i, g := range group {
+			clean[i] = redact(g)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(clean...)}
+	case slog.KindAny:
+		return slog.String(a.Key, Scrub(fmt.Sprint(a.Value.Any())))
+	}
+	return a
+}
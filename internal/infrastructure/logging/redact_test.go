@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestScrub(t *testing.T) {
+	Secret("k3y-0f-the-account", "abc") // the second is too short to register
+	tests := []struct {
+		in, want string
+	}{
+		{"no credentials here: price=100", "no credentials here: price=100"},
+		{"key k3y-0f-the-account rejected", "key [REDACTED] rejected"},
+		{"login abc failed", "login abc failed"},
+		{"Authorization: Bearer eyJhbGciOi.x-y", "Authorization: Bearer [REDACTED]"},
+		{"body signed sha256=9f86d081884c7d65", "body signed sha256=[REDACTED]"},
+		{"X-Signature: sha256=9f86d081884c7d65", "X-Signature: [REDACTED]"},
+		{`{"secret": "s3cr3t", "action": "buy"}`, `{"secret": "[REDACTED]", "action": "buy"}`},
+		{"GET /orders?api_key=AKIA123&page=2", "GET /orders?api_key=[REDACTED]&page=2"},
+		{"password='hunter2', user=bob", "password='[REDACTED]', user=bob"},
+		{"X-FB-ACCESS-KEY: 0xDEADBEEF", "X-FB-ACCESS-KEY: [REDACTED]"},
+		{"api-key=1", "api-key=[REDACTED]"},
+		{"TOKEN=t1 token=t2", "TOKEN=[REDACTED] token=[REDACTED]"},
+	}
+	for _, tt := range tests {
+		if got := Scrub(tt.in); got != tt.want {
+			t.Errorf("Scrub(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestScrubSecretsSharingAPrefix(t *testing.T) {
+	// the replacer is rebuilt each time, from a map in random order
+	for i := 0; i < 20; i++ {
+		Secret("t0k-shared", "t0k-shared-and-longer")
+		if got := Scrub("sent t0k-shared-and-longer and t0k-shared"); got != "sent [REDACTED] and [REDACTED]" {
+			t.Fatalf("Scrub left %q", got)
+		}
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	Secret("k3y-0f-the-account")
+	var buf bytes.Buffer
+	log := slog.New(&redactor{next: slog.NewTextHandler(&buf, nil)}).With("api_secret", "plain")
+	ctx := WithRequestID(context.Background(), "req-1")
+	log.InfoContext(ctx, "sent k3y-0f-the-account",
+		"user_token", 12345,
+		"err", errors.New("401: bad key k3y-0f-the-account"),
+		slog.Group("request", "authorization", "Bearer x", "path", "/orders"))
+
+	got := buf.String()
+	for _, want := range []string{
+		`msg="sent [REDACTED]"`, "api_secret=[REDACTED]", "user_token=[REDACTED]",
+		`err="401: bad key [REDACTED]"`, "request.authorization=[REDACTED]", "request.path=/orders",
+		"request_id=req-1",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s in %s", want, got)
+		}
+	}
+	if strings.Contains(got, "k3y-0f-the-account") || strings.Contains(got, "plain") {
+		t.Errorf("secret written: %s", got)
+	}
+}
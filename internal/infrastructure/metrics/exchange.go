@@ -0,0 +1,239 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+var (
+	ordersPlaced = Counter("orders_placed_total",
+		"Orders accepted by the exchange.", "market", "side")
+	ordersCancelled = Counter("orders_cancelled_total",
+		"Orders cancelled.", "market")
+	ordersRejected = Counter("orders_rejected_total",
+		"Orders refused, by the kill switch, the risk limits or the exchange.", "market", "reason")
+	fills = Counter("fills_total",
+		"Executions seen on our orders.", "market", "side")
+	filledQuantity = Counter("filled_quantity_total",
+		"Base quantity executed on our orders.", "market", "side")
+	openOrders = Gauge("open_orders",
+		"Active orders at the last listing of the market.", "market")
+	balances = Gauge("balance",
+		"Account balance at the last refresh.", "currency", "kind")
+)
+
+// Exchange is a service.Exchange decorator that counts the orders placed,
+// cancelled and rejected through it and the executions seen on them, and
+// keeps the open order and balance gauges up to date whenever the exchange
+// is asked for them.
+//
+// Executions are found by comparing the executed quantity of an order with
+// the last one seen by this process, so an order filled while the bot was
+// down only counts what it executes from then on.
+type Exchange struct {
+	service.Exchange
+
+	// Reason labels a rejected order with the cause of err, e.g.
+	// "risk" or "kill_switch". Nil labels every rejection "error".
+	Reason func(err error) string
+
+	mu      sync.Mutex
+	working map[string]tracked // by order ID, until the order is seen final
+	refresh time.Time
+}
+
+// tracked is what was last seen of a working order.
+type tracked struct {
+	market   string
+	executed float64
+}
+
+// CreateOrder implements Exchange.CreateOrder.
+func (e *Exchange) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
+	placed, err := e.Exchange.CreateOrder(ctx, o)
+	e.placed(o, placed, err)
+	return placed, err
+}
+
+// PlaceOrders implements Exchange.PlaceOrders.
+func (e *Exchange) PlaceOrders(ctx context.Context, orders []model.Order) []model.OrderResult {
+	results := e.Exchange.PlaceOrders(ctx, orders)
+	for i, res := range results {
+		e.placed(orders[i], &res.Order, res.Err)
+	}
+	return results
+}
+
+// CancelOrder implements Exchange.CancelOrder.
+func (e *Exchange) CancelOrder(ctx context.Context, id string) error {
+	err := e.Exchange.CancelOrder(ctx, id)
+	if err == nil {
+		e.mu.Lock()
+		market := e.working[id].market
+		e.mu.Unlock()
+		ordersCancelled.WithLabelValues(market).Inc()
+	}
+	return err
+}
+
+// CancelOrders implements Exchange.CancelOrders.
+func (e *Exchange) CancelOrders(ctx context.Context, ids []string) []model.OrderResult {
+	results := e.Exchange.CancelOrders(ctx, ids)
+	e.cancelled(results)
+	return results
+}
+
+// CancelAllOrders implements Exchange.CancelAllOrders.
+func (e *Exchange) CancelAllOrders(ctx context.Context, market string, side model.OrderSide) ([]model.OrderResult, error) {
+	results, err := e.Exchange.CancelAllOrders(ctx, market, side)
+	e.cancelled(results)
+	return results, err
+}
+
+// GetOrderByID implements Exchange.GetOrderByID.
+func (e *Exchange) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
+	o, err := e.Exchange.GetOrderByID(ctx, id)
+	if err == nil {
+		e.observe(*o)
+	}
+	return o, err
+}
+
+// GetActiveOrders implements Exchange.GetActiveOrders.
+func (e *Exchange) GetActiveOrders(ctx context.Context, market string) ([]model.Order, error) {
+	orders, err := e.Exchange.GetActiveOrders(ctx, market)
+	if err != nil {
+		return orders, err
+	}
+	count := make(map[string]int)
+	if market != "" {
+		count[strings.ToUpper(market)] = 0 // an empty book still sets the gauge
+	} else {
+		openOrders.Reset()
+	}
+	for _, o := range orders {
+		e.observe(o)
+		count[strings.ToUpper(o.MarketSymbol)]++
+	}
+	for m, n := range count {
+		openOrders.WithLabelValues(m).Set(float64(n))
+	}
+	return orders, err
+}
+
+// GetBalances implements Exchange.GetBalances.
+func (e *Exchange) GetBalances(ctx context.Context) ([]model.Balance, error) {
+	bals, err := e.Exchange.GetBalances(ctx)
+	if err != nil {
+		return bals, err
+	}
+	balances.Reset()
+	for _, b := range bals {
+		balances.WithLabelValues(b.Currency, "total").Set(parse(b.Total))
+		balances.WithLabelValues(b.Currency, "available").Set(parse(b.Available))
+		balances.WithLabelValues(b.Currency, "locked").Set(parse(b.Locked))
+	}
+	e.mu.Lock()
+	e.refresh = time.Now()
+	e.mu.Unlock()
+	return bals, nil
+}
+
+// RefreshBalances returns a scrape hook that reads the balances when they
+// were last read more than maxAge ago, since no bot command does so on
+// its own. A failed read is not retried before maxAge either, so an
+// unreachable exchange does not slow every scrape down.
+func (e *Exchange) RefreshBalances(maxAge time.Duration) func() {
+	return func() {
+		e.mu.Lock()
+		stale := time.Since(e.refresh) > maxAge
+		if stale {
+			e.refresh = time.Now()
+		}
+		e.mu.Unlock()
+		if !stale {
+			return
+		}
+		if _, err := e.GetBalances(context.Background()); err != nil {
+			slog.Warn("metrics: failed to refresh the balances", "err", err)
+		}
+	}
+}
+
+func (e *Exchange) placed(req model.Order, placed *model.Order, err error) {
+	market := strings.ToUpper(req.MarketSymbol)
+	if err != nil {
+		reason := "error"
+		if e.Reason != nil {
+			reason = e.Reason(err)
+		}
+		ordersRejected.WithLabelValues(market, reason).Inc()
+		return
+	}
+	ordersPlaced.WithLabelValues(market, string(req.Side)).Inc()
+	o := *placed
+	if o.MarketSymbol == "" {
+		o.MarketSymbol = req.MarketSymbol
+	}
+	if o.Side == "" {
+		o.Side = req.Side
+	}
+	if o.ID == "" {
+		return
+	}
+	e.mu.Lock()
+	if e.working == nil {
+		e.working = make(map[string]tracked)
+	}
+	if _, ok := e.working[o.ID]; !ok {
+		e.working[o.ID] = tracked{market: market} // ours from the start: any execution is new
+	}
+	e.mu.Unlock()
+	e.observe(o)
+}
+
+// observe counts what o executed since it was last seen.
+func (e *Exchange) observe(o model.Order) {
+	if o.ID == "" {
+		return
+	}
+	market, executed := strings.ToUpper(o.MarketSymbol), parse(o.QuantityExecuted)
+	e.mu.Lock()
+	if e.working == nil {
+		e.working = make(map[string]tracked)
+	}
+	last, seen := e.working[o.ID]
+	if market == "" {
+		market = last.market
+	}
+	e.working[o.ID] = tracked{market: market, executed: executed}
+	if o.State.Final() {
+		delete(e.working, o.ID)
+	}
+	e.mu.Unlock()
+
+	if seen && executed > last.executed {
+		fills.WithLabelValues(market, string(o.Side)).Inc()
+		filledQuantity.WithLabelValues(market, string(o.Side)).Add(executed - last.executed)
+	}
+}
+
+func (e *Exchange) cancelled(results []model.OrderResult) {
+	for _, res := range results {
+		if res.Err == nil {
+			ordersCancelled.WithLabelValues(strings.ToUpper(res.Order.MarketSymbol)).Inc()
+		}
+	}
+}
+
+func parse(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
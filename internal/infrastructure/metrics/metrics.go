@@ -0,0 +1,120 @@
+// Package metrics registers the bot's Prometheus metrics and serves them,
+// so running bots can be scraped.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Namespace prefixes the name of every metric of the bot.
+const Namespace = "trading_bot"
+
+// DefaultBuckets are the upper bounds, in seconds, of the latency
+// histograms.
+var DefaultBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry is the registry the bot's packages register their metrics
+// with. It also holds the Go runtime and process collectors.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+var (
+	hooksMu sync.Mutex
+	hooks   []func()
+)
+
+// Counter registers a counter with Registry. Its name gets the namespace
+// prefix and should end in _total.
+func Counter(name, help string, labels ...string) *prometheus.CounterVec {
+	return promauto.With(Registry).NewCounterVec(
+		prometheus.CounterOpts{Namespace: Namespace, Name: name, Help: help}, labels)
+}
+
+// Gauge registers a gauge with Registry. Its name gets the namespace
+// prefix.
+func Gauge(name, help string, labels ...string) *prometheus.GaugeVec {
+	return promauto.With(Registry).NewGaugeVec(
+		prometheus.GaugeOpts{Namespace: Namespace, Name: name, Help: help}, labels)
+}
+
+// Histogram registers a histogram with Registry with the given bucket
+// upper bounds, DefaultBuckets when nil. Its name gets the namespace
+// prefix.
+func Histogram(name, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	return promauto.With(Registry).NewHistogramVec(
+		prometheus.HistogramOpts{Namespace: Namespace, Name: name, Help: help, Buckets: buckets}, labels)
+}
+
+// OnScrape registers fn to run before every scrape, to refresh gauges
+// that are cheaper to read on demand than to keep current.
+func OnScrape(fn func()) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, fn)
+}
+
+// gatherer runs the scrape hooks before gathering Registry.
+var gatherer = prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+	hooksMu.Lock()
+	fns := append([]func(){}, hooks...)
+	hooksMu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+	return Registry.Gather()
+})
+
+// Handler serves Registry in the Prometheus exposition formats.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{ErrorLog: errorLog{}})
+}
+
+// errorLog reports the errors of the handler through slog.
+type errorLog struct{}
+
+func (errorLog) Println(v ...any) { slog.Warn("metrics: scrape failed", "err", fmt.Sprint(v...)) }
+
+// Serve exposes Registry on addr under /metrics until ctx is cancelled.
+func Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", Handler())
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	slog.Info("metrics: listening", "addr", addr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(sctx)
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+func TestExposition(t *testing.T) {
+	ctx := context.Background()
+	ex := &Exchange{Exchange: &fakeExchange{}, Reason: func(error) string { return "risk" }}
+	scrapes := 0
+	OnScrape(func() { scrapes++ })
+
+	if _, err := ex.CreateOrder(ctx, model.Order{MarketSymbol: "ethbrl", Side: model.Buy, Quantity: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	ex.CreateOrder(ctx, model.Order{MarketSymbol: "ethbrl", Side: model.Sell, Quantity: "0"})
+	ex.GetOrderByID(ctx, "1") // half filled
+	ex.GetBalances(ctx)
+	Histogram("test_duration_seconds", "A test histogram.", []float64{0.1, 1}, "op").WithLabelValues("x").Observe(0.5)
+
+	got := scrape(t)
+	if scrapes != 1 {
+		t.Errorf("scrape hook ran %d times, want 1", scrapes)
+	}
+	for _, want := range []string{
+		"# HELP trading_bot_orders_placed_total Orders accepted by the exchange.",
+		"# TYPE trading_bot_orders_placed_total counter",
+		`trading_bot_orders_placed_total{market="ETHBRL",side="BUY"} 1`,
+		`trading_bot_orders_rejected_total{market="ETHBRL",reason="risk"} 1`,
+		`trading_bot_fills_total{market="ETHBRL",side="BUY"} 1`,
+		`trading_bot_filled_quantity_total{market="ETHBRL",side="BUY"} 0.5`,
+		"# TYPE trading_bot_balance gauge",
+		`trading_bot_balance{currency="BRL",kind="available"} 80`,
+		`trading_bot_balance{currency="BRL",kind="locked"} 20`,
+		"# TYPE trading_bot_test_duration_seconds histogram",
+		`trading_bot_test_duration_seconds_bucket{op="x",le="0.1"} 0`,
+		`trading_bot_test_duration_seconds_bucket{op="x",le="1"} 1`,
+		`trading_bot_test_duration_seconds_bucket{op="x",le="+Inf"} 1`,
+		`trading_bot_test_duration_seconds_sum{op="x"} 0.5`,
+		"# TYPE go_goroutines gauge",
+	} {
+		if !strings.Contains(got, want+"\n") {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(Handler())
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("content type %q", ct)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+// fakeExchange accepts orders with a positive quantity as ID 1, which
+// has executed half of its quantity when read back.
+type fakeExchange struct {
+	service.Exchange
+}
+
+func (f *fakeExchange) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
+	if o.Quantity == "0" {
+		return nil, errors.New("refused")
+	}
+	o.ID, o.State = "1", model.StateActive
+	return &o, nil
+}
+
+func (f *fakeExchange) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
+	return &model.Order{ID: id, MarketSymbol: "ETHBRL", Side: model.Buy, State: model.StatePartiallyFilled, QuantityExecuted: "0.5"}, nil
+}
+
+func (f *fakeExchange) GetBalances(ctx context.Context) ([]model.Balance, error) {
+	return []model.Balance{{Currency: "BRL", Total: "100", Available: "80", Locked: "20"}}, nil
+}
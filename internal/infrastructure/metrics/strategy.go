@@ -0,0 +1,11 @@
+package metrics
+
+// Strategy gauges, set by the commands running the strategies.
+var (
+	StrategyPnL = Gauge("strategy_pnl",
+		"Profit of a strategy in quote currency: realized for grids, marked to the mid for market makers.", "strategy", "market")
+	StrategyPosition = Gauge("strategy_position",
+		"Base quantity bought minus sold by a strategy.", "strategy", "market")
+	StrategyCost = Gauge("strategy_cost",
+		"Quote currency spent by a strategy, net of what it received.", "strategy", "market")
+)
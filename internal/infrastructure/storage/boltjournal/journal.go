@@ -0,0 +1,202 @@
+// Package boltjournal implements service.Journal on a bbolt database.
+package boltjournal
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+var (
+	entriesBucket = []byte("entries") // sequence → entry
+	ordersBucket  = []byte("orders")  // order ID → latest snapshot
+)
+
+// Journal stores entries in a bbolt file. bbolt allows one process at a
+// time, so the file is only opened for the duration of each call; other
+// processes wait up to lockTimeout for it.
+type Journal struct {
+	path string
+}
+
+const lockTimeout = 10 * time.Second
+
+// New returns a Journal kept in the file at path, creating it if needed.
+func New(path string) (service.Journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("journal: failed to create %s: %w", filepath.Dir(path), err)
+	}
+	j := &Journal{path: path}
+	err := j.update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(entriesBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(ordersBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Append implements Journal.Append.
+func (j *Journal) Append(entries ...model.JournalEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return j.update(func(tx *bolt.Tx) error {
+		eb, ob := tx.Bucket(entriesBucket), tx.Bucket(ordersBucket)
+		for _, e := range entries {
+			seq, err := eb.NextSequence()
+			if err != nil {
+				return err
+			}
+			e.Seq = seq
+			if e.Time.IsZero() {
+				e.Time = time.Now()
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if err := eb.Put(key(seq), data); err != nil {
+				return err
+			}
+			if e.Order != nil && e.OrderID != "" {
+				snap, err := json.Marshal(e.Order)
+				if err != nil {
+					return err
+				}
+				if err := ob.Put([]byte(e.OrderID), snap); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Query implements Journal.Query.
+func (j *Journal) Query(f model.JournalFilter) ([]model.JournalEntry, error) {
+	var out []model.JournalEntry
+	err := j.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(entriesBucket).Cursor()
+		// walk backwards so Limit keeps the newest entries
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var e model.JournalEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("entry %d: %w", binary.BigEndian.Uint64(k), err)
+			}
+			if !f.Since.IsZero() && e.Time.Before(f.Since) {
+				break
+			}
+			if match(f, e) {
+				out = append(out, e)
+				if f.Limit > 0 && len(out) == f.Limit {
+					break
+				}
+			}
+		}
+		return nil
+	})
+	for i, k := 0, len(out)-1; i < k; i, k = i+1, k-1 {
+		out[i], out[k] = out[k], out[i]
+	}
+	return out, err
+}
+
+// Last implements Journal.Last.
+func (j *Journal) Last(orderID string) (*model.Order, error) {
+	var o *model.Order
+	err := j.view(func(tx *bolt.Tx) error {
+		v := tx.Bucket(ordersBucket).Get([]byte(orderID))
+		if v == nil {
+			return nil
+		}
+		o = new(model.Order)
+		return json.Unmarshal(v, o)
+	})
+	return o, err
+}
+
+// Open implements Journal.Open.
+func (j *Journal) Open(market string) ([]model.Order, error) {
+	var out []model.Order
+	err := j.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(ordersBucket).ForEach(func(_, v []byte) error {
+			var o model.Order
+			if err := json.Unmarshal(v, &o); err != nil {
+				return err
+			}
+			if o.State.Final() {
+				return nil
+			}
+			if market == "" || strings.EqualFold(o.MarketSymbol, market) {
+				out = append(out, o)
+			}
+			return nil
+		})
+	})
+	return out, err
+}
+
+func match(f model.JournalFilter, e model.JournalEntry) bool {
+	if f.OrderID != "" && e.OrderID != f.OrderID {
+		return false
+	}
+	if f.Market != "" && !strings.EqualFold(e.Market, f.Market) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	if len(f.Kinds) == 0 {
+		return true
+	}
+	for _, k := range f.Kinds {
+		if e.Kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *Journal) update(fn func(*bolt.Tx) error) error {
+	db, err := bolt.Open(j.path, 0o600, &bolt.Options{Timeout: lockTimeout})
+	if err != nil {
+		return fmt.Errorf("journal: failed to open %s: %w", j.path, err)
+	}
+	defer db.Close()
+	if err := db.Update(fn); err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+	return nil
+}
+
+func (j *Journal) view(fn func(*bolt.Tx) error) error {
+	db, err := bolt.Open(j.path, 0o600, &bolt.Options{Timeout: lockTimeout, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("journal: failed to open %s: %w", j.path, err)
+	}
+	defer db.Close()
+	if err := db.View(fn); err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+	return nil
+}
+
+func key(seq uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, seq)
+	return b
+}
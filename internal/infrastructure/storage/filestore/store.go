@@ -0,0 +1,104 @@
+package filestore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"trading-bot/internal/domain/service"
+)
+
+// FileStore implements service.StateStore with one JSON file per key.
+type FileStore struct {
+	dir string
+}
+
+// New returns a FileStore rooted at dir, creating the directory if needed.
+func New(dir string) (service.StateStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("filestore: failed to create %s: %w", dir, err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Load implements StateStore.Load.
+func (s *FileStore) Load(key string, dest interface{}) (bool, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("filestore: failed to read %q: %w", key, err)
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return false, fmt.Errorf("filestore: failed to decode %q: %w", key, err)
+	}
+	return true, nil
+}
+
+// Save implements StateStore.Save.
+// The snapshot is written to a temporary file and renamed over the old one,
+// so a crash mid-write never leaves a truncated state behind.
+func (s *FileStore) Save(key string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("filestore: failed to encode %q: %w", key, err)
+	}
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("filestore: failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("filestore: failed to write %q: %w", key, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("filestore: failed to sync %q: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("filestore: failed to close %q: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(key)); err != nil {
+		return fmt.Errorf("filestore: failed to replace %q: %w", key, err)
+	}
+	return nil
+}
+
+// Delete implements StateStore.Delete.
+func (s *FileStore) Delete(key string) error {
+	err := os.Remove(s.path(key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("filestore: failed to delete %q: %w", key, err)
+	}
+	return nil
+}
+
+// Keys implements StateStore.Keys.
+func (s *FileStore) Keys(prefix string) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("filestore: failed to list %s: %w", s.dir, err)
+	}
+	var keys []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".json") || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		keys = append(keys, strings.TrimSuffix(name, ".json"))
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// path maps a key to a file name, neutralising path separators.
+func (s *FileStore) path(key string) string {
+	safe := strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(key)
+	return filepath.Join(s.dir, safe+".json")
+}
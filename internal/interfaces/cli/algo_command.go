@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"trading-bot/internal/application/algo"
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+)
+
+// runExecuteAlgo handles the "execute-algo" sub-command.
+func runExecuteAlgo(args []string) {
+	fs := flag.NewFlagSet("execute-algo", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	kind := fs.String("algo", "twap", "Execution algorithm: twap|vwap")
+	market := fs.String("market", defaultMarket(), "Market symbol, e.g. BTCBRL")
+	sideF := fs.String("side", "buy", "Order side: buy|sell")
+	qty := fs.Float64("quantity", 0, "Parent order quantity")
+	duration := fs.Duration("duration", time.Hour, "Time over which the order is worked")
+	slices := fs.Int("slices", 12, "Number of child slices")
+	limit := fs.Float64("limit-price", 0, "Never buy above / sell below this price (0 = none)")
+	participation := fs.Float64("max-participation", 0, "Cap each child at this fraction of recent market volume (0 = none)")
+	lookback := fs.Int("lookback-days", 7, "Days of history used to build the VWAP volume profile")
+	interval := fs.Duration("interval", 10*time.Second, "How often the working child is polled")
+	stateDir := fs.String("state-dir", defaultStateDir(), "Directory where executions are saved")
+	resume := fs.String("resume", "", "Resume a paused execution by ID")
+	status := fs.String("status", "", "Show the progress of an execution by ID and exit")
+	abort := fs.String("cancel", "", "Cancel an execution by ID and exit")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s execute-algo [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Press Ctrl+C to pause; resume later with -resume ID.")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	ex := mustInitExchange(*exch)
+	x := &algo.Executor{
+		Place:    &usecase.PlaceOrder{Ex: ex},
+		Cancel:   &usecase.CancelOrder{Ex: ex},
+		Get:      &usecase.GetOrder{Ex: ex},
+		Book:     &usecase.FetchOrderBook{Ex: ex},
+		Candles:  &usecase.FetchCandles{Ex: ex},
+		Store:    mustOpenStateStore(*stateDir),
+		Interval: *interval,
+	}
+
+	id := *resume
+	if *status != "" {
+		id = *status
+	} else if *abort != "" {
+		id = *abort
+	}
+
+	var e *algo.Execution
+	var err error
+	if id != "" {
+		if e, err = x.Load(id); err != nil {
+			DisplayError(err)
+			os.Exit(1)
+		}
+		if *status != "" {
+			DisplayExecution(e)
+			return
+		}
+		x.Market = mustLookupMarket(ex, e.Params.Market)
+		if *abort != "" {
+			if err := x.Abort(commandCtx, e); err != nil {
+				DisplayError(err)
+				os.Exit(1)
+			}
+			DisplayExecution(e)
+			return
+		}
+	} else {
+		if *market == "" || *qty <= 0 {
+			fmt.Fprintln(os.Stderr, "error: -market and -quantity are required")
+			fs.Usage()
+			os.Exit(1)
+		}
+		var side model.OrderSide
+		switch strings.ToLower(*sideF) {
+		case "buy":
+			side = model.Buy
+		case "sell":
+			side = model.Sell
+		default:
+			fmt.Fprintln(os.Stderr, "error: invalid side, use buy or sell")
+			os.Exit(1)
+		}
+		x.Market = mustLookupMarket(ex, *market)
+		e, err = x.Plan(commandCtx, algo.Params{
+			Kind:             algo.Kind(strings.ToUpper(*kind)),
+			Market:           x.Market.Symbol,
+			Side:             side,
+			Quantity:         *qty,
+			Duration:         *duration,
+			Slices:           *slices,
+			LimitPrice:       *limit,
+			MaxParticipation: *participation,
+		}, *lookback)
+		if err != nil {
+			DisplayError(err)
+			os.Exit(1)
+		}
+		notef("Execution %s started.\n", e.ID)
+	}
+
+	ctx, stop := runContext()
+	defer stop()
+	if err := x.Run(ctx, e); err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	DisplayExecution(e)
+	if e.Status == algo.Paused {
+		notef("\nPaused; resume with: %s execute-algo -resume %s\n", os.Args[0], e.ID)
+	}
+}
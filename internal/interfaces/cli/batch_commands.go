@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+)
+
+// orderRow is one order of a batch file. CSV files use the JSON field
+// names as their header.
+type orderRow struct {
+	ID       string `json:"id"`
+	Market   string `json:"market"`
+	Side     string `json:"side"`
+	Type     string `json:"type"`
+	Price    string `json:"price"`
+	Quantity string `json:"quantity"`
+	PostOnly *bool  `json:"post_only"`
+}
+
+// order converts the row, defaulting to a post-only limit order like
+// place-order does.
+func (r orderRow) order() (model.Order, error) {
+	o := model.Order{
+		ID:           r.ID,
+		MarketSymbol: r.Market,
+		Side:         model.OrderSide(strings.ToUpper(r.Side)),
+		Type:         model.OrderType(strings.ToUpper(r.Type)),
+		Price:        r.Price,
+		Quantity:     r.Quantity,
+	}
+	if o.Type == "" {
+		o.Type = model.Limit
+	}
+	if o.Side != model.Buy && o.Side != model.Sell {
+		return o, fmt.Errorf("invalid side %q", r.Side)
+	}
+	if o.Type == model.Limit {
+		o.PostOnly = r.PostOnly == nil || *r.PostOnly
+	}
+	return o, nil
+}
+
+// readOrderRows reads a .json file holding an array of orders, or a .csv
+// file with a header line.
+func readOrderRows(path string) ([]orderRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rows []orderRow
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.NewDecoder(f).Decode(&rows); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return rows, nil
+	}
+
+	r := csv.NewReader(f)
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for line := 2; ; line++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		var row orderRow
+		for i, name := range header {
+			v := strings.TrimSpace(rec[i])
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "id":
+				row.ID = v
+			case "market":
+				row.Market = v
+			case "side":
+				row.Side = v
+			case "type":
+				row.Type = v
+			case "price":
+				row.Price = v
+			case "quantity":
+				row.Quantity = v
+			case "post_only":
+				if v == "" {
+					continue
+				}
+				b, err := strconv.ParseBool(v)
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: invalid post_only %q", path, line, v)
+				}
+				row.PostOnly = &b
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// runPlaceOrders handles the "place-orders" sub-command.
+func runPlaceOrders(args []string) {
+	fs := flag.NewFlagSet("place-orders", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	file := fs.String("file", "", "CSV or JSON file with the orders (required)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s place-orders [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Columns/fields: market, side, quantity, price, type (default limit), post_only (default true).")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "error: -file is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	rows, err := readOrderRows(*file)
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	orders := make([]model.Order, len(rows))
+	for i, r := range rows {
+		if orders[i], err = r.order(); err != nil {
+			DisplayError(fmt.Errorf("%s: order %d: %w", *file, i+1, err))
+			os.Exit(1)
+		}
+	}
+
+	ex := mustInitExchange(*exch)
+	results := (&usecase.PlaceOrders{Ex: ex}).Execute(commandCtx, orders)
+	DisplayOrderResults(results)
+	exitOnFailedResult(results)
+}
+
+// runCancelOrders handles the "cancel-orders" sub-command.
+func runCancelOrders(args []string) {
+	fs := flag.NewFlagSet("cancel-orders", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	ids := fs.String("ids", "", "Comma-separated order IDs")
+	file := fs.String("file", "", "CSV or JSON file whose id column/field lists the orders")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s cancel-orders [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Options:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var list []string
+	for _, id := range strings.Split(*ids, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			list = append(list, id)
+		}
+	}
+	if *file != "" {
+		rows, err := readOrderRows(*file)
+		if err != nil {
+			DisplayError(err)
+			os.Exit(1)
+		}
+		for _, r := range rows {
+			if r.ID != "" {
+				list = append(list, r.ID)
+			}
+		}
+	}
+	if len(list) == 0 {
+		fmt.Fprintln(os.Stderr, "error: -ids or -file with at least one order ID is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	ex := mustInitExchange(*exch)
+	results := (&usecase.CancelOrders{Ex: ex}).Execute(commandCtx, list)
+	DisplayOrderResults(results)
+	exitOnFailedResult(results)
+}
+
+// runCancelAll handles the "cancel-all" sub-command.
+func runCancelAll(args []string) {
+	fs := flag.NewFlagSet("cancel-all", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	market := fs.String("market", "", "Market symbol, e.g. BTCBRL (required unless -all-markets)")
+	all := fs.Bool("all-markets", false, "Cancel in every market")
+	sideF := fs.String("side", "", "Only cancel this side: buy|sell")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s cancel-all [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Options:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if (*market == "") == !*all {
+		fmt.Fprintln(os.Stderr, "error: use either -market or -all-markets")
+		fs.Usage()
+		os.Exit(1)
+	}
+	var side model.OrderSide
+	switch strings.ToLower(*sideF) {
+	case "":
+	case "buy":
+		side = model.Buy
+	case "sell":
+		side = model.Sell
+	default:
+		fmt.Fprintln(os.Stderr, "error: invalid side, use buy or sell")
+		os.Exit(1)
+	}
+
+	ex := mustInitExchange(*exch)
+	results, err := (&usecase.CancelAllOrders{Ex: ex}).Execute(commandCtx, *market, side)
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	DisplayOrderResults(results)
+	exitOnFailedResult(results)
+}
+
+// exitOnFailedResult exits with status 1 when any item of a batch failed.
+func exitOnFailedResult(results []model.OrderResult) {
+	for _, r := range results {
+		if r.Err != nil {
+			os.Exit(1)
+		}
+	}
+}
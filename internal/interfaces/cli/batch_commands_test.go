@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"trading-bot/internal/domain/model"
+)
+
+func TestReadOrderRows(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name    string
+		file    string
+		data    string
+		want    []orderRow
+		wantErr string // part of the error, which names the file
+	}{
+		{
+			name: "JSON",
+			file: "orders.json",
+			data: `[{"market":"btcbrl","side":"buy","price":"100","quantity":"0.1"},
+				{"market":"btcbrl","side":"sell","type":"market","quantity":"0.2","post_only":false,"note":"ignored"}]`,
+			want: []orderRow{
+				{Market: "btcbrl", Side: "buy", Price: "100", Quantity: "0.1"},
+				{Market: "btcbrl", Side: "sell", Type: "market", Quantity: "0.2", PostOnly: &no},
+			},
+		},
+		{name: "malformed JSON", file: "orders.json", data: `[{"market":`, wantErr: "orders.json"},
+		{name: "JSON with a number for a string", file: "orders.JSON", data: `[{"quantity":1}]`, wantErr: "orders.JSON"},
+		{
+			name: "CSV",
+			file: "orders.csv",
+			data: " Quantity,SIDE,market,price,post_only,note\n0.1, buy,btcbrl,100,,x\n0.2,sell ,btcbrl,101,true,y\n",
+			want: []orderRow{
+				{Market: "btcbrl", Side: "buy", Price: "100", Quantity: "0.1"},
+				{Market: "btcbrl", Side: "sell", Price: "101", Quantity: "0.2", PostOnly: &yes},
+			},
+		},
+		{name: "CSV with only a header", file: "orders.csv", data: "market,side,quantity\n"},
+		{name: "bad post_only", file: "orders.csv", data: "market,side,quantity,post_only\nbtcbrl,buy,1,true\nbtcbrl,buy,1,maybe\n", wantErr: `orders.csv:3: invalid post_only "maybe"`},
+		{name: "missing field", file: "orders.csv", data: "market,side,quantity\nbtcbrl,buy\n", wantErr: "orders.csv"},
+		{name: "empty CSV", file: "orders.csv", wantErr: "orders.csv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			rows, err := readOrderRows(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("rows %+v, want %+v", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderRowOrder(t *testing.T) {
+	no := false
+	tests := []struct {
+		name    string
+		row     orderRow
+		want    model.Order
+		wantErr bool
+	}{
+		{
+			name: "post-only limit by default",
+			row:  orderRow{Market: "btcbrl", Side: "buy", Price: "100", Quantity: "1"},
+			want: model.Order{MarketSymbol: "btcbrl", Side: model.Buy, Type: model.Limit, Price: "100", Quantity: "1", PostOnly: true},
+		},
+		{
+			name: "limit taking liquidity",
+			row:  orderRow{Market: "btcbrl", Side: "Sell", Type: "limit", Price: "100", Quantity: "1", PostOnly: &no},
+			want: model.Order{MarketSymbol: "btcbrl", Side: model.Sell, Type: model.Limit, Price: "100", Quantity: "1"},
+		},
+		{
+			name: "market order is never post-only",
+			row:  orderRow{Market: "btcbrl", Side: "sell", Type: "market", Quantity: "1"},
+			want: model.Order{MarketSymbol: "btcbrl", Side: model.Sell, Type: model.MarketOrder, Quantity: "1"},
+		},
+		{name: "invalid side", row: orderRow{Market: "btcbrl", Side: "hold", Quantity: "1"}, wantErr: true},
+		{name: "no side", row: orderRow{Market: "btcbrl", Quantity: "1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := tt.row.order()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("order %+v, want an error", o)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o != tt.want {
+				t.Errorf("order %+v, want %+v", o, tt.want)
+			}
+		})
+	}
+}
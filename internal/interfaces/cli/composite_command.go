@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"trading-bot/internal/application/composite"
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+)
+
+// runComposite handles the "oco" and "bracket" sub-commands.
+func runComposite(kind composite.Kind, args []string) {
+	name := strings.ToLower(string(kind))
+	bracket := kind == composite.Bracket
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	market := fs.String("market", defaultMarket(), "Market symbol, e.g. BTCBRL")
+	sideF := fs.String("side", "sell", "Side of the exit orders: sell closes a long, buy closes a short")
+	qty := fs.String("quantity", "", "Quantity to protect")
+	tp := fs.String("take-profit", "", "Limit price of the take-profit order")
+	stopPrice := fs.String("stop-price", "", "Last traded price that fires the stop")
+	stopLimit := fs.String("stop-limit-price", "", "Send the stop as a limit order at this price instead of a market order")
+	var entry *string
+	if bracket {
+		entry = fs.String("entry-price", "", "Limit price of the entry order")
+	}
+	interval := fs.Duration("interval", 2*time.Second, "How often orders are checked")
+	stopTimeout := fs.Duration("stop-timeout", time.Minute, "How long a fired stop-limit may rest before the rest is sold at market")
+	stateDir := fs.String("state-dir", defaultStateDir(), "Directory where composite orders are saved")
+	resume := fs.String("resume", "", "Resume watching an order by ID")
+	status := fs.String("status", "", "Show an order by ID and exit")
+	abort := fs.String("cancel", "", "Cancel an order by ID and exit")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [options]\n\n", os.Args[0], name)
+		fmt.Fprintln(fs.Output(), "The stop is a trigger fired by run-triggers, which must be running. This command")
+		fmt.Fprintln(fs.Output(), "keeps the exits sized to the position; resume it later with -resume ID.")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	ex := mustInitExchange(*exch)
+	mgr := &composite.Manager{
+		Place:       &usecase.PlaceOrder{Ex: ex},
+		Cancel:      &usecase.CancelOrder{Ex: ex},
+		Get:         &usecase.GetOrder{Ex: ex},
+		Triggers:    newTriggerEngine(ex, *stateDir, 0),
+		Store:       mustOpenStateStore(*stateDir),
+		Interval:    *interval,
+		StopTimeout: *stopTimeout,
+	}
+
+	id := *resume
+	if *status != "" {
+		id = *status
+	} else if *abort != "" {
+		id = *abort
+	}
+
+	var o *composite.Order
+	var err error
+	if id != "" {
+		if o, err = mgr.Load(id); err != nil {
+			DisplayError(err)
+			os.Exit(1)
+		}
+		if *status != "" {
+			DisplayComposite(o)
+			return
+		}
+		mgr.Market = mustLookupMarket(ex, o.Market)
+		if *abort != "" {
+			if err := mgr.Abort(commandCtx, o); err != nil {
+				DisplayError(err)
+				os.Exit(1)
+			}
+			DisplayComposite(o)
+			return
+		}
+	} else {
+		if *market == "" || *qty == "" || *tp == "" || *stopPrice == "" || (bracket && *entry == "") {
+			fmt.Fprintln(os.Stderr, "error: -market, -quantity, -take-profit and -stop-price are required")
+			fs.Usage()
+			os.Exit(1)
+		}
+		var side model.OrderSide
+		switch strings.ToLower(*sideF) {
+		case "buy":
+			side = model.Buy
+		case "sell":
+			side = model.Sell
+		default:
+			fmt.Fprintln(os.Stderr, "error: invalid side, use buy or sell")
+			os.Exit(1)
+		}
+		mgr.Market = mustLookupMarket(ex, *market)
+		m := mgr.Market
+		req := composite.Order{
+			Kind:            kind,
+			Market:          m.Symbol,
+			Side:            side,
+			Quantity:        m.AlignQuantity(parseFloat(*qty)),
+			TakeProfitPrice: m.AlignPrice(parseFloat(*tp)),
+			StopPrice:       m.AlignPrice(parseFloat(*stopPrice)),
+		}
+		if *stopLimit != "" {
+			req.StopLimitPrice = m.AlignPrice(parseFloat(*stopLimit))
+		}
+		if bracket {
+			req.EntryPrice = m.AlignPrice(parseFloat(*entry))
+		}
+		if o, err = mgr.Submit(commandCtx, req); err != nil {
+			DisplayError(err)
+			os.Exit(1)
+		}
+		notef("%s %s submitted.\n", kind, o.ID)
+	}
+
+	ctx, stop := runContext()
+	defer stop()
+	if err := mgr.Run(ctx, o); err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	DisplayComposite(o)
+	if !o.Done() {
+		notef("\nStopped watching; run-triggers still fires the stop. Resize the exits again with: %s %s -resume %s\n",
+			os.Args[0], name, o.ID)
+	}
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"trading-bot/internal/infrastructure/keystore"
+)
+
+// runConfig handles the "config" sub-command.
+func runConfig(args []string) {
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s config [list|validate]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "list (the default) shows the profiles of the config file; validate checks")
+		fmt.Fprintln(fs.Output(), "every profile and exits with status 1 if any is unusable.")
+	}
+	fs.Parse(args)
+
+	if cfg == nil {
+		fmt.Fprintln(os.Stderr, "error: no config file; pass -config or create ~/.trading-bot/config.yaml")
+		os.Exit(1)
+	}
+	switch fs.Arg(0) {
+	case "", "list":
+		DisplayProfiles(cfg, profile)
+	case "validate":
+		if err := validateConfig(); err != nil {
+			DisplayError(err)
+			os.Exit(1)
+		}
+		notef("%d profile(s) OK\n", len(cfg.Profiles))
+	default:
+		fs.Usage()
+		os.Exit(1)
+	}
+}
+
+// validateConfig checks the config file and the parts of each profile
+// only the CLI knows about: the exchange adapter, the output format and
+// whether the keystore can supply credentials the profile lacks.
+func validateConfig() error {
+	errs := []error{cfg.Validate()}
+	for _, n := range cfg.Names() {
+		p := cfg.Profiles[n]
+		if _, ok := adapters[strings.ToLower(p.Exchange)]; p.Exchange != "" && !ok {
+			errs = append(errs, fmt.Errorf("config: profile %q: unknown exchange %q", n, p.Exchange))
+		}
+		if key, _ := p.Credentials(); key == "" && !keystore.Has(keystorePath(), n) {
+			errs = append(errs, fmt.Errorf("config: profile %q: no credentials in the file, the environment or the keystore", n))
+		}
+		if p.Output != "" {
+			if _, err := ParseFormat(p.Output); err != nil {
+				errs = append(errs, fmt.Errorf("config: profile %q: %w", n, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
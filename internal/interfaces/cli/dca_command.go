@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"trading-bot/internal/application/strategy"
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/infrastructure/metrics"
+)
+
+// runDCA handles the "dca" sub-command.
+func runDCA(args []string) {
+	fs := flag.NewFlagSet("dca", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	market := fs.String("market", defaultMarket(), "Market symbol (required), e.g. BTCBRL")
+	amount := fs.Float64("amount", 0, "Quote currency spent on every purchase (required)")
+	schedule := fs.String("schedule", "", `Cron schedule (required), e.g. "0 9 * * mon"`)
+	postOnly := fs.Bool("post-only", false, "Rest at the best bid instead of crossing at the best ask")
+	timeout := fs.Duration("timeout", 5*time.Minute, "How long an order may rest before it is cancelled")
+	chase := fs.Bool("chase", false, "Re-price the unfilled remainder after a timeout")
+	maxChases := fs.Int("max-chases", 5, "How many times a purchase may be re-priced")
+	interval := fs.Duration("interval", 10*time.Second, "How often the working order is polled")
+	stateDir := fs.String("state-dir", defaultStateDir(), "Directory where the purchase history is saved")
+	report := fs.Bool("report", false, "Print the purchase history and average cost, then exit")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s dca [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Options:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *market == "" || (!*report && (*amount <= 0 || *schedule == "")) {
+		fmt.Fprintln(os.Stderr, "error: -market, -amount and -schedule are required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	ex := mustInitExchange(*exch)
+	d := &strategy.DCA{
+		Place:  &usecase.PlaceOrder{Ex: ex},
+		Cancel: &usecase.CancelOrder{Ex: ex},
+		Get:    &usecase.GetOrder{Ex: ex},
+		Book:   &usecase.FetchOrderBook{Ex: ex},
+		List:   &usecase.ListActiveOrders{Ex: ex},
+		Trades: &usecase.FetchTrades{Ex: ex},
+		Store:  mustOpenStateStore(*stateDir),
+		Config: strategy.DCAConfig{
+			Market:    mustLookupMarket(ex, *market),
+			Amount:    *amount,
+			PostOnly:  *postOnly,
+			Timeout:   *timeout,
+			Chase:     *chase,
+			MaxChases: *maxChases,
+			Interval:  *interval,
+		},
+		OnUpdate: func(s strategy.DCAState) {
+			qty, cost := s.Totals()
+			metrics.StrategyPosition.WithLabelValues("dca", s.Market).Set(qty)
+			metrics.StrategyCost.WithLabelValues("dca", s.Market).Set(cost)
+		},
+		Rejected: orderRefused,
+	}
+
+	if *report {
+		if err := d.Load(); err != nil {
+			DisplayError(err)
+			os.Exit(1)
+		}
+		DisplayDCA(d.State())
+		return
+	}
+
+	sched, err := strategy.ParseSchedule(*schedule)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+	d.Config.Schedule = sched
+
+	ctx, stop := runContext()
+	defer stop()
+	if err := d.Run(ctx); err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	DisplayDCA(d.State())
+}
@@ -2,60 +2,287 @@ package cli
 
 import (
 	"fmt"
-	"os"
 	"strconv"
-	"text/tabwriter"
+	"strings"
 
+	"trading-bot/internal/application/algo"
+	"trading-bot/internal/application/composite"
+	"trading-bot/internal/application/journal"
+	"trading-bot/internal/application/killswitch"
+	"trading-bot/internal/application/portfolio"
+	"trading-bot/internal/application/strategy"
+	"trading-bot/internal/application/tax"
+	"trading-bot/internal/application/trigger"
+	"trading-bot/internal/application/usecase"
 	"trading-bot/internal/domain/model"
+	"trading-bot/internal/infrastructure/config"
+	"trading-bot/internal/infrastructure/keystore"
 )
 
 // DisplayMarkets prints a table of Market entries.
 func DisplayMarkets(markets []model.Market) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "SYMBOL\tPRICE_MIN\tPRICE_INCREMENT\tPRICE_PRECISION\tQUANTITY_MIN\tQUANTITY_INCREMENT\tQUANTITY_PRECISION")
+	t := &Table{Columns: []string{"SYMBOL", "PRICE_MIN", "PRICE_INCREMENT", "PRICE_PRECISION", "QUANTITY_MIN", "QUANTITY_INCREMENT", "QUANTITY_PRECISION"}}
 	for _, m := range markets {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%d\n",
-			m.Symbol, m.PriceMin, m.PriceIncrement, m.PricePrecision,
-			m.QuantityMin, m.QuantityIncrement, m.QuantityPrecision,
-		)
+		t.Add(m.Symbol, m.PriceMin, m.PriceIncrement, m.PricePrecision,
+			m.QuantityMin, m.QuantityIncrement, m.QuantityPrecision)
 	}
-	w.Flush()
+	render(t)
 }
 
 // DisplayOrderBook prints bids and asks in tabular form.
 func DisplayOrderBook(ob *model.OrderBook) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "SIDE\tPRICE\tQUANTITY")
+	t := &Table{Columns: []string{"SIDE", "PRICE", "QUANTITY"}}
 	for _, bid := range ob.Bids {
 		p, _ := strconv.ParseFloat(bid[0], 64)
 		q, _ := strconv.ParseFloat(bid[1], 64)
-		fmt.Fprintf(w, "BID\t%.2f\t%.8f\n", p, q)
+		t.Add("BID", fmt.Sprintf("%.2f", p), fmt.Sprintf("%.8f", q))
 	}
 	for _, ask := range ob.Asks {
 		p, _ := strconv.ParseFloat(ask[0], 64)
 		q, _ := strconv.ParseFloat(ask[1], 64)
-		fmt.Fprintf(w, "ASK\t%.2f\t%.8f\n", p, q)
+		t.Add("ASK", fmt.Sprintf("%.2f", p), fmt.Sprintf("%.8f", q))
 	}
-	w.Flush()
+	render(t)
 }
 
 // DisplayOrders prints a list of orders in tabular form.
 func DisplayOrders(orders []model.Order) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\tMARKET\tSIDE\tTYPE\tPRICE\tQUANTITY\tSTATE")
+	render(ordersTable(orders))
+}
+
+func ordersTable(orders []model.Order) *Table {
+	t := &Table{Columns: []string{"ID", "MARKET", "SIDE", "TYPE", "PRICE", "STOP_PRICE", "QUANTITY", "EXECUTED", "AVG_PRICE", "STATE"}}
 	for _, o := range orders {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-			o.ID, o.MarketSymbol, string(o.Side), string(o.Type),
-			o.Price, o.Quantity, o.State,
-		)
+		t.Add(o.ID, o.MarketSymbol, o.Side, o.Type,
+			o.Price, o.StopPrice, o.Quantity, o.QuantityExecuted, o.PriceAvg, o.State)
 	}
-	w.Flush()
+	return t
 }
 
 // DisplayCancel prints the result of a cancel operation in two columns.
 func DisplayCancel(orderID string) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ORDER_ID\tSTATUS")
-	fmt.Fprintf(w, "%s\t%s\n", orderID, "CANCELLED")
-	w.Flush()
+	t := &Table{Columns: []string{"ORDER_ID", "STATUS"}}
+	t.Add(orderID, "CANCELLED")
+	render(t)
+}
+
+// DisplayReplace prints the cancelled order and its replacement.
+func DisplayReplace(r *usecase.Replacement) {
+	t := ordersTable([]model.Order{*r.Old, *r.New})
+	t.Footer = fmt.Sprintf("Order %s replaced by %s", r.Old.ID, r.New.ID)
+	render(t)
+}
+
+// DisplayOrderResults prints the outcome of each item of a batch operation.
+func DisplayOrderResults(results []model.OrderResult) {
+	t := &Table{Columns: []string{"ID", "MARKET", "SIDE", "PRICE", "QUANTITY", "RESULT"}}
+	failed := 0
+	for _, r := range results {
+		result := "OK"
+		if r.Err != nil {
+			result = r.Err.Error()
+			failed++
+		}
+		o := r.Order
+		t.Add(o.ID, o.MarketSymbol, o.Side, o.Price, o.Quantity, result)
+	}
+	t.Footer = fmt.Sprintf("%d succeeded, %d failed", len(results)-failed, failed)
+	render(t)
+}
+
+// DisplayGrid prints every grid level followed by the realized profit.
+func DisplayGrid(s strategy.GridState) {
+	t := &Table{Columns: []string{"LEVEL", "PRICE", "SIDE", "ORDER_ID", "ENTRY_PRICE"}}
+	for i := len(s.Levels) - 1; i >= 0; i-- {
+		l := s.Levels[i]
+		t.Add(i, l.Price, l.Side, l.OrderID, l.EntryPrice)
+	}
+	t.Footer = fmt.Sprintf("Round trips: %d  Realized profit: %.8f", s.RoundTrips, s.RealizedProfit)
+	render(t)
+}
+
+// DisplayDCA prints the purchase history followed by the average cost.
+func DisplayDCA(s strategy.DCAState) {
+	t := &Table{Columns: []string{"SLOT", "STATUS", "QUANTITY", "COST", "AVG_PRICE", "ORDERS"}}
+	for _, b := range s.Buys {
+		avg := 0.0
+		if b.Quantity > 0 {
+			avg = b.Cost / b.Quantity
+		}
+		t.Add(b.Slot.Format("2006-01-02 15:04"), b.Status, fmt.Sprintf("%.8f", b.Quantity),
+			fmt.Sprintf("%.2f", b.Cost), fmt.Sprintf("%.2f", avg), len(b.OrderIDs))
+	}
+	qty, cost := s.Totals()
+	t.Footer = fmt.Sprintf("Total bought: %.8f  Total spent: %.2f  Average cost: %.2f", qty, cost, s.AverageCost())
+	render(t)
+}
+
+// DisplayMarketMaker prints the market maker counters in two columns.
+func DisplayMarketMaker(s strategy.MarketMakerStats) {
+	t := &Table{Columns: []string{"METRIC", "VALUE"}}
+	t.Add("INVENTORY", fmt.Sprintf("%.8f", s.Inventory))
+	t.Add("BOUGHT", fmt.Sprintf("%.8f", s.Bought))
+	t.Add("SOLD", fmt.Sprintf("%.8f", s.Sold))
+	t.Add("CASH_FLOW", fmt.Sprintf("%.2f", s.CashFlow))
+	t.Add("PNL", fmt.Sprintf("%.2f", s.PnL))
+	t.Add("QUOTES", s.Quotes)
+	t.Add("CANCELS", s.Cancels)
+	render(t)
+}
+
+// DisplayExecution prints the progress of an algorithmic parent order.
+func DisplayExecution(e *algo.Execution) {
+	t := &Table{Columns: []string{"ID", "ALGO", "MARKET", "SIDE", "STATUS", "SLICE", "FILLED", "QUANTITY", "PROGRESS", "AVG_PRICE"}}
+	t.Add(e.ID, e.Params.Kind, e.Params.Market, e.Params.Side, e.Status,
+		fmt.Sprintf("%d/%d", e.Next, len(e.Slices)), fmt.Sprintf("%.8f", e.Filled), fmt.Sprintf("%.8f", e.Params.Quantity),
+		fmt.Sprintf("%.1f%%", 100*e.Progress()), fmt.Sprintf("%.8f", e.AveragePrice()))
+	render(t)
+}
+
+// DisplayIceberg prints the progress of an iceberg order.
+func DisplayIceberg(ib *algo.Iceberg) {
+	t := &Table{Columns: []string{"ID", "MARKET", "SIDE", "PRICE", "STATUS", "FILLED", "QUANTITY", "CLIPS", "AVG_PRICE", "VISIBLE_ORDER"}}
+	t.Add(ib.ID, ib.Params.Market, ib.Params.Side, fmt.Sprintf("%.8f", ib.Params.Price), ib.Status,
+		fmt.Sprintf("%.8f", ib.Filled), fmt.Sprintf("%.8f", ib.Params.Quantity), len(ib.Clips),
+		fmt.Sprintf("%.8f", ib.AveragePrice()), ib.Clip)
+	render(t)
+}
+
+// DisplayTriggers prints locally held conditional orders in tabular form.
+func DisplayTriggers(ts []trigger.Trigger) {
+	t := &Table{Columns: []string{"ID", "MARKET", "SIDE", "TYPE", "STOP_PRICE", "LIMIT_PRICE", "TRAIL", "QUANTITY", "SOURCE", "STATUS", "ORDER_ID"}}
+	for _, tr := range ts {
+		trail := tr.TrailAmount
+		if tr.TrailPercent > 0 {
+			trail = strconv.FormatFloat(tr.TrailPercent, 'f', -1, 64) + "%"
+		}
+		t.Add(tr.ID, tr.Market, tr.Side, tr.Type, tr.StopPrice, tr.LimitPrice,
+			trail, tr.Quantity, tr.Source, tr.Status, tr.OrderID)
+	}
+	render(t)
+}
+
+// DisplayComposite prints an OCO or bracket order in tabular form.
+func DisplayComposite(o *composite.Order) {
+	t := &Table{Columns: []string{"ID", "MARKET", "SIDE", "QUANTITY", "ENTRY", "TAKE_PROFIT", "STOP", "STATUS", "ENTRY_FILLED", "TP_FILLED", "STOP_FILLED"}}
+	t.Add(o.ID, o.Market, o.Side, o.Quantity, o.EntryPrice, o.TakeProfitPrice, o.StopPrice, o.Status,
+		fmt.Sprintf("%.8f", o.EntryFilled), fmt.Sprintf("%.8f", o.TakeProfitFilled), fmt.Sprintf("%.8f", o.StopFilled))
+	render(t)
+}
+
+// DisplayPanic prints the orders disarmed, cancelled and sold by panic.
+func DisplayPanic(actions []killswitch.Action) {
+	t := &Table{Columns: []string{"ACTION", "MARKET", "ORDER_ID", "QUANTITY", "RESULT"}}
+	for _, a := range actions {
+		result := "OK"
+		if a.Err != nil {
+			result = a.Err.Error()
+		}
+		t.Add(a.Kind, a.Market, a.OrderID, a.Quantity, result)
+	}
+	render(t)
+}
+
+// DisplayPortfolio prints one row per asset followed by the totals.
+func DisplayPortfolio(r *portfolio.Report) {
+	t := &Table{Columns: []string{"ASSET", "QUANTITY", "AVG_COST", "MARK", "VALUE", "REALIZED", "UNREALIZED"}}
+	for _, p := range r.Positions {
+		t.Add(strings.ToUpper(p.Asset), fmt.Sprintf("%.8f", p.Quantity), fmt.Sprintf("%.2f", p.AverageCost),
+			fmt.Sprintf("%.2f", p.Mark), fmt.Sprintf("%.2f", p.Value), fmt.Sprintf("%.2f", p.Realized), fmt.Sprintf("%.2f", p.Unrealized))
+	}
+	t.Add("TOTAL", "", "", "", fmt.Sprintf("%.2f", r.Value), fmt.Sprintf("%.2f", r.Realized), fmt.Sprintf("%.2f", r.Unrealized))
+	t.Footer = fmt.Sprintf("Amounts in %s  Cost method: %s", strings.ToUpper(r.Currency), r.Method)
+	render(t)
+}
+
+// DisplayTaxMonths prints the monthly capital gains summary.
+func DisplayTaxMonths(months []tax.Month) {
+	t := &Table{Columns: []string{"MONTH", "SALES_BRL", "GAINS", "LOSSES", "NET", "EXEMPT", "TAX_DUE"}}
+	for _, m := range months {
+		t.Add(m.Month, fmt.Sprintf("%.2f", m.Sales), fmt.Sprintf("%.2f", m.Gains), fmt.Sprintf("%.2f", m.Losses),
+			fmt.Sprintf("%.2f", m.Net), m.Exempt, fmt.Sprintf("%.2f", m.Tax))
+	}
+	render(t)
+}
+
+// DisplayJournal prints order journal entries, oldest first.
+func DisplayJournal(entries []model.JournalEntry) {
+	t := &Table{Columns: []string{"TIME", "KIND", "ORDER_ID", "MARKET", "SIDE", "TYPE", "PRICE", "QUANTITY", "STATE", "FILLED", "ERROR"}}
+	for _, e := range entries {
+		var o model.Order
+		if e.Order != nil {
+			o = *e.Order
+		}
+		t.Add(e.Time.Local().Format("2006-01-02 15:04:05"), e.Kind, e.OrderID, e.Market,
+			o.Side, o.Type, o.Price, o.Quantity, o.State, e.Filled, e.Error)
+	}
+	render(t)
+}
+
+// DisplayDiscrepancies prints the differences found by the reconciler.
+func DisplayDiscrepancies(ds []journal.Discrepancy) {
+	t := &Table{
+		Columns: []string{"KIND", "ORDER_ID", "MARKET", "JOURNAL", "EXCHANGE", "ACTION", "ERROR"},
+		Empty:   "The journal agrees with the exchange.",
+	}
+	for _, d := range ds {
+		errText := ""
+		if d.Err != nil {
+			errText = d.Err.Error()
+		}
+		t.Add(d.Kind, d.OrderID, d.Market, describe(d.Local), describe(d.Remote), d.Action, errText)
+	}
+	render(t)
+}
+
+// describe summarises an order as its state and executed quantity.
+func describe(o *model.Order) string {
+	if o == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%s %s/%s", o.State, o.QuantityExecuted, o.Quantity)
+}
+
+// DisplayProfiles prints the profiles of the config file, marking the
+// selected one. Credentials are described, never shown.
+func DisplayProfiles(c *config.Config, selected *config.Profile) {
+	t := &Table{
+		Columns: []string{"PROFILE", "EXCHANGE", "MARKET", "OUTPUT", "CREDENTIALS", "SELECTED"},
+		Empty:   "No profiles defined.",
+	}
+	for _, n := range c.Names() {
+		p := c.Profiles[n]
+		mark := ""
+		if selected != nil && selected.Name == n {
+			mark = "*"
+		}
+		t.Add(n, p.Exchange, p.Market, p.Output, p.CredentialSource(), mark)
+	}
+	render(t)
+}
+
+// DisplayKeys prints the credentials of the keystore with the API key
+// masked and the secret left out.
+func DisplayKeys(k *keystore.Keystore) {
+	t := &Table{
+		Columns: []string{"PROFILE", "KEY", "ADDED", "ROTATED"},
+		Empty:   "The keystore is empty.",
+	}
+	for _, n := range k.Names() {
+		c, _ := k.Get(n)
+		rotated := "-"
+		if !c.RotatedAt.IsZero() {
+			rotated = c.RotatedAt.Local().Format("2006-01-02 15:04")
+		}
+		t.Add(n, mask(c.Key), c.AddedAt.Local().Format("2006-01-02 15:04"), rotated)
+	}
+	render(t)
+}
+
+// mask keeps the last four characters of s.
+func mask(s string) string {
+	if len(s) <= 4 {
+		return strings.Repeat("*", len(s))
+	}
+	return strings.Repeat("*", len(s)-4) + s[len(s)-4:]
 }
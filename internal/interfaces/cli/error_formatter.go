@@ -19,19 +19,28 @@ type apiError struct {
 
 // DisplayError prints any Foxbit‐style error in a single table:
 // TYPE | CODE | MESSAGE | DETAIL
+// With a machine-readable output format it prints the error as JSON
+// instead, in the shape of the Foxbit payload.
 func DisplayError(err error) {
 	raw := err.Error()
+	var e apiError
 	// find the JSON blob
 	idx := strings.Index(raw, "{")
-	if idx < 0 {
-		fmt.Fprintln(os.Stderr, raw)
+	parsed := idx >= 0 && json.Unmarshal([]byte(raw[idx:]), &e) == nil
+
+	if output != FormatTable {
+		if !parsed || e.Error.Message == "" {
+			e = apiError{}
+			e.Error.Message = raw
+		}
+		if e.Error.Details == nil {
+			e.Error.Details = []string{}
+		}
+		json.NewEncoder(os.Stderr).Encode(e)
 		return
 	}
-
-	blob := raw[idx:]
-	var e apiError
-	if jsonErr := json.Unmarshal([]byte(blob), &e); jsonErr != nil {
-		// malformed JSON: fallback to raw
+	if !parsed {
+		// no or malformed JSON: fallback to raw
 		fmt.Fprintln(os.Stderr, raw)
 		return
 	}
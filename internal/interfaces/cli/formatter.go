@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the output format selected with the global -output flag.
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatJSON  Format = "json"
+	FormatCSV   Format = "csv"
+	FormatYAML  Format = "yaml"
+)
+
+// ParseFormat converts an -output flag value to a Format.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatTable, FormatJSON, FormatCSV, FormatYAML:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown output format %q, use table, json, csv or yaml", s)
+}
+
+// output is the format set by the -output flag.
+var output = FormatTable
+
+// Table is what a command prints: named columns and rows of cells.
+//
+// Column names are part of the CLI's contract: tables show them in upper
+// case, and the other formats use them in lower case as field names, so
+// they must not change between releases. Every format prints the same
+// rows; Footer and Empty are human notes printed by the table format only.
+type Table struct {
+	Columns []string
+	Rows    [][]string
+	Footer  string // printed after the rows
+	Empty   string // printed instead of the table when there are no rows
+}
+
+// Add appends a row; cells are formatted with fmt.Sprint.
+func (t *Table) Add(cells ...any) {
+	row := make([]string, len(cells))
+	for i, c := range cells {
+		row[i] = fmt.Sprint(c)
+	}
+	t.Rows = append(t.Rows, row)
+}
+
+// fields returns the column names used as field names.
+func (t *Table) fields() []string {
+	out := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		out[i] = strings.ToLower(c)
+	}
+	return out
+}
+
+// Formatter writes a Table in one output format.
+type Formatter interface {
+	Format(w io.Writer, t *Table) error
+}
+
+// formatter returns the Formatter of f.
+func formatter(f Format) Formatter {
+	switch f {
+	case FormatJSON:
+		return jsonFormatter{}
+	case FormatCSV:
+		return csvFormatter{}
+	case FormatYAML:
+		return yamlFormatter{}
+	default:
+		return tableFormatter{}
+	}
+}
+
+// render prints t to stdout in the selected output format.
+func render(t *Table) {
+	if err := formatter(output).Format(os.Stdout, t); err != nil {
+		fmt.Fprintf(os.Stderr, "error: failed to write output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// notef prints a human message. It goes to stdout with the table format
+// and to stderr otherwise, so that stdout stays machine-readable.
+func notef(format string, args ...any) {
+	w := os.Stdout
+	if output != FormatTable {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, format, args...)
+}
+
+type tableFormatter struct{}
+
+func (tableFormatter) Format(w io.Writer, t *Table) error {
+	if len(t.Rows) == 0 && t.Empty != "" {
+		_, err := fmt.Fprintln(w, t.Empty)
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.Columns, "\t"))
+	for _, r := range t.Rows {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if t.Footer != "" {
+		_, err := fmt.Fprintf(w, "\n%s\n", t.Footer)
+		return err
+	}
+	return nil
+}
+
+// jsonFormatter writes an array of objects whose fields keep the column
+// order.
+type jsonFormatter struct{}
+
+func (jsonFormatter) Format(w io.Writer, t *Table) error {
+	fields := t.fields()
+	var b strings.Builder
+	b.WriteString("[")
+	for i, r := range t.Rows {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for j, f := range fields {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			k, _ := json.Marshal(f)
+			v, _ := json.Marshal(r[j])
+			b.Write(k)
+			b.WriteString(": ")
+			b.Write(v)
+		}
+		b.WriteString("}")
+	}
+	if len(t.Rows) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type csvFormatter struct{}
+
+func (csvFormatter) Format(w io.Writer, t *Table) error {
+	cw := csv.NewWriter(w)
+	cw.Write(t.fields())
+	cw.WriteAll(t.Rows)
+	return cw.Error()
+}
+
+// yamlFormatter writes a sequence of mappings whose keys keep the column
+// order.
+type yamlFormatter struct{}
+
+func (yamlFormatter) Format(w io.Writer, t *Table) error {
+	fields := t.fields()
+	seq := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, r := range t.Rows {
+		m := &yaml.Node{Kind: yaml.MappingNode}
+		for j, f := range fields {
+			m.Content = append(m.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: f},
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: r[j]},
+			)
+		}
+		seq.Content = append(seq.Content, m)
+	}
+	if len(seq.Content) == 0 {
+		seq.Style = yaml.FlowStyle
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(seq); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"trading-bot/internal/application/strategy"
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/infrastructure/metrics"
+)
+
+// runGrid handles the "grid" sub-command.
+func runGrid(args []string) {
+	fs := flag.NewFlagSet("grid", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	market := fs.String("market", defaultMarket(), "Market symbol (required), e.g. BTCBRL")
+	lower := fs.Float64("lower", 0, "Lowest grid price (required)")
+	upper := fs.Float64("upper", 0, "Highest grid price (required)")
+	levels := fs.Int("levels", 10, "Number of price levels, bounds included")
+	qty := fs.String("quantity", "", "Quantity of every grid order (required)")
+	spacing := fs.String("spacing", "arithmetic", "Level spacing: arithmetic|geometric")
+	interval := fs.Duration("interval", 10*time.Second, "How often resting orders are polled")
+	stateDir := fs.String("state-dir", defaultStateDir(), "Directory where the grid state is saved")
+	teardown := fs.Bool("teardown", false, "Cancel every grid order, forget the saved state and exit")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s grid [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Options:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *market == "" || (!*teardown && (*qty == "" || *lower == 0 || *upper == 0)) {
+		fmt.Fprintln(os.Stderr, "error: -market, -lower, -upper and -quantity are required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	ex := mustInitExchange(*exch)
+	g := &strategy.Grid{
+		Place:  &usecase.PlaceOrder{Ex: ex},
+		Cancel: &usecase.CancelOrder{Ex: ex},
+		Get:    &usecase.GetOrder{Ex: ex},
+		Book:   &usecase.FetchOrderBook{Ex: ex},
+		Store:  mustOpenStateStore(*stateDir),
+		Config: strategy.GridConfig{
+			Market:   mustLookupMarket(ex, *market),
+			Lower:    *lower,
+			Upper:    *upper,
+			Levels:   *levels,
+			Quantity: *qty,
+			Spacing:  strategy.GridSpacing(strings.ToUpper(*spacing)),
+			Interval: *interval,
+		},
+		OnUpdate: func(s strategy.GridState) {
+			metrics.StrategyPnL.WithLabelValues("grid", s.Market).Set(s.RealizedProfit)
+		},
+	}
+
+	if *teardown {
+		if err := g.Teardown(commandCtx); err != nil {
+			DisplayError(err)
+			os.Exit(1)
+		}
+		notef("Grid orders cancelled and state removed.\n")
+		return
+	}
+
+	ctx, stop := runContext()
+	defer stop()
+	if err := g.Run(ctx); err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	DisplayGrid(g.State())
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"trading-bot/internal/application/algo"
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+)
+
+// runIceberg handles the "iceberg" sub-command.
+func runIceberg(args []string) {
+	fs := flag.NewFlagSet("iceberg", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	market := fs.String("market", defaultMarket(), "Market symbol, e.g. BTCBRL")
+	sideF := fs.String("side", "buy", "Order side: buy|sell")
+	price := fs.Float64("price", 0, "Limit price of every clip")
+	qty := fs.Float64("quantity", 0, "Total quantity, visible plus hidden")
+	clipMin := fs.Float64("clip-min", 0, "Smallest visible clip")
+	clipMax := fs.Float64("clip-max", 0, "Largest visible clip (defaults to -clip-min)")
+	postOnly := fs.Bool("post-only", true, "Reject clips that would take liquidity")
+	interval := fs.Duration("interval", 5*time.Second, "How often the visible clip is polled")
+	stateDir := fs.String("state-dir", defaultStateDir(), "Directory where icebergs are saved")
+	resume := fs.String("resume", "", "Resume a paused iceberg by ID")
+	status := fs.String("status", "", "Show the progress of an iceberg by ID and exit")
+	abort := fs.String("cancel", "", "Cancel an iceberg by ID and exit")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s iceberg [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Press Ctrl+C to pause; resume later with -resume ID.")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	ex := mustInitExchange(*exch)
+	x := &algo.Executor{
+		Place:    &usecase.PlaceOrder{Ex: ex},
+		Cancel:   &usecase.CancelOrder{Ex: ex},
+		Get:      &usecase.GetOrder{Ex: ex},
+		Book:     &usecase.FetchOrderBook{Ex: ex},
+		Candles:  &usecase.FetchCandles{Ex: ex},
+		Store:    mustOpenStateStore(*stateDir),
+		Interval: *interval,
+	}
+
+	id := *resume
+	if *status != "" {
+		id = *status
+	} else if *abort != "" {
+		id = *abort
+	}
+
+	var ib *algo.Iceberg
+	var err error
+	if id != "" {
+		if ib, err = x.LoadIceberg(id); err != nil {
+			DisplayError(err)
+			os.Exit(1)
+		}
+		if *status != "" {
+			DisplayIceberg(ib)
+			return
+		}
+		x.Market = mustLookupMarket(ex, ib.Params.Market)
+		if *abort != "" {
+			if err := x.AbortIceberg(commandCtx, ib); err != nil {
+				DisplayError(err)
+				os.Exit(1)
+			}
+			DisplayIceberg(ib)
+			return
+		}
+	} else {
+		if *market == "" || *qty <= 0 || *price <= 0 || *clipMin <= 0 {
+			fmt.Fprintln(os.Stderr, "error: -market, -price, -quantity and -clip-min are required")
+			fs.Usage()
+			os.Exit(1)
+		}
+		var side model.OrderSide
+		switch strings.ToLower(*sideF) {
+		case "buy":
+			side = model.Buy
+		case "sell":
+			side = model.Sell
+		default:
+			fmt.Fprintln(os.Stderr, "error: invalid side, use buy or sell")
+			os.Exit(1)
+		}
+		if *clipMax == 0 {
+			*clipMax = *clipMin
+		}
+		x.Market = mustLookupMarket(ex, *market)
+		ib, err = x.PlanIceberg(algo.IcebergParams{
+			Market:   x.Market.Symbol,
+			Side:     side,
+			Price:    *price,
+			Quantity: *qty,
+			ClipMin:  *clipMin,
+			ClipMax:  *clipMax,
+			PostOnly: *postOnly,
+		})
+		if err != nil {
+			DisplayError(err)
+			os.Exit(1)
+		}
+		notef("Iceberg %s started.\n", ib.ID)
+	}
+
+	ctx, stop := runContext()
+	defer stop()
+	if err := x.RunIceberg(ctx, ib); err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	DisplayIceberg(ib)
+	if ib.Status == algo.Paused {
+		notef("\nPaused; resume with: %s iceberg -resume %s\n", os.Args[0], ib.ID)
+	}
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"trading-bot/internal/application/journal"
+	"trading-bot/internal/domain/model"
+)
+
+// runOrderHistory handles the "order-history" sub-command.
+func runOrderHistory(args []string) {
+	fs := flag.NewFlagSet("order-history", flag.ExitOnError)
+	market := fs.String("market", "", "Only events of this market")
+	orderID := fs.String("order-id", "", "Only events of this order")
+	kinds := fs.String("kind", "", "Comma-separated event kinds: request,accepted,rejected,cancel,state,fill")
+	since := fs.String("since", "", "Only events from this date (YYYY-MM-DD)")
+	limit := fs.Int("limit", 50, "Show only the newest N events (0 = all)")
+	open := fs.Bool("open", false, "List the orders the journal last saw open instead")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s order-history [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Options:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	j := mustOpenJournal()
+	if *open {
+		orders, err := j.Open(strings.ToUpper(*market))
+		if err != nil {
+			DisplayError(err)
+			os.Exit(1)
+		}
+		DisplayOrders(orders)
+		return
+	}
+
+	f := model.JournalFilter{OrderID: *orderID, Market: *market, Limit: *limit}
+	if *since != "" {
+		t, err := time.ParseInLocation("2006-01-02", *since, time.Local)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error: -since must be a date like 2025-01-31")
+			os.Exit(1)
+		}
+		f.Since = t
+	}
+	if *kinds != "" {
+		for _, k := range strings.Split(*kinds, ",") {
+			f.Kinds = append(f.Kinds, model.JournalKind(strings.ToUpper(strings.TrimSpace(k))))
+		}
+	}
+	entries, err := j.Query(f)
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	DisplayJournal(entries)
+}
+
+// runReconcile handles the "reconcile" sub-command.
+func runReconcile(args []string) {
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	market := fs.String("market", "", "Market symbol (default: all markets)")
+	orphans := fs.String("orphans", string(journal.DefaultPolicy.Orphans), "Orders active on the exchange but not in the journal: report|repair|cancel")
+	drift := fs.String("drift", string(journal.DefaultPolicy.Drift), "Orders filled or closed behind the journal's back: report|repair")
+	missing := fs.String("missing", string(journal.DefaultPolicy.Missing), "Journaled orders the exchange does not know: report|repair")
+	grace := fs.Duration("orphan-grace", time.Minute, "Leave alone orders unknown to the journal that are younger than this")
+	interval := fs.Duration("interval", 0, "Reconcile again at this interval until interrupted (0 = once)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s reconcile [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Options:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var p journal.Policy
+	var err error
+	for _, f := range []struct {
+		dst *journal.Action
+		val string
+	}{{&p.Orphans, *orphans}, {&p.Drift, *drift}, {&p.Missing, *missing}} {
+		if *f.dst, err = journal.ParseAction(f.val); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = p.Validate()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	r := &journal.Reconciler{
+		Ex:          mustInitAdapter(*exch),
+		Journal:     mustOpenJournal(),
+		Policy:      p,
+		OrphanGrace: *grace,
+	}
+	symbol := strings.ToUpper(*market)
+	if *interval > 0 {
+		r.OnDiscrepancy = func(d journal.Discrepancy) {
+			attrs := []any{"kind", d.Kind, "order", d.OrderID, "market", d.Market,
+				"journal", describe(d.Local), "exchange", describe(d.Remote), "action", d.Action}
+			if d.Err != nil {
+				attrs = append(attrs, "err", d.Err)
+			}
+			slog.Warn("reconcile: discrepancy", attrs...)
+		}
+		ctx, stop := runContext()
+		defer stop()
+		if err := r.Run(ctx, symbol, *interval); err != nil {
+			DisplayError(err)
+			os.Exit(1)
+		}
+		return
+	}
+	ds, err := r.Reconcile(commandCtx, symbol)
+	DisplayDiscrepancies(ds)
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/term"
+
+	"trading-bot/internal/infrastructure/keystore"
+	"trading-bot/internal/infrastructure/logging"
+)
+
+// passphraseEnv lets scripts unlock the keystore without a terminal. It
+// is less safe than the prompt or the agent and meant for automation.
+const passphraseEnv = "TRADING_BOT_PASSPHRASE"
+
+// runKeys handles the "keys" sub-command.
+func runKeys(args []string) {
+	if len(args) == 0 {
+		keysUsage()
+		os.Exit(1)
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("keys "+sub, flag.ExitOnError)
+	name := fs.String("profile", keystoreEntry(), "Profile the credentials belong to")
+	ttl := fs.Duration("ttl", time.Hour, "How long the agent keeps the keystore unlocked (unlock)")
+	passphrase := fs.Bool("passphrase", false, "Change the keystore passphrase instead of a profile's key (rotate)")
+	fs.Usage = keysUsage
+	fs.Parse(args[1:])
+
+	switch sub {
+	case "add":
+		ks := mustOpenOrCreateKeystore()
+		if _, ok := ks.Get(*name); ok {
+			fatalf("Credentials for %q already exist; use keys rotate to replace them", *name)
+		}
+		c := readCredentials()
+		c.AddedAt = time.Now()
+		ks.Put(*name, c)
+		mustSaveKeystore(ks)
+		notef("Credentials for %q added.\n", *name)
+
+	case "list":
+		ks := mustUnlockKeystore()
+		DisplayKeys(ks)
+
+	case "remove":
+		ks := mustUnlockKeystore()
+		if !ks.Delete(*name) {
+			fatalf("No credentials for %q", *name)
+		}
+		mustSaveKeystore(ks)
+		notef("Credentials for %q removed.\n", *name)
+
+	case "rotate":
+		ks := mustUnlockKeystore()
+		if *passphrase {
+			if err := ks.ChangePassphrase(readPassphrase(true)); err != nil {
+				DisplayError(err)
+				os.Exit(1)
+			}
+			notef("Passphrase changed.\n")
+			return
+		}
+		c, ok := ks.Get(*name)
+		if !ok {
+			fatalf("No credentials for %q; use keys add", *name)
+		}
+		next := readCredentials()
+		next.AddedAt, next.RotatedAt = c.AddedAt, time.Now()
+		ks.Put(*name, next)
+		mustSaveKeystore(ks)
+		notef("Credentials for %q rotated; revoke the old key on the exchange.\n", *name)
+
+	case "unlock":
+		ks := mustUnlockKeystore()
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		ctx, cancel := context.WithTimeout(ctx, *ttl)
+		defer cancel()
+		notef("Keystore unlocked until %s; press Ctrl+C to lock it.\n", time.Now().Add(*ttl).Format("15:04:05"))
+		if err := keystore.Serve(ctx, agentSocket(), ks); err != nil {
+			DisplayError(err)
+			os.Exit(1)
+		}
+		notef("Keystore locked.\n")
+
+	default:
+		keysUsage()
+		os.Exit(1)
+	}
+}
+
+func keysUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s keys <add|list|remove|rotate|unlock> [options]\n\n", os.Args[0])
+	fmt.Fprintln(os.Stderr, "  add      Store the API key and secret of a profile (prompted)")
+	fmt.Fprintln(os.Stderr, "  list     List the stored credentials, keys masked")
+	fmt.Fprintln(os.Stderr, "  remove   Delete the credentials of a profile")
+	fmt.Fprintln(os.Stderr, "  rotate   Replace the credentials of a profile, or the passphrase with -passphrase")
+	fmt.Fprintln(os.Stderr, "  unlock   Keep the keystore unlocked for other commands until -ttl expires")
+	fmt.Fprintln(os.Stderr, "\nOptions:")
+	fmt.Fprintln(os.Stderr, "  -profile NAME   Profile the credentials belong to (default: the selected profile)")
+	fmt.Fprintln(os.Stderr, "  -ttl DURATION   unlock only (default 1h)")
+	fmt.Fprintln(os.Stderr, "  -passphrase     rotate only")
+}
+
+// keystoreCredentials returns the credentials of the selected profile
+// from the keystore agent or, failing that, from the keystore itself,
+// asking for the passphrase. It reports false when the keystore holds
+// none.
+func keystoreCredentials() (keystore.Credentials, bool) {
+	path, name := keystorePath(), keystoreEntry()
+	if !keystore.Has(path, name) {
+		return keystore.Credentials{}, false
+	}
+	if c, err := keystore.FromAgent(agentSocket(), name); err == nil {
+		return c, true
+	}
+	return mustUnlockKeystore().Get(name)
+}
+
+// keystoreEntry returns the keystore name of the selected profile.
+func keystoreEntry() string {
+	if profile == nil {
+		return keystore.DefaultEntry
+	}
+	return profile.Name
+}
+
+// keystorePath returns ~/.trading-bot/keystore.json.
+func keystorePath() string {
+	return appPath("keystore.json")
+}
+
+// agentSocket returns ~/.trading-bot/agent.sock.
+func agentSocket() string {
+	return appPath("agent.sock")
+}
+
+func mustUnlockKeystore() *keystore.Keystore {
+	if !keystore.Exists(keystorePath()) {
+		fatalf("No keystore; create it with keys add")
+	}
+	ks, err := keystore.Open(keystorePath(), readPassphrase(false))
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	return ks
+}
+
+func mustOpenOrCreateKeystore() *keystore.Keystore {
+	if keystore.Exists(keystorePath()) {
+		return mustUnlockKeystore()
+	}
+	notef("Creating %s\n", keystorePath())
+	ks, err := keystore.Create(keystorePath(), readPassphrase(true))
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	return ks
+}
+
+func mustSaveKeystore(ks *keystore.Keystore) {
+	if err := ks.Save(); err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+}
+
+// readPassphrase returns $TRADING_BOT_PASSPHRASE or prompts for the
+// passphrase, twice when confirm is set.
+func readPassphrase(confirm bool) []byte {
+	if p := os.Getenv(passphraseEnv); p != "" {
+		logging.Secret(p)
+		return []byte(p)
+	}
+	p := readSecret("Keystore passphrase: ")
+	if confirm && !bytes.Equal(p, readSecret("Repeat passphrase: ")) {
+		fatalf("Passphrases do not match")
+	}
+	return p
+}
+
+// readCredentials prompts for an API key pair.
+func readCredentials() keystore.Credentials {
+	c := keystore.Credentials{
+		Key:    strings.TrimSpace(string(readSecret("API key: "))),
+		Secret: strings.TrimSpace(string(readSecret("API secret: "))),
+	}
+	if c.Key == "" || c.Secret == "" {
+		fatalf("Both the API key and the secret are required")
+	}
+	return c
+}
+
+var stdin = bufio.NewReader(os.Stdin)
+
+// readSecret prompts on stderr and reads a line without echo from the
+// terminal, or a plain line when stdin is not a terminal.
+func readSecret(prompt string) []byte {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			fatalf("Failed to read input: %v", err)
+		}
+		logging.Secret(strings.TrimSpace(string(b)))
+		return b
+	}
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		fatalf("Failed to read input: %v", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+	logging.Secret(strings.TrimSpace(line))
+	return []byte(line)
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"trading-bot/internal/application/strategy"
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/infrastructure/metrics"
+)
+
+// runMarketMaker handles the "market-maker" sub-command.
+func runMarketMaker(args []string) {
+	fs := flag.NewFlagSet("market-maker", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	market := fs.String("market", defaultMarket(), "Market symbol (required), e.g. BTCBRL")
+	spread := fs.Float64("spread", 0.004, "Distance between bid and ask as a fraction of mid")
+	size := fs.String("size", "", "Quantity of each quote (required)")
+	threshold := fs.Float64("threshold", 0.0005, "Re-quote only when the target moves more than this fraction")
+	inventory := fs.Float64("inventory", 0, "Base inventory held when the strategy starts")
+	maxLong := fs.Float64("max-long", 0, "Stop bidding once inventory reaches this (0 = no limit)")
+	maxShort := fs.Float64("max-short", 0, "Stop offering once inventory falls to minus this (0 = no limit)")
+	skew := fs.Float64("skew", 0.002, "Fraction of mid the quotes shift when inventory is at a limit")
+	interval := fs.Duration("interval", 5*time.Second, "How often the book and the quotes are refreshed")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s market-maker [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Options:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *market == "" || *size == "" {
+		fmt.Fprintln(os.Stderr, "error: -market and -size are required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	ex := mustInitExchange(*exch)
+	mm := &strategy.MarketMaker{
+		Place:  &usecase.PlaceOrder{Ex: ex},
+		Cancel: &usecase.CancelOrder{Ex: ex},
+		Get:    &usecase.GetOrder{Ex: ex},
+		List:   &usecase.ListActiveOrders{Ex: ex},
+		Book:   &usecase.FetchOrderBook{Ex: ex},
+		Config: strategy.MarketMakerConfig{
+			Market:    mustLookupMarket(ex, *market),
+			Spread:    *spread,
+			Size:      *size,
+			Threshold: *threshold,
+			Inventory: *inventory,
+			MaxLong:   *maxLong,
+			MaxShort:  *maxShort,
+			Skew:      *skew,
+			Interval:  *interval,
+		},
+	}
+	mm.OnUpdate = func(s strategy.MarketMakerStats) {
+		m := mm.Config.Market.Symbol
+		metrics.StrategyPnL.WithLabelValues("market_maker", m).Set(s.PnL)
+		metrics.StrategyPosition.WithLabelValues("market_maker", m).Set(s.Bought - s.Sold)
+		metrics.StrategyCost.WithLabelValues("market_maker", m).Set(-s.CashFlow)
+	}
+
+	ctx, stop := runContext()
+	defer stop()
+	if err := mm.Run(ctx); err != nil {
+		DisplayError(err)
+		DisplayMarketMaker(mm.Stats())
+		os.Exit(1)
+	}
+	DisplayMarketMaker(mm.Stats())
+}
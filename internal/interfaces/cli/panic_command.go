@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"trading-bot/internal/application/composite"
+	"trading-bot/internal/application/killswitch"
+)
+
+// runPanic handles the "panic" sub-command.
+func runPanic(args []string) {
+	fs := flag.NewFlagSet("panic", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	flatten := fs.String("flatten-to", "", "Market-sell every available balance into this currency, e.g. brl")
+	reason := fs.String("reason", "manual panic", "Why trading was halted")
+	stateDir := fs.String("state-dir", defaultStateDir(), "Directory where triggers and composite orders are saved")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s panic [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Halts every running strategy, disarms the saved triggers and composite orders,")
+		fmt.Fprintln(fs.Output(), "cancels all active orders and refuses new ones until rearm.")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	ex := mustInitRawExchange(*exch)
+	var local []killswitch.Disarmer
+	if stateDirExists(*stateDir) {
+		// triggers first: the composites' stops are triggers too
+		triggers := newTriggerEngine(ex, *stateDir, 0)
+		local = append(local, triggers, &composite.Manager{Triggers: triggers, Store: triggers.Store})
+	}
+	actions, err := killswitch.Panic(commandCtx, ex, killSwitch(), *reason, *flatten, local...)
+	DisplayPanic(actions)
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	for _, a := range actions {
+		if a.Err != nil {
+			os.Exit(1)
+		}
+	}
+}
+
+// runRearm handles the "rearm" sub-command.
+func runRearm(args []string) {
+	fs := flag.NewFlagSet("rearm", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s rearm\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Releases the kill switch engaged by panic so orders can be sent again.")
+	}
+	fs.Parse(args)
+
+	sw := killSwitch()
+	st := sw.State()
+	if st == nil {
+		notef("Kill switch is not engaged.\n")
+		return
+	}
+	if err := sw.Rearm(); err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	notef("Kill switch engaged at %s (%s) released.\n", st.EngagedAt.Format(time.RFC3339), st.Reason)
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"trading-bot/internal/application/portfolio"
+	"trading-bot/internal/application/usecase"
+)
+
+// runPortfolio handles the "portfolio" sub-command.
+func runPortfolio(args []string) {
+	fs := flag.NewFlagSet("portfolio", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	methodF := fs.String("method", "fifo", "Cost method for realized PnL: fifo|average")
+	since := fs.String("since", time.Now().AddDate(-1, 0, 0).Format("2006-01-02"), "Download trades from this date (YYYY-MM-DD) on the first run")
+	currency := fs.String("currency", "brl", "Currency everything is valued in")
+	stateDir := fs.String("state-dir", defaultStateDir(), "Directory where the trade history is saved")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s portfolio [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Options:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	method, err := portfolio.ParseMethod(*methodF)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	start, err := time.ParseInLocation("2006-01-02", *since, time.Local)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error: -since must be a date like 2025-01-31")
+		os.Exit(1)
+	}
+
+	ex := mustInitExchange(*exch)
+	t := &portfolio.Tracker{
+		History: &portfolio.History{
+			Trades: &usecase.FetchTrades{Ex: ex},
+			Store:  mustOpenStateStore(*stateDir),
+		},
+		Balances: &usecase.FetchBalances{Ex: ex},
+		Markets:  &usecase.FetchMarkets{Ex: ex},
+		Book:     &usecase.FetchOrderBook{Ex: ex},
+		Currency: *currency,
+	}
+	r, err := t.Report(commandCtx, method, start)
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	DisplayPortfolio(r)
+}
@@ -1,17 +1,35 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"trading-bot/internal/application/composite"
+	"trading-bot/internal/application/journal"
+	"trading-bot/internal/application/killswitch"
+	"trading-bot/internal/application/risk"
 	"trading-bot/internal/application/usecase"
 	"trading-bot/internal/domain/model"
 	"trading-bot/internal/domain/service"
+	"trading-bot/internal/infrastructure/config"
 	"trading-bot/internal/infrastructure/exchange/foxbit"
+	"trading-bot/internal/infrastructure/httputil"
+	"trading-bot/internal/infrastructure/logging"
+	"trading-bot/internal/infrastructure/metrics"
+	"trading-bot/internal/infrastructure/storage/boltjournal"
+	"trading-bot/internal/infrastructure/storage/filestore"
 )
 
 // GOOS represents the operating system on which the program is running.
@@ -29,6 +47,11 @@ var CODEBUILDDATE string
 // CODEBUILDREVISION represents the revision of the code build.
 var CODEBUILDREVISION string
 
+// commandCtx is the context of the command being run. It carries a
+// request ID, so every exchange request the command makes is logged with
+// the same one.
+var commandCtx = context.Background()
+
 // ExecuteCLI is the entry point for the command-line interface.
 // It first parses any global flags (-info / -license), then
 // dispatches on the sub-command.
@@ -36,8 +59,37 @@ func ExecuteCLI() {
 	// --- GLOBAL FLAGS ---
 	infoFlag := flag.Bool("info", false, "Display program compilation and version information")
 	licenseFlag := flag.Bool("license", false, "Display program license information")
+	flag.StringVar(&riskFile, "risk-limits", defaultRiskFile(), "JSON file with the pre-trade risk limits")
+	outputFlag := flag.String("output", string(FormatTable), "Output format: table|json|csv|yaml")
+	configFlag := flag.String("config", appPath("config.yaml"), "YAML file with the account profiles")
+	profileFlag := flag.String("profile", "", "Profile to use (default: $"+config.ProfileEnv+", then the file's default_profile)")
+	metricsFlag := flag.String("metrics-addr", "", "Serve Prometheus metrics on this address under /metrics, e.g. 127.0.0.1:9100")
+	logLevelFlag := flag.String("log-level", "info", "Log level: debug|info|warn|error")
+	logFormatFlag := flag.String("log-format", string(logging.Text), "Log format: text|json")
 	flag.Parse()
 
+	mustLoadProfile(*configFlag, *profileFlag)
+	if !flagSet("output") && profile != nil && profile.Output != "" {
+		*outputFlag = profile.Output
+	}
+	if !flagSet("log-level") && profile != nil && profile.LogLevel != "" {
+		*logLevelFlag = profile.LogLevel
+	}
+	if !flagSet("log-format") && profile != nil && profile.LogFormat != "" {
+		*logFormatFlag = profile.LogFormat
+	}
+	if err := logging.Setup(os.Stderr, logging.Options{Level: *logLevelFlag, Format: logging.Format(*logFormatFlag)}); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	commandCtx = logging.WithRequestID(context.Background(), logging.NewRequestID())
+	f, err := ParseFormat(*outputFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	output = f
+
 	if *infoFlag {
 		fmt.Printf("Version: %s\n", CODEVERSION)
 		fmt.Printf("Operating System: %s\n", runtime.GOOS)
@@ -61,6 +113,14 @@ func ExecuteCLI() {
 		return
 	}
 
+	if *metricsFlag != "" {
+		go func() {
+			if err := metrics.Serve(context.Background(), *metricsFlag); err != nil {
+				slog.Error("metrics: server failed", "err", err)
+			}
+		}()
+	}
+
 	// now handle subcommands
 	args := flag.Args()
 	if len(args) < 1 {
@@ -76,7 +136,7 @@ func ExecuteCLI() {
 
 	case "fetch-markets":
 		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
-		exch := fs.String("exchange", "foxbit", "Exchange adapter: foxbit|binance|coinbase")
+		exch := fs.String("exchange", defaultExchange(), "Exchange adapter: foxbit|binance|coinbase")
 		fs.Usage = func() {
 			fmt.Fprintf(fs.Output(), "Usage: %s fetch-markets [options]\n\n", os.Args[0])
 			fmt.Fprintln(fs.Output(), "Options:")
@@ -85,7 +145,7 @@ func ExecuteCLI() {
 		fs.Parse(args[1:])
 
 		ex := mustInitExchange(*exch)
-		mkts, err := (&usecase.FetchMarkets{Ex: ex}).Execute()
+		mkts, err := (&usecase.FetchMarkets{Ex: ex}).Execute(commandCtx)
 		if err != nil {
 			DisplayError(err)
 			os.Exit(1)
@@ -94,8 +154,8 @@ func ExecuteCLI() {
 
 	case "fetch-order-book":
 		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
-		exch := fs.String("exchange", "foxbit", "Exchange adapter: foxbit|binance|coinbase")
-		market := fs.String("market", "", "Market symbol (required), e.g. BTCBRL")
+		exch := fs.String("exchange", defaultExchange(), "Exchange adapter: foxbit|binance|coinbase")
+		market := fs.String("market", defaultMarket(), "Market symbol (required), e.g. BTCBRL")
 		depth := fs.Int("depth", 10, "Order book depth")
 		fs.Usage = func() {
 			fmt.Fprintf(fs.Output(), "Usage: %s fetch-order-book [options]\n\n", os.Args[0])
@@ -110,7 +170,7 @@ func ExecuteCLI() {
 			os.Exit(1)
 		}
 		ex := mustInitExchange(*exch)
-		ob, err := (&usecase.FetchOrderBook{Ex: ex}).Execute(strings.ToUpper(*market), *depth)
+		ob, err := (&usecase.FetchOrderBook{Ex: ex}).Execute(commandCtx, strings.ToUpper(*market), *depth)
 		if err != nil {
 			DisplayError(err)
 			os.Exit(1)
@@ -119,8 +179,8 @@ func ExecuteCLI() {
 
 	case "place-order":
 		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
-		exch := fs.String("exchange", "foxbit", "Exchange adapter: foxbit|binance|coinbase")
-		market := fs.String("market", "", "Market symbol (required), e.g. BTCBRL")
+		exch := fs.String("exchange", defaultExchange(), "Exchange adapter: foxbit|binance|coinbase")
+		market := fs.String("market", defaultMarket(), "Market symbol (required), e.g. BTCBRL")
 		qty := fs.String("quantity", "", "Order quantity (required)")
 		prc := fs.String("price", "", "Order price (required)")
 		sideF := fs.String("side", "buy", "Order side: buy|sell")
@@ -153,8 +213,9 @@ func ExecuteCLI() {
 			Type:         model.Limit,
 			Quantity:     *qty,
 			Price:        *prc,
+			PostOnly:     true,
 		}
-		res, err := (&usecase.PlaceOrder{Ex: ex}).Execute(order)
+		res, err := (&usecase.PlaceOrder{Ex: ex}).Execute(commandCtx, order)
 		if err != nil {
 			DisplayError(err)
 			os.Exit(1)
@@ -163,7 +224,7 @@ func ExecuteCLI() {
 
 	case "cancel-order":
 		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
-		exch := fs.String("exchange", "foxbit", "Exchange adapter")
+		exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
 		orderID := fs.String("order-id", "", "Order ID to cancel (required)")
 		fs.Usage = func() {
 			fmt.Fprintf(fs.Output(), "Usage: %s cancel-order [options]\n\n", os.Args[0])
@@ -178,16 +239,44 @@ func ExecuteCLI() {
 			os.Exit(1)
 		}
 		ex := mustInitExchange(*exch)
-		if err := (&usecase.CancelOrder{Ex: ex}).Execute(*orderID); err != nil {
+		if err := (&usecase.CancelOrder{Ex: ex}).Execute(commandCtx, *orderID); err != nil {
 			DisplayError(err)
 			os.Exit(1)
 		}
 		DisplayCancel(*orderID)
 
+	case "replace-order":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+		orderID := fs.String("order-id", "", "Order ID to replace (required)")
+		prc := fs.String("price", "", "New price (default: unchanged)")
+		qty := fs.String("quantity", "", "New total quantity, including what already executed (default: unchanged)")
+		postOnly := fs.Bool("post-only", true, "Reject a LIMIT replacement that would take liquidity")
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s replace-order [options]\n\n", os.Args[0])
+			fmt.Fprintln(fs.Output(), "Options:")
+			fs.PrintDefaults()
+		}
+		fs.Parse(args[1:])
+
+		if *orderID == "" || (*prc == "" && *qty == "") {
+			fmt.Fprintln(os.Stderr, "error: -order-id and at least one of -price or -quantity are required")
+			fs.Usage()
+			os.Exit(1)
+		}
+		ex := mustInitExchange(*exch)
+		res, err := (&usecase.ReplaceOrder{Ex: ex}).Execute(commandCtx, *orderID, *prc, *qty, *postOnly)
+		if err != nil {
+			DisplayError(err)
+			os.Exit(1)
+		}
+		DisplayReplace(res)
+
 	case "list-active-orders":
 		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
-		exch := fs.String("exchange", "foxbit", "Exchange adapter")
-		market := fs.String("market", "", "Market symbol (required)")
+		exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+		market := fs.String("market", defaultMarket(), "Market symbol (required)")
+		stateDir := fs.String("state-dir", defaultStateDir(), "Directory where triggers are saved")
 		fs.Usage = func() {
 			fmt.Fprintf(fs.Output(), "Usage: %s list-active-orders [options]\n\n", os.Args[0])
 			fmt.Fprintln(fs.Output(), "Options:")
@@ -201,7 +290,11 @@ func ExecuteCLI() {
 			os.Exit(1)
 		}
 		ex := mustInitExchange(*exch)
-		act, err := (&usecase.ListActiveOrders{Ex: ex}).Execute(*market)
+		list := &usecase.ListActiveOrders{Ex: ex}
+		if stateDirExists(*stateDir) {
+			list.Local = newTriggerEngine(ex, *stateDir, 0)
+		}
+		act, err := list.Execute(commandCtx, *market)
 		if err != nil {
 			DisplayError(err)
 			os.Exit(1)
@@ -210,7 +303,7 @@ func ExecuteCLI() {
 
 	case "get-order":
 		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
-		exch := fs.String("exchange", "foxbit", "Exchange adapter")
+		exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
 		orderID := fs.String("order-id", "", "Order ID (required)")
 		fs.Usage = func() {
 			fmt.Fprintf(fs.Output(), "Usage: %s get-order [options]\n\n", os.Args[0])
@@ -225,13 +318,85 @@ func ExecuteCLI() {
 			os.Exit(1)
 		}
 		ex := mustInitExchange(*exch)
-		o, err := (&usecase.GetOrder{Ex: ex}).Execute(*orderID)
+		o, err := (&usecase.GetOrder{Ex: ex}).Execute(commandCtx, *orderID)
 		if err != nil {
 			DisplayError(err)
 			os.Exit(1)
 		}
 		DisplayOrders([]model.Order{*o})
 
+	case "place-orders":
+		runPlaceOrders(args[1:])
+
+	case "cancel-orders":
+		runCancelOrders(args[1:])
+
+	case "cancel-all":
+		runCancelAll(args[1:])
+
+	case "order-history":
+		runOrderHistory(args[1:])
+
+	case "reconcile":
+		runReconcile(args[1:])
+
+	case "config":
+		runConfig(args[1:])
+
+	case "keys":
+		runKeys(args[1:])
+
+	case "serve":
+		runServe(args[1:])
+
+	case "webhook":
+		runWebhook(args[1:])
+
+	case "portfolio":
+		runPortfolio(args[1:])
+
+	case "tax-report":
+		runTaxReport(args[1:])
+
+	case "grid":
+		runGrid(args[1:])
+
+	case "dca":
+		runDCA(args[1:])
+
+	case "market-maker":
+		runMarketMaker(args[1:])
+
+	case "execute-algo":
+		runExecuteAlgo(args[1:])
+
+	case "iceberg":
+		runIceberg(args[1:])
+
+	case "oco":
+		runComposite(composite.OCO, args[1:])
+
+	case "bracket":
+		runComposite(composite.Bracket, args[1:])
+
+	case "panic":
+		runPanic(args[1:])
+
+	case "rearm":
+		runRearm(args[1:])
+
+	case "add-trigger":
+		runAddTrigger(args[1:])
+
+	case "list-triggers":
+		runListTriggers(args[1:])
+
+	case "cancel-trigger":
+		runCancelTrigger(args[1:])
+
+	case "run-triggers":
+		runRunTriggers(args[1:])
+
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", cmd)
 		usage()
@@ -245,22 +410,302 @@ func usage() {
 	fmt.Fprintln(os.Stderr, "Global Flags:")
 	fmt.Fprintln(os.Stderr, "  -info       Display build/version information")
 	fmt.Fprintln(os.Stderr, "  -license    Display license information")
+	fmt.Fprintln(os.Stderr, "  -risk-limits FILE  Pre-trade risk limits (default ~/.trading-bot/risk.json)")
+	fmt.Fprintln(os.Stderr, "  -output FORMAT     Output format: table, json, csv or yaml (default table)")
+	fmt.Fprintln(os.Stderr, "  -config FILE       Account profiles (default ~/.trading-bot/config.yaml)")
+	fmt.Fprintln(os.Stderr, "  -profile NAME      Profile of the config file to use")
+	fmt.Fprintln(os.Stderr, "  -metrics-addr ADDR Serve Prometheus metrics on ADDR under /metrics")
+	fmt.Fprintln(os.Stderr, "  -log-level LEVEL   debug, info, warn or error (default info)")
+	fmt.Fprintln(os.Stderr, "  -log-format FORMAT text or json (default text)")
 	fmt.Fprintln(os.Stderr, "\nCommands:")
 	fmt.Fprintln(os.Stderr, "  fetch-markets           List all markets")
 	fmt.Fprintln(os.Stderr, "  fetch-order-book        Fetch order book for a market")
 	fmt.Fprintln(os.Stderr, "  place-order             Place a new limit order")
 	fmt.Fprintln(os.Stderr, "  cancel-order            Cancel an existing order")
+	fmt.Fprintln(os.Stderr, "  replace-order           Change the price or quantity of an order")
 	fmt.Fprintln(os.Stderr, "  list-active-orders      List active orders for a market")
 	fmt.Fprintln(os.Stderr, "  get-order               Get details of a single order")
+	fmt.Fprintln(os.Stderr, "  place-orders            Place the orders listed in a CSV or JSON file")
+	fmt.Fprintln(os.Stderr, "  cancel-orders           Cancel several orders by ID")
+	fmt.Fprintln(os.Stderr, "  cancel-all              Cancel every active order of a market")
+	fmt.Fprintln(os.Stderr, "  order-history           Show the local journal of order events")
+	fmt.Fprintln(os.Stderr, "  reconcile               Compare the order journal with the exchange and repair it")
+	fmt.Fprintln(os.Stderr, "  config                  List or validate the profiles of the config file")
+	fmt.Fprintln(os.Stderr, "  keys                    Manage the encrypted credential keystore")
+	fmt.Fprintln(os.Stderr, "  serve                   Serve the use cases as a JSON HTTP API")
+	fmt.Fprintln(os.Stderr, "  webhook                 Place and cancel orders from charting tool alerts")
+	fmt.Fprintln(os.Stderr, "  portfolio               Show positions with realized and unrealized PnL")
+	fmt.Fprintln(os.Stderr, "  tax-report              Export monthly capital gains for Receita Federal")
+	fmt.Fprintln(os.Stderr, "  grid                    Run a grid trading strategy")
+	fmt.Fprintln(os.Stderr, "  dca                     Run a recurring (dollar-cost averaging) purchase")
+	fmt.Fprintln(os.Stderr, "  market-maker            Quote both sides of a market around mid")
+	fmt.Fprintln(os.Stderr, "  execute-algo            Work a large order over time with TWAP or VWAP")
+	fmt.Fprintln(os.Stderr, "  iceberg                 Place an order showing only a small clip at a time")
+	fmt.Fprintln(os.Stderr, "  oco                     Protect a position with a take-profit and a stop")
+	fmt.Fprintln(os.Stderr, "  bracket                 Place an entry with an attached take-profit and stop")
+	fmt.Fprintln(os.Stderr, "  panic                   Halt trading, cancel every order and optionally flatten")
+	fmt.Fprintln(os.Stderr, "  rearm                   Allow trading again after panic")
+	fmt.Fprintln(os.Stderr, "  add-trigger             Add a stop-loss or take-profit trigger")
+	fmt.Fprintln(os.Stderr, "  list-triggers           List locally held triggers")
+	fmt.Fprintln(os.Stderr, "  cancel-trigger          Cancel a pending trigger")
+	fmt.Fprintln(os.Stderr, "  run-triggers            Watch prices and fire pending triggers")
 	fmt.Fprintln(os.Stderr, "\nUse “<command> --help” for more information about a command.")
 }
 
+// cfg is the configuration file, nil when there is none, and profile the
+// profile selected in it, nil when none is.
+var (
+	cfg     *config.Config
+	profile *config.Profile
+)
+
+// mustLoadProfile loads the configuration at path and selects the profile
+// called name, or the default one.
+func mustLoadProfile(path, name string) {
+	var err error
+	if cfg, err = config.Load(path); err != nil {
+		fatalf("%v", err)
+	}
+	if cfg == nil {
+		if name != "" {
+			fatalf("Profile %q requested but %s does not exist", name, path)
+		}
+		return
+	}
+	if profile, err = cfg.Select(name); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+// fatalf logs an error that prevents the command from running and exits.
+func fatalf(format string, args ...any) {
+	slog.Error(fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
+
+// flagSet reports whether the global flag name was given on the command
+// line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// defaultExchange returns the exchange of the selected profile, foxbit
+// when there is none.
+func defaultExchange() string {
+	if profile != nil && profile.Exchange != "" {
+		return strings.ToLower(profile.Exchange)
+	}
+	return "foxbit"
+}
+
+// defaultMarket returns the market of the selected profile, if any.
+func defaultMarket() string {
+	if profile == nil {
+		return ""
+	}
+	return strings.ToUpper(profile.Market)
+}
+
+// riskFile is the path of the risk limits set by the -risk-limits flag.
+var riskFile string
+
+// mustInitExchange returns the exchange trading commands go through: the
+// adapter behind the journal, the risk limits and the kill switch, with
+// every order counted in the metrics.
 func mustInitExchange(name string) service.Exchange {
-	switch strings.ToLower(name) {
-	case "foxbit":
-		return foxbit.New(os.Getenv("FOXBIT_API_KEY"), os.Getenv("FOXBIT_API_SECRET"))
-	default:
-		log.Fatalf("Unknown exchange: %s", name)
-		return nil
+	ex := &metrics.Exchange{
+		Exchange: &killswitch.Guard{
+			Exchange: mustApplyRiskLimits(mustInitRawExchange(name)),
+			Switch:   killSwitch(),
+		},
+		Reason: rejectionReason,
+	}
+	metrics.OnScrape(ex.RefreshBalances(30 * time.Second))
+	return ex
+}
+
+// rejectionReason labels a refused order in the metrics.
+func rejectionReason(err error) string {
+	var v *risk.Violation
+	switch {
+	case errors.Is(err, killswitch.ErrEngaged):
+		return "kill_switch"
+	case errors.As(err, &v):
+		return "risk"
+	}
+	return "exchange"
+}
+
+// orderRefused reports whether err means the exchange refused an order
+// outright, so none was placed. A timeout or a 5xx leaves it unknown.
+func orderRefused(err error) bool {
+	var se *httputil.StatusError
+	return errors.As(err, &se) && se.Code >= 400 && se.Code < 500 && se.Code != http.StatusRequestTimeout
+}
+
+// mustInitRawExchange returns the exchange adapter without the risk and
+// kill switch checks, for commands that must work while trading is halted.
+// Every order operation is still recorded in the order journal.
+func mustInitRawExchange(name string) service.Exchange {
+	return &journal.Recorder{Exchange: mustInitAdapter(name), Journal: mustOpenJournal()}
+}
+
+// adapters builds each supported exchange adapter from its credentials.
+var adapters = map[string]func(key, secret string) service.Exchange{
+	"foxbit": foxbit.New,
+}
+
+// mustInitAdapter returns the bare exchange adapter, which does not even
+// journal its orders.
+func mustInitAdapter(name string) service.Exchange {
+	name = strings.ToLower(name)
+	newAdapter, ok := adapters[name]
+	if !ok {
+		fatalf("Unknown exchange: %s", name)
+	}
+	if profile != nil && !strings.EqualFold(profile.Exchange, name) {
+		fatalf("Profile %q is for %s, not %s", profile.Name, profile.Exchange, name)
+	}
+	return newAdapter(credentials())
+}
+
+// credentials returns the API key pair of the selected profile, or of
+// $FOXBIT_API_KEY and $FOXBIT_API_SECRET when there is none. When those
+// are not set the keystore is used, through the agent if one is running.
+// Either way the pair is kept out of the logs.
+func credentials() (key, secret string) {
+	if profile == nil {
+		key, secret = os.Getenv("FOXBIT_API_KEY"), os.Getenv("FOXBIT_API_SECRET")
+	} else {
+		key, secret = profile.Credentials()
+	}
+	if key == "" || secret == "" {
+		if c, ok := keystoreCredentials(); ok {
+			key, secret = c.Key, c.Secret
+		}
+	}
+	logging.Secret(key, secret)
+	return key, secret
+}
+
+// mustApplyRiskLimits wraps ex with the pre-trade checks when a risk limits
+// file exists.
+func mustApplyRiskLimits(ex service.Exchange) service.Exchange {
+	if riskFile == "" {
+		return ex
+	}
+	limits, err := risk.LoadLimits(riskFile)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if limits == nil {
+		return ex
+	}
+	return risk.NewGuard(ex, *limits, mustOpenStateStore(defaultStateDir()))
+}
+
+// mustLookupMarket fetches the market rules for symbol, exiting if the
+// exchange does not list it.
+func mustLookupMarket(ex service.Exchange, symbol string) model.Market {
+	mkts, err := (&usecase.FetchMarkets{Ex: ex}).Execute(commandCtx)
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	for _, m := range mkts {
+		if strings.EqualFold(m.Symbol, symbol) {
+			return m
+		}
+	}
+	fatalf("Unknown market: %s", symbol)
+	return model.Market{}
+}
+
+// stateDirExists reports whether dir was created by an earlier command.
+// Read-only commands check it before opening the state store, which would
+// create the directory.
+func stateDirExists(dir string) bool {
+	fi, err := os.Stat(dir)
+	return err == nil && fi.IsDir()
+}
+
+// mustOpenStateStore opens the directory where strategies keep their state.
+func mustOpenStateStore(dir string) service.StateStore {
+	st, err := filestore.New(dir)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	return st
+}
+
+// mustOpenJournal opens the order journal of the account.
+func mustOpenJournal() service.Journal {
+	j, err := boltjournal.New(accountPath("journal.db"))
+	if err != nil {
+		fatalf("%v", err)
+	}
+	return j
+}
+
+// defaultStateDir returns the state directory of the account.
+func defaultStateDir() string {
+	return accountPath("state")
+}
+
+// defaultRiskFile returns ~/.trading-bot/risk.json.
+func defaultRiskFile() string {
+	return appPath("risk.json")
+}
+
+// killSwitch returns the switch shared by every process of the bot, kept
+// in ~/.trading-bot/kill-switch.
+func killSwitch() *killswitch.Switch {
+	return &killswitch.Switch{Path: appPath("kill-switch")}
+}
+
+// appPath returns name inside ~/.trading-bot, or inside a relative
+// .trading-bot when the home directory cannot be determined.
+func appPath(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".trading-bot", name)
+	}
+	return filepath.Join(home, ".trading-bot", name)
+}
+
+// accountPath returns name inside the directory of the selected profile,
+// ~/.trading-bot/profiles/<profile>, or inside ~/.trading-bot when no
+// profile is selected. Journals and strategy state are kept per account
+// since order IDs mean nothing to another account.
+func accountPath(name string) string {
+	if profile == nil {
+		return appPath(name)
+	}
+	return appPath(filepath.Join("profiles", profile.Name, name))
+}
+
+// runContext returns the context long-running commands work under. It is
+// cancelled on Ctrl+C or SIGTERM, and when the kill switch is engaged.
+func runContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(commandCtx, os.Interrupt, syscall.SIGTERM)
+	ctx, cancel := killSwitch().Watch(ctx, time.Second)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// parseFloat converts a decimal flag value, exiting on malformed input.
+func parseFloat(s string) float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		fatalf("Invalid number: %s", s)
 	}
+	return f
 }
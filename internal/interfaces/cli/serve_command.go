@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"trading-bot/internal/infrastructure/logging"
+	"trading-bot/internal/infrastructure/metrics"
+	"trading-bot/internal/interfaces/grpcapi"
+	"trading-bot/internal/interfaces/httpapi"
+)
+
+// apiTokenEnv adds one API token to those of the tokens file.
+const apiTokenEnv = "TRADING_BOT_API_TOKEN"
+
+// runServe handles the "serve" sub-command.
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	addr := fs.String("addr", "127.0.0.1:8080", "Address of the HTTP API; empty to disable it")
+	grpcAddr := fs.String("grpc-addr", "", "Address of the gRPC API, e.g. 127.0.0.1:9090; empty to disable it")
+	tokensFile := fs.String("tokens-file", appPath("api-tokens"), "File with the accepted bearer tokens, one per line")
+	stateDir := fs.String("state-dir", defaultStateDir(), "Directory where triggers are saved")
+	shutdown := fs.Duration("shutdown-timeout", 0, "How long to wait for requests in flight on shutdown (default 10s)")
+	spec := fs.Bool("openapi", false, "Print the OpenAPI document and exit")
+	proto := fs.Bool("proto", false, "Print the protobuf definition of the gRPC API and exit")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s serve [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Serves the use cases as a JSON HTTP API under /"+httpapi.Version+", and over gRPC with -grpc-addr,")
+		fmt.Fprintln(fs.Output(), "until Ctrl+C or SIGTERM. Requests need one of the tokens as 'Authorization: Bearer <token>'.")
+		fmt.Fprintln(fs.Output(), "Prometheus metrics are served on /metrics, with the same tokens.")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *spec {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode((&httpapi.Server{}).OpenAPI())
+		return
+	}
+	if *proto {
+		fmt.Print(grpcapi.Proto)
+		return
+	}
+	if *addr == "" && *grpcAddr == "" {
+		fatalf("Nothing to serve: both -addr and -grpc-addr are empty")
+	}
+
+	tokens, err := loadAPITokens(*tokensFile)
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	if len(tokens) == 0 {
+		fatalf("No API tokens: write some to %s or set $%s", *tokensFile, apiTokenEnv)
+	}
+	ex := mustInitExchange(*exch)
+	local := newTriggerEngine(ex, *stateDir, 0)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Both APIs share the exchange, and so the journal, which only one
+	// process may hold open. The first to fail stops the other.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var servers []func() error
+	if *addr != "" {
+		srv := &httpapi.Server{Ex: ex, Local: local, Tokens: tokens, Metrics: metrics.Handler(), ShutdownTimeout: *shutdown}
+		servers = append(servers, func() error { return srv.Run(ctx, *addr) })
+	}
+	if *grpcAddr != "" {
+		srv := &grpcapi.Server{Ex: ex, Local: local, Tokens: tokens, ShutdownTimeout: *shutdown}
+		servers = append(servers, func() error { return srv.Run(ctx, *grpcAddr) })
+	}
+	errc := make(chan error, len(servers))
+	for _, run := range servers {
+		go func() {
+			err := run()
+			cancel()
+			errc <- err
+		}()
+	}
+	var errs []error
+	for range servers {
+		errs = append(errs, <-errc)
+	}
+	if err := errors.Join(errs...); err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+}
+
+// loadAPITokens reads the tokens file, skipping blank lines and # comments,
+// and adds $TRADING_BOT_API_TOKEN. A missing file is not an error.
+func loadAPITokens(path string) ([]string, error) {
+	var tokens []string
+	if t := os.Getenv(apiTokenEnv); t != "" {
+		logging.Secret(t)
+		tokens = append(tokens, t)
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if fi, err := f.Stat(); err == nil && fi.Mode().Perm()&0o077 != 0 {
+		slog.Warn("serve: tokens file is readable by other users; chmod 600 it", "path", path)
+	}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, line)
+	}
+	logging.Secret(tokens...)
+	return tokens, sc.Err()
+}
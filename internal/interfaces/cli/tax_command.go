@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"trading-bot/internal/application/portfolio"
+	"trading-bot/internal/application/tax"
+	"trading-bot/internal/application/usecase"
+)
+
+// runTaxReport handles the "tax-report" sub-command.
+func runTaxReport(args []string) {
+	fs := flag.NewFlagSet("tax-report", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	year := fs.Int("year", time.Now().Year()-1, "Calendar year of the report")
+	since := fs.String("since", "", "Download trades from this date (YYYY-MM-DD) on the first run (default: five years before -year)")
+	format := fs.String("format", "csv", "Export format: csv|json")
+	out := fs.String("out", ".", "Directory the report files are written to")
+	stateDir := fs.String("state-dir", defaultStateDir(), "Directory where the trade history is saved")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s tax-report [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Average costs need every purchase, so -since must reach back to the first one.")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	start := time.Date(*year-5, 1, 1, 0, 0, 0, 0, tax.Location)
+	if *since != "" {
+		var err error
+		if start, err = time.ParseInLocation("2006-01-02", *since, tax.Location); err != nil {
+			fmt.Fprintln(os.Stderr, "error: -since must be a date like 2020-01-31")
+			os.Exit(1)
+		}
+	}
+	*format = strings.ToLower(*format)
+	if *format != "csv" && *format != "json" {
+		fmt.Fprintln(os.Stderr, "error: invalid format, use csv or json")
+		os.Exit(1)
+	}
+
+	ex := mustInitExchange(*exch)
+	h := &portfolio.History{Trades: &usecase.FetchTrades{Ex: ex}, Store: mustOpenStateStore(*stateDir)}
+	trades, err := h.Sync(commandCtx, start)
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	markets, err := (&usecase.FetchMarkets{Ex: ex}).Execute(commandCtx)
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	r, err := tax.Generate(commandCtx, trades, markets, &tax.CandleRates{Candles: &usecase.FetchCandles{Ex: ex}}, *year)
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+
+	var paths []string
+	if *format == "json" {
+		path := filepath.Join(*out, strconv.Itoa(*year)+"-tax-report.json")
+		f, err := os.Create(path)
+		if err == nil {
+			err = r.WriteJSON(f)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err != nil {
+			DisplayError(err)
+			os.Exit(1)
+		}
+		paths = append(paths, path)
+	} else if paths, err = r.WriteCSV(*out); err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+
+	DisplayTaxMonths(r.Months)
+	notef("\n")
+	for _, p := range paths {
+		notef("Wrote %s\n", p)
+	}
+}
@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"trading-bot/internal/application/trigger"
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+)
+
+// newTriggerEngine wires a trigger engine to ex and the state directory.
+func newTriggerEngine(ex service.Exchange, stateDir string, interval time.Duration) *trigger.Engine {
+	return &trigger.Engine{
+		Place:       &usecase.PlaceOrder{Ex: ex},
+		Ticker:      &usecase.FetchTicker{Ex: ex},
+		Store:       mustOpenStateStore(stateDir),
+		Interval:    interval,
+		GetOrder:    &usecase.GetOrder{Ex: ex},
+		CancelOrder: &usecase.CancelOrder{Ex: ex},
+	}
+}
+
+// runAddTrigger handles the "add-trigger" sub-command.
+func runAddTrigger(args []string) {
+	fs := flag.NewFlagSet("add-trigger", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	typ := fs.String("type", "stop-market", "Trigger type: stop-market|stop-limit|take-profit|trailing-stop")
+	market := fs.String("market", defaultMarket(), "Market symbol (required), e.g. BTCBRL")
+	sideF := fs.String("side", "sell", "Order side: buy|sell")
+	qty := fs.String("quantity", "", "Order quantity (required)")
+	stop := fs.String("stop-price", "", "Price that fires the trigger (required except for trailing-stop)")
+	limit := fs.String("limit-price", "", "Limit price of the child order (stop-limit, optional for take-profit)")
+	trailAmount := fs.String("trail-amount", "", "Trailing distance in quote currency (trailing-stop)")
+	trailPercent := fs.Float64("trail-percent", 0, "Trailing distance as a percentage of price (trailing-stop)")
+	activation := fs.String("activation-price", "", "Start trailing only once this price is reached (trailing-stop)")
+	source := fs.String("source", "last", "Price watched: last|bid|ask")
+	stateDir := fs.String("state-dir", defaultStateDir(), "Directory where triggers are saved")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s add-trigger [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Options:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	trailing := strings.EqualFold(*typ, "trailing-stop")
+	if *market == "" || *qty == "" || (*stop == "" && !trailing) {
+		fmt.Fprintln(os.Stderr, "error: -market, -quantity and -stop-price are required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	var side model.OrderSide
+	switch strings.ToLower(*sideF) {
+	case "buy":
+		side = model.Buy
+	case "sell":
+		side = model.Sell
+	default:
+		fmt.Fprintln(os.Stderr, "error: invalid side, use buy or sell")
+		os.Exit(1)
+	}
+	ex := mustInitExchange(*exch)
+	m := mustLookupMarket(ex, *market)
+	t := trigger.Trigger{
+		Type:         model.OrderType(strings.ToUpper(strings.ReplaceAll(*typ, "-", "_"))),
+		Market:       m.Symbol,
+		Side:         side,
+		Quantity:     m.AlignQuantity(parseFloat(*qty)),
+		Source:       trigger.PriceSource(strings.ToUpper(*source)),
+		TrailPercent: *trailPercent,
+	}
+	if *stop != "" {
+		t.StopPrice = m.AlignPrice(parseFloat(*stop))
+	}
+	if *limit != "" {
+		t.LimitPrice = m.AlignPrice(parseFloat(*limit))
+	}
+	if *trailAmount != "" {
+		t.TrailAmount = m.AlignPrice(parseFloat(*trailAmount))
+	}
+	if *activation != "" {
+		t.ActivationPrice = m.AlignPrice(parseFloat(*activation))
+	}
+	added, err := newTriggerEngine(ex, *stateDir, 0).Add(t)
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	DisplayTriggers([]trigger.Trigger{*added})
+}
+
+// runListTriggers handles the "list-triggers" sub-command.
+func runListTriggers(args []string) {
+	fs := flag.NewFlagSet("list-triggers", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	all := fs.Bool("all", false, "Include triggered, failed and cancelled triggers")
+	stateDir := fs.String("state-dir", defaultStateDir(), "Directory where triggers are saved")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s list-triggers [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Options:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if !stateDirExists(*stateDir) {
+		DisplayTriggers(nil)
+		return
+	}
+	ts, err := newTriggerEngine(mustInitExchange(*exch), *stateDir, 0).List()
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	if !*all {
+		pending := ts[:0]
+		for _, t := range ts {
+			if t.Status == trigger.Pending {
+				pending = append(pending, t)
+			}
+		}
+		ts = pending
+	}
+	DisplayTriggers(ts)
+}
+
+// runCancelTrigger handles the "cancel-trigger" sub-command.
+func runCancelTrigger(args []string) {
+	fs := flag.NewFlagSet("cancel-trigger", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	id := fs.String("id", "", "Trigger ID to cancel (required)")
+	stateDir := fs.String("state-dir", defaultStateDir(), "Directory where triggers are saved")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s cancel-trigger [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Options:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *id == "" {
+		fmt.Fprintln(os.Stderr, "error: -id is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if err := newTriggerEngine(mustInitExchange(*exch), *stateDir, 0).Cancel(*id); err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	DisplayCancel(*id)
+}
+
+// runRunTriggers handles the "run-triggers" sub-command.
+func runRunTriggers(args []string) {
+	fs := flag.NewFlagSet("run-triggers", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	interval := fs.Duration("interval", 2*time.Second, "How often prices are checked")
+	stateDir := fs.String("state-dir", defaultStateDir(), "Directory where triggers are saved")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s run-triggers [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Options:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	ctx, stop := runContext()
+	defer stop()
+	if err := newTriggerEngine(mustInitExchange(*exch), *stateDir, *interval).Run(ctx); err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"trading-bot/internal/application/alert"
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/infrastructure/logging"
+	"trading-bot/internal/interfaces/webhook"
+)
+
+// webhookSecretEnv holds the shared secret when no secret file exists.
+const webhookSecretEnv = "TRADING_BOT_WEBHOOK_SECRET"
+
+// runWebhook handles the "webhook" sub-command.
+func runWebhook(args []string) {
+	fs := flag.NewFlagSet("webhook", flag.ExitOnError)
+	exch := fs.String("exchange", defaultExchange(), "Exchange adapter")
+	addr := fs.String("addr", "127.0.0.1:8090", "Address to listen on")
+	path := fs.String("path", "/webhook", "URL path alerts are posted to")
+	secretFile := fs.String("secret-file", appPath("webhook-secret"), "File holding the shared secret")
+	markets := fs.String("markets", "", "Comma-separated markets alerts may trade (default: any)")
+	window := fs.Duration("dedup-window", 5*time.Minute, "Repeated alerts within this window are ignored")
+	maxAge := fs.Duration("max-age", 5*time.Minute, "Alerts whose timestamp is further than this from now are refused")
+	stateDir := fs.String("state-dir", defaultStateDir(), "Directory where the dedup keys are saved")
+	decisions := fs.String("decisions", accountPath("webhook-decisions.jsonl"), "File each decision is appended to")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s webhook [options]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Receives buy, sell and cancel alerts posted as JSON by charting tools and places or")
+		fmt.Fprintln(fs.Output(), "cancels the orders, subject to the risk limits and the kill switch.")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *window < *maxAge {
+		// a replay older than the window would no longer be a duplicate
+		fatalf("-dedup-window (%s) must be at least -max-age (%s)", *window, *maxAge)
+	}
+	secret, err := loadWebhookSecret(*secretFile)
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	if secret == "" {
+		fatalf("No webhook secret: write one to %s or set $%s", *secretFile, webhookSecretEnv)
+	}
+	logf, err := openDecisionLog(*decisions)
+	if err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+	defer logf.Close()
+	enc := json.NewEncoder(logf)
+	var mu sync.Mutex // alerts are handled concurrently
+
+	ex := mustInitExchange(*exch)
+	rcv := &alert.Receiver{
+		Place:     &usecase.PlaceOrder{Ex: ex},
+		Cancel:    &usecase.CancelOrder{Ex: ex},
+		CancelAll: &usecase.CancelAllOrders{Ex: ex},
+		Ticker:    &usecase.FetchTicker{Ex: ex},
+		Markets:   &usecase.FetchMarkets{Ex: ex},
+		Window:    *window,
+		Store:     mustOpenStateStore(*stateDir),
+		OnDecision: func(ctx context.Context, d alert.Decision) {
+			level := slog.LevelInfo
+			if d.Outcome == alert.Rejected || d.Outcome == alert.Failed {
+				level = slog.LevelWarn
+			}
+			slog.Log(ctx, level, "webhook: decision", "key", d.Key, "action", d.Action, "market", d.Market,
+				"size", d.Size, "price", d.Price, "outcome", d.Outcome, "orders", strings.Join(d.OrderIDs, ","), "reason", d.Reason)
+			mu.Lock()
+			defer mu.Unlock()
+			if err := enc.Encode(d); err != nil {
+				slog.ErrorContext(ctx, "webhook: failed to record the decision", "err", err)
+			}
+		},
+	}
+	if *markets != "" {
+		rcv.Allowed = strings.Split(*markets, ",")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	srv := &webhook.Server{Receiver: rcv, Secret: secret, Path: *path, MaxAge: *maxAge}
+	if err := srv.Run(ctx, *addr); err != nil {
+		DisplayError(err)
+		os.Exit(1)
+	}
+}
+
+// loadWebhookSecret reads the secret file, or $TRADING_BOT_WEBHOOK_SECRET
+// when there is none.
+func loadWebhookSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		secret := os.Getenv(webhookSecretEnv)
+		logging.Secret(secret)
+		return secret, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSpace(string(data))
+	logging.Secret(secret)
+	return secret, nil
+}
+
+func openDecisionLog(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+}
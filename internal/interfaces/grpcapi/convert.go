@@ -0,0 +1,74 @@
+package grpcapi
+
+import (
+	"trading-bot/internal/domain/model"
+)
+
+// Conversions between the domain model and the messages generated from
+// trading.proto.
+
+func toMarkets(markets []model.Market) []*Market {
+	out := make([]*Market, len(markets))
+	for i, m := range markets {
+		out[i] = &Market{
+			Symbol:            m.Symbol,
+			PriceMin:          m.PriceMin,
+			PriceIncrement:    m.PriceIncrement,
+			PricePrecision:    int32(m.PricePrecision),
+			QuantityMin:       m.QuantityMin,
+			QuantityIncrement: m.QuantityIncrement,
+			QuantityPrecision: int32(m.QuantityPrecision),
+			Base:              &Asset{Symbol: m.Base.Symbol},
+			Quote:             &Asset{Symbol: m.Quote.Symbol},
+		}
+	}
+	return out
+}
+
+func toOrder(o *model.Order) *Order {
+	if o == nil {
+		return nil
+	}
+	return &Order{
+		Id:                o.ID,
+		MarketSymbol:      o.MarketSymbol,
+		Side:              string(o.Side),
+		Type:              string(o.Type),
+		Price:             o.Price,
+		Quantity:          o.Quantity,
+		PostOnly:          o.PostOnly,
+		StopPrice:         o.StopPrice,
+		State:             string(o.State),
+		QuantityExecuted:  o.QuantityExecuted,
+		QuantityRemaining: o.QuantityRemaining,
+		PriceAvg:          o.PriceAvg,
+	}
+}
+
+func toOrders(orders []model.Order) []*Order {
+	out := make([]*Order, len(orders))
+	for i := range orders {
+		out[i] = toOrder(&orders[i])
+	}
+	return out
+}
+
+// toOrderBook turns each level of the book, a price/quantity pair, into a
+// PriceLevel.
+func toOrderBook(ob *model.OrderBook) *OrderBook {
+	if ob == nil {
+		return nil
+	}
+	return &OrderBook{Bids: toLevels(ob.Bids), Asks: toLevels(ob.Asks)}
+}
+
+func toLevels(levels [][]string) []*PriceLevel {
+	out := make([]*PriceLevel, 0, len(levels))
+	for _, l := range levels {
+		if len(l) < 2 {
+			continue
+		}
+		out = append(out, &PriceLevel{Price: l[0], Quantity: l[1]})
+	}
+	return out
+}
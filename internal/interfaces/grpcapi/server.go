@@ -0,0 +1,246 @@
+// Package grpcapi serves the use cases over gRPC, for integrations that
+// need lower latency than the JSON HTTP API and streamed updates. The
+// interface is defined in trading.proto; trading.pb.go and
+// trading_grpc.pb.go are generated from it.
+package grpcapi
+
+//go:generate buf generate
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"trading-bot/internal/application/killswitch"
+	"trading-bot/internal/application/risk"
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/service"
+	"trading-bot/internal/infrastructure/httputil"
+	"trading-bot/internal/infrastructure/logging"
+)
+
+// Proto is the content of trading.proto, for generating clients.
+//
+//go:embed trading.proto
+var Proto string
+
+// Server serves the TradingService. Every call needs one of Tokens as a
+// bearer token in its "authorization" metadata.
+type Server struct {
+	UnimplementedTradingServiceServer
+
+	Ex     service.Exchange
+	Local  usecase.LocalOrders // listed with the active orders when set
+	Tokens []string
+
+	// ShutdownTimeout bounds how long Run waits for calls in flight once
+	// its context is cancelled. Zero means 10 seconds.
+	ShutdownTimeout time.Duration
+
+	done <-chan struct{} // closed when Run is asked to stop, ending streams
+}
+
+// Run listens on addr until ctx is cancelled, then ends the streams, stops
+// accepting calls and waits for the unary ones in flight.
+func (s *Server) Run(ctx context.Context, addr string) error {
+	if len(s.Tokens) == 0 {
+		return errors.New("grpcapi: no API tokens configured")
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("grpcapi: %w", err)
+	}
+	s.done = ctx.Done()
+	srv := s.newGRPCServer()
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(l) }()
+	slog.Info("grpcapi: listening", "addr", addr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	timeout := s.ShutdownTimeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	slog.Info("grpcapi: shutting down, waiting for calls in flight", "timeout", timeout)
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		srv.Stop()
+	}
+	return nil
+}
+
+// newGRPCServer returns a gRPC server with the TradingService and its
+// interceptors registered.
+func (s *Server) newGRPCServer() *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(logUnary, s.authUnary, errorsUnary),
+		grpc.ChainStreamInterceptor(logStream, s.authStream, errorsStream),
+	)
+	RegisterTradingServiceServer(srv, s)
+	return srv
+}
+
+// authorized reports whether the metadata of ctx carries a known token.
+// Tokens are compared in constant time.
+func (s *Server) authorized(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, h := range md.Get("authorization") {
+		got, ok := strings.CutPrefix(h, "Bearer ")
+		if !ok {
+			continue
+		}
+		for _, t := range s.Tokens {
+			if subtle.ConstantTimeCompare([]byte(got), []byte(t)) == 1 {
+				return nil
+			}
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+}
+
+func (s *Server) authUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	if err := s.authorized(ctx); err != nil {
+		return nil, err
+	}
+	return next(ctx, req)
+}
+
+func (s *Server) authStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+	if err := s.authorized(ss.Context()); err != nil {
+		return err
+	}
+	return next(srv, ss)
+}
+
+// requestIDKey is the metadata key of the request ID. A client may set
+// it, to find its calls in the logs; otherwise one is generated. Either
+// way it is sent back in the response header.
+const requestIDKey = "x-request-id"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// withRequestID returns ctx tagged with the ID of the call.
+func withRequestID(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := ""
+	if ids := md.Get(requestIDKey); len(ids) > 0 {
+		id = ids[0]
+	}
+	if !validRequestID.MatchString(id) {
+		id = logging.NewRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+	return logging.WithRequestID(ctx, id)
+}
+
+func logUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ctx = withRequestID(ctx)
+	res, err := next(ctx, req)
+	slog.InfoContext(ctx, "grpcapi: call", "method", info.FullMethod, "code", status.Code(err).String(),
+		"duration", time.Since(start))
+	return res, err
+}
+
+func logStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+	start := time.Now()
+	ss = &taggedStream{ServerStream: ss, ctx: withRequestID(ss.Context())}
+	slog.InfoContext(ss.Context(), "grpcapi: stream started", "method", info.FullMethod)
+	err := next(srv, ss)
+	slog.InfoContext(ss.Context(), "grpcapi: stream ended", "method", info.FullMethod, "code", status.Code(err).String(),
+		"duration", time.Since(start))
+	return err
+}
+
+// taggedStream is a stream whose context carries the request ID.
+type taggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *taggedStream) Context() context.Context { return s.ctx }
+
+func errorsUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	res, err := next(ctx, req)
+	return res, logged(ctx, err)
+}
+
+func errorsStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+	return logged(ss.Context(), next(srv, ss))
+}
+
+// logged maps err to a status, logging the failures of the exchange.
+func logged(ctx context.Context, err error) error {
+	st := toStatus(err)
+	if status.Code(st) == codes.Unavailable {
+		slog.WarnContext(ctx, "grpcapi: call failed", "err", err)
+	}
+	return st
+}
+
+// toStatus maps the errors of the use cases to a gRPC status, as the HTTP
+// API maps them to HTTP statuses. The exchange refusing a request is the
+// caller's problem; only its failures and network errors are Unavailable,
+// as is anything unknown.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	var v *risk.Violation
+	var se *httputil.StatusError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, service.ErrOrderNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, killswitch.ErrEngaged):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.As(err, &v):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.As(err, &se) && se.Code < 500:
+		return status.Error(exchangeCode(se.Code), err.Error())
+	default:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+}
+
+// exchangeCode maps a 4xx status of the exchange to a gRPC code.
+func exchangeCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	default: // e.g. insufficient balance, or credentials the exchange refuses
+		return codes.FailedPrecondition
+	}
+}
@@ -0,0 +1,167 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+
+	"trading-bot/internal/application/killswitch"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+	"trading-bot/internal/infrastructure/httputil"
+	"trading-bot/internal/infrastructure/logging"
+)
+
+func TestRoundTrip(t *testing.T) {
+	ex := &fakeExchange{}
+	client := dial(t, &Server{Ex: ex, Tokens: []string{"token"}})
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer token")
+
+	mkts, err := client.FetchMarkets(ctx, &FetchMarketsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Market{
+		Symbol: "BTCBRL", PriceMin: "1", PriceIncrement: "1", PricePrecision: 0,
+		QuantityMin: "0.0001", QuantityIncrement: "0.0001", QuantityPrecision: 4,
+		Base: &Asset{Symbol: "btc"}, Quote: &Asset{Symbol: "brl"},
+	}
+	if len(mkts.Markets) != 1 || !proto.Equal(mkts.Markets[0], want) {
+		t.Errorf("markets %v, want %v", mkts.Markets, want)
+	}
+
+	ob, err := client.FetchOrderBook(ctx, &FetchOrderBookRequest{Market: "BTCBRL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ob.Bids) != 1 || ob.Bids[0].Price != "99" || ob.Bids[0].Quantity != "0.5" || len(ob.Asks) != 1 {
+		t.Errorf("book %v", ob)
+	}
+
+	placed, err := client.PlaceOrder(ctx, &PlaceOrderRequest{Market: "BTCBRL", Side: "buy", Price: "100", Quantity: "0.001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if placed.Id != "1" || placed.Side != "BUY" || placed.Type != "LIMIT" || !placed.PostOnly || placed.State != "ACTIVE" {
+		t.Errorf("placed %v", placed)
+	}
+	taker := false
+	if _, err := client.PlaceOrder(ctx, &PlaceOrderRequest{Market: "BTCBRL", Side: "SELL", Price: "100", Quantity: "0.001", PostOnly: &taker}); err != nil {
+		t.Fatal(err)
+	}
+	if ex.placed[1].PostOnly {
+		t.Error("post_only false was not honoured")
+	}
+
+	// the exchange requests an API call causes are logged with its ID
+	traced := metadata.AppendToOutgoingContext(ctx, "x-request-id", "trace-1")
+	if _, err := client.PlaceOrder(traced, &PlaceOrderRequest{Market: "BTCBRL", Side: "BUY", Price: "100", Quantity: "0.001"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := ex.requestIDs[2]; got != "trace-1" {
+		t.Errorf("exchange called with request ID %q, want trace-1", got)
+	}
+
+	if _, err := client.PlaceOrder(ctx, &PlaceOrderRequest{Market: "BTCBRL", Side: "BUY", Quantity: "0.001"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("LIMIT without a price: %v, want InvalidArgument", err)
+	}
+	if _, err := client.GetOrder(context.Background(), &GetOrderRequest{Id: "1"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("no token: %v, want Unauthenticated", err)
+	}
+}
+
+// TestGeneratedCode fails when trading.proto changed without running
+// go generate.
+func TestGeneratedCode(t *testing.T) {
+	msgs := File_trading_proto.Messages()
+	for i := 0; i < msgs.Len(); i++ {
+		m := msgs.Get(i)
+		if !strings.Contains(Proto, "message "+string(m.Name())+" {") {
+			t.Errorf("%s is generated but not in trading.proto", m.Name())
+		}
+	}
+	if got := strings.Count(Proto, "\nmessage "); got != msgs.Len() {
+		t.Errorf("trading.proto has %d messages, the generated code %d", got, msgs.Len())
+	}
+	methods := File_trading_proto.Services().Get(0).Methods()
+	if got := strings.Count(Proto, "  rpc "); got != methods.Len() {
+		t.Errorf("trading.proto has %d methods, the generated code %d", got, methods.Len())
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{service.ErrOrderNotFound, codes.NotFound},
+		{fmt.Errorf("guard: %w", killswitch.ErrEngaged), codes.FailedPrecondition},
+		{&httputil.StatusError{Code: 400, Body: "invalid quantity"}, codes.InvalidArgument},
+		{&httputil.StatusError{Code: 422, Body: "price out of range"}, codes.InvalidArgument},
+		{&httputil.StatusError{Code: 404}, codes.NotFound},
+		{&httputil.StatusError{Code: 403, Body: "insufficient balance"}, codes.FailedPrecondition},
+		{&httputil.StatusError{Code: 429}, codes.ResourceExhausted},
+		{fmt.Errorf("foxbit: %w", &httputil.StatusError{Code: 502}), codes.Unavailable},
+		{errors.New("httputil: request failed: connection refused"), codes.Unavailable},
+		{context.Canceled, codes.Canceled},
+		{status.Error(codes.InvalidArgument, "market is required"), codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		if got := status.Code(toStatus(tt.err)); got != tt.want {
+			t.Errorf("%v: %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+// dial serves s in memory and returns a client of it.
+func dial(t *testing.T, s *Server) TradingServiceClient {
+	t.Helper()
+	l := bufconn.Listen(1 << 20)
+	srv := s.newGRPCServer()
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewTradingServiceClient(conn)
+}
+
+type fakeExchange struct {
+	service.Exchange
+	placed     []model.Order
+	requestIDs []string // of the context of each CreateOrder
+}
+
+func (f *fakeExchange) GetMarkets(ctx context.Context) ([]model.Market, error) {
+	return []model.Market{{
+		Symbol: "BTCBRL", PriceMin: "1", PriceIncrement: "1", QuantityMin: "0.0001",
+		QuantityIncrement: "0.0001", QuantityPrecision: 4,
+		Base: model.Asset{Symbol: "btc"}, Quote: model.Asset{Symbol: "brl"},
+	}}, nil
+}
+
+func (f *fakeExchange) GetOrderBook(ctx context.Context, market string, depth int) (*model.OrderBook, error) {
+	return &model.OrderBook{Bids: [][]string{{"99", "0.5"}}, Asks: [][]string{{"101", "0.2"}}}, nil
+}
+
+func (f *fakeExchange) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
+	f.placed = append(f.placed, o)
+	f.requestIDs = append(f.requestIDs, logging.RequestID(ctx))
+	o.ID, o.State = fmt.Sprint(len(f.placed)), model.StateActive
+	return &o, nil
+}
@@ -0,0 +1,204 @@
+package grpcapi
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+)
+
+// FetchMarkets implements TradingServiceServer.
+func (s *Server) FetchMarkets(ctx context.Context, _ *FetchMarketsRequest) (*FetchMarketsResponse, error) {
+	markets, err := (&usecase.FetchMarkets{Ex: s.Ex}).Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &FetchMarketsResponse{Markets: toMarkets(markets)}, nil
+}
+
+// FetchOrderBook implements TradingServiceServer.
+func (s *Server) FetchOrderBook(ctx context.Context, req *FetchOrderBookRequest) (*OrderBook, error) {
+	depth, err := depthOf(req.Market, req.Depth)
+	if err != nil {
+		return nil, err
+	}
+	ob, err := (&usecase.FetchOrderBook{Ex: s.Ex}).Execute(ctx, req.Market, depth)
+	if err != nil {
+		return nil, err
+	}
+	return toOrderBook(ob), nil
+}
+
+// PlaceOrder implements TradingServiceServer.
+func (s *Server) PlaceOrder(ctx context.Context, req *PlaceOrderRequest) (*Order, error) {
+	o, err := order(req)
+	if err != nil {
+		return nil, err
+	}
+	placed, err := (&usecase.PlaceOrder{Ex: s.Ex}).Execute(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+	return toOrder(placed), nil
+}
+
+// CancelOrder implements TradingServiceServer.
+func (s *Server) CancelOrder(ctx context.Context, req *CancelOrderRequest) (*CancelOrderResponse, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if err := (&usecase.CancelOrder{Ex: s.Ex}).Execute(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	return &CancelOrderResponse{}, nil
+}
+
+// ListActiveOrders implements TradingServiceServer.
+func (s *Server) ListActiveOrders(ctx context.Context, req *ListActiveOrdersRequest) (*ListActiveOrdersResponse, error) {
+	if req.Market == "" {
+		return nil, status.Error(codes.InvalidArgument, "market is required")
+	}
+	orders, err := (&usecase.ListActiveOrders{Ex: s.Ex, Local: s.Local}).Execute(ctx, req.Market)
+	if err != nil {
+		return nil, err
+	}
+	return &ListActiveOrdersResponse{Orders: toOrders(orders)}, nil
+}
+
+// GetOrder implements TradingServiceServer.
+func (s *Server) GetOrder(ctx context.Context, req *GetOrderRequest) (*Order, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	o, err := (&usecase.GetOrder{Ex: s.Ex}).Execute(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return toOrder(o), nil
+}
+
+// WatchOrderBook implements TradingServiceServer.
+func (s *Server) WatchOrderBook(req *WatchOrderBookRequest, ss grpc.ServerStreamingServer[OrderBook]) error {
+	depth, err := depthOf(req.Market, req.Depth)
+	if err != nil {
+		return err
+	}
+	every, err := intervalOf(req.IntervalMs)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := s.streamContext(ss.Context())
+	defer cancel()
+	return (&usecase.WatchOrderBook{Ex: s.Ex, Interval: every}).Execute(ctx, req.Market, depth, func(ob *model.OrderBook) error {
+		return ss.Send(toOrderBook(ob))
+	})
+}
+
+// WatchOrders implements TradingServiceServer.
+func (s *Server) WatchOrders(req *WatchOrdersRequest, ss grpc.ServerStreamingServer[Order]) error {
+	if req.Market == "" {
+		return status.Error(codes.InvalidArgument, "market is required")
+	}
+	every, err := intervalOf(req.IntervalMs)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := s.streamContext(ss.Context())
+	defer cancel()
+	return (&usecase.WatchOrders{Ex: s.Ex, Interval: every}).Execute(ctx, req.Market, func(o model.Order) error {
+		return ss.Send(toOrder(&o))
+	})
+}
+
+// streamContext returns a context cancelled when the client goes away or
+// the server shuts down, whichever comes first.
+func (s *Server) streamContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// order validates the request and turns it into the domain order, with
+// the same rules as the HTTP API.
+func order(req *PlaceOrderRequest) (model.Order, error) {
+	side, typ := strings.ToUpper(req.Side), strings.ToUpper(req.Type)
+	if typ == "" {
+		typ = string(model.Limit)
+	}
+	var problems []string
+	if req.Market == "" {
+		problems = append(problems, "market is required")
+	}
+	if side != string(model.Buy) && side != string(model.Sell) {
+		problems = append(problems, "side must be BUY or SELL")
+	}
+	if !positive(req.Quantity) {
+		problems = append(problems, "quantity must be a positive number")
+	}
+	switch typ {
+	case string(model.Limit):
+		if !positive(req.Price) {
+			problems = append(problems, "price must be a positive number for LIMIT orders")
+		}
+	case string(model.MarketOrder):
+		if req.Price != "" {
+			problems = append(problems, "price is not allowed for MARKET orders")
+		}
+		if req.PostOnly != nil && *req.PostOnly {
+			problems = append(problems, "post_only is not allowed for MARKET orders")
+		}
+	default:
+		problems = append(problems, "type must be LIMIT or MARKET")
+	}
+	if len(problems) > 0 {
+		return model.Order{}, status.Error(codes.InvalidArgument, "invalid order: "+strings.Join(problems, "; "))
+	}
+	return model.Order{
+		MarketSymbol: req.Market,
+		Side:         model.OrderSide(side),
+		Type:         model.OrderType(typ),
+		Price:        req.Price,
+		Quantity:     req.Quantity,
+		PostOnly:     typ == string(model.Limit) && (req.PostOnly == nil || *req.PostOnly),
+	}, nil
+}
+
+func depthOf(market string, depth int32) (int, error) {
+	switch {
+	case market == "":
+		return 0, status.Error(codes.InvalidArgument, "market is required")
+	case depth == 0:
+		return 10, nil
+	case depth < 1 || depth > 500:
+		return 0, status.Error(codes.InvalidArgument, "depth must be from 1 to 500")
+	}
+	return int(depth), nil
+}
+
+func intervalOf(ms int32) (time.Duration, error) {
+	switch {
+	case ms == 0:
+		return time.Second, nil
+	case ms < 200:
+		return 0, status.Error(codes.InvalidArgument, "interval_ms must be at least 200")
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func positive(s string) bool {
+	f, err := strconv.ParseFloat(s, 64)
+	return err == nil && f > 0
+}
@@ -0,0 +1,409 @@
+// The gRPC interface of the trading core, served by `trading-bot serve
+// -grpc-addr`. Generate clients with protoc as usual; the messages mirror
+// the domain model, so prices and quantities are decimal strings.
+//
+// Every call needs the metadata "authorization: Bearer <token>", with a
+// token accepted by `serve`.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: trading.proto
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TradingService_FetchMarkets_FullMethodName     = "/tradingbot.v1.TradingService/FetchMarkets"
+	TradingService_FetchOrderBook_FullMethodName   = "/tradingbot.v1.TradingService/FetchOrderBook"
+	TradingService_PlaceOrder_FullMethodName       = "/tradingbot.v1.TradingService/PlaceOrder"
+	TradingService_CancelOrder_FullMethodName      = "/tradingbot.v1.TradingService/CancelOrder"
+	TradingService_ListActiveOrders_FullMethodName = "/tradingbot.v1.TradingService/ListActiveOrders"
+	TradingService_GetOrder_FullMethodName         = "/tradingbot.v1.TradingService/GetOrder"
+	TradingService_WatchOrderBook_FullMethodName   = "/tradingbot.v1.TradingService/WatchOrderBook"
+	TradingService_WatchOrders_FullMethodName      = "/tradingbot.v1.TradingService/WatchOrders"
+)
+
+// TradingServiceClient is the client API for TradingService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TradingServiceClient interface {
+	FetchMarkets(ctx context.Context, in *FetchMarketsRequest, opts ...grpc.CallOption) (*FetchMarketsResponse, error)
+	FetchOrderBook(ctx context.Context, in *FetchOrderBookRequest, opts ...grpc.CallOption) (*OrderBook, error)
+	PlaceOrder(ctx context.Context, in *PlaceOrderRequest, opts ...grpc.CallOption) (*Order, error)
+	CancelOrder(ctx context.Context, in *CancelOrderRequest, opts ...grpc.CallOption) (*CancelOrderResponse, error)
+	ListActiveOrders(ctx context.Context, in *ListActiveOrdersRequest, opts ...grpc.CallOption) (*ListActiveOrdersResponse, error)
+	GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error)
+	// WatchOrderBook sends the book of a market, then the whole book again
+	// each time it changes.
+	WatchOrderBook(ctx context.Context, in *WatchOrderBookRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderBook], error)
+	// WatchOrders sends the active orders of a market, then every order that
+	// appears or changes, including its final state once it is closed.
+	WatchOrders(ctx context.Context, in *WatchOrdersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Order], error)
+}
+
+type tradingServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTradingServiceClient(cc grpc.ClientConnInterface) TradingServiceClient {
+	return &tradingServiceClient{cc}
+}
+
+func (c *tradingServiceClient) FetchMarkets(ctx context.Context, in *FetchMarketsRequest, opts ...grpc.CallOption) (*FetchMarketsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FetchMarketsResponse)
+	err := c.cc.Invoke(ctx, TradingService_FetchMarkets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradingServiceClient) FetchOrderBook(ctx context.Context, in *FetchOrderBookRequest, opts ...grpc.CallOption) (*OrderBook, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderBook)
+	err := c.cc.Invoke(ctx, TradingService_FetchOrderBook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradingServiceClient) PlaceOrder(ctx context.Context, in *PlaceOrderRequest, opts ...grpc.CallOption) (*Order, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Order)
+	err := c.cc.Invoke(ctx, TradingService_PlaceOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradingServiceClient) CancelOrder(ctx context.Context, in *CancelOrderRequest, opts ...grpc.CallOption) (*CancelOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelOrderResponse)
+	err := c.cc.Invoke(ctx, TradingService_CancelOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradingServiceClient) ListActiveOrders(ctx context.Context, in *ListActiveOrdersRequest, opts ...grpc.CallOption) (*ListActiveOrdersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListActiveOrdersResponse)
+	err := c.cc.Invoke(ctx, TradingService_ListActiveOrders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradingServiceClient) GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Order)
+	err := c.cc.Invoke(ctx, TradingService_GetOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradingServiceClient) WatchOrderBook(ctx context.Context, in *WatchOrderBookRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderBook], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TradingService_ServiceDesc.Streams[0], TradingService_WatchOrderBook_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchOrderBookRequest, OrderBook]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TradingService_WatchOrderBookClient = grpc.ServerStreamingClient[OrderBook]
+
+func (c *tradingServiceClient) WatchOrders(ctx context.Context, in *WatchOrdersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Order], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TradingService_ServiceDesc.Streams[1], TradingService_WatchOrders_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchOrdersRequest, Order]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TradingService_WatchOrdersClient = grpc.ServerStreamingClient[Order]
+
+// TradingServiceServer is the server API for TradingService service.
+// All implementations must embed UnimplementedTradingServiceServer
+// for forward compatibility.
+type TradingServiceServer interface {
+	FetchMarkets(context.Context, *FetchMarketsRequest) (*FetchMarketsResponse, error)
+	FetchOrderBook(context.Context, *FetchOrderBookRequest) (*OrderBook, error)
+	PlaceOrder(context.Context, *PlaceOrderRequest) (*Order, error)
+	CancelOrder(context.Context, *CancelOrderRequest) (*CancelOrderResponse, error)
+	ListActiveOrders(context.Context, *ListActiveOrdersRequest) (*ListActiveOrdersResponse, error)
+	GetOrder(context.Context, *GetOrderRequest) (*Order, error)
+	// WatchOrderBook sends the book of a market, then the whole book again
+	// each time it changes.
+	WatchOrderBook(*WatchOrderBookRequest, grpc.ServerStreamingServer[OrderBook]) error
+	// WatchOrders sends the active orders of a market, then every order that
+	// appears or changes, including its final state once it is closed.
+	WatchOrders(*WatchOrdersRequest, grpc.ServerStreamingServer[Order]) error
+	mustEmbedUnimplementedTradingServiceServer()
+}
+
+// UnimplementedTradingServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTradingServiceServer struct{}
+
+func (UnimplementedTradingServiceServer) FetchMarkets(context.Context, *FetchMarketsRequest) (*FetchMarketsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchMarkets not implemented")
+}
+func (UnimplementedTradingServiceServer) FetchOrderBook(context.Context, *FetchOrderBookRequest) (*OrderBook, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchOrderBook not implemented")
+}
+func (UnimplementedTradingServiceServer) PlaceOrder(context.Context, *PlaceOrderRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PlaceOrder not implemented")
+}
+func (UnimplementedTradingServiceServer) CancelOrder(context.Context, *CancelOrderRequest) (*CancelOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelOrder not implemented")
+}
+func (UnimplementedTradingServiceServer) ListActiveOrders(context.Context, *ListActiveOrdersRequest) (*ListActiveOrdersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListActiveOrders not implemented")
+}
+func (UnimplementedTradingServiceServer) GetOrder(context.Context, *GetOrderRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrder not implemented")
+}
+func (UnimplementedTradingServiceServer) WatchOrderBook(*WatchOrderBookRequest, grpc.ServerStreamingServer[OrderBook]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchOrderBook not implemented")
+}
+func (UnimplementedTradingServiceServer) WatchOrders(*WatchOrdersRequest, grpc.ServerStreamingServer[Order]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchOrders not implemented")
+}
+func (UnimplementedTradingServiceServer) mustEmbedUnimplementedTradingServiceServer() {}
+func (UnimplementedTradingServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeTradingServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TradingServiceServer will
+// result in compilation errors.
+type UnsafeTradingServiceServer interface {
+	mustEmbedUnimplementedTradingServiceServer()
+}
+
+func RegisterTradingServiceServer(s grpc.ServiceRegistrar, srv TradingServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTradingServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TradingService_ServiceDesc, srv)
+}
+
+func _TradingService_FetchMarkets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchMarketsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradingServiceServer).FetchMarkets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradingService_FetchMarkets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradingServiceServer).FetchMarkets(ctx, req.(*FetchMarketsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradingService_FetchOrderBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchOrderBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradingServiceServer).FetchOrderBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradingService_FetchOrderBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradingServiceServer).FetchOrderBook(ctx, req.(*FetchOrderBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradingService_PlaceOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlaceOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradingServiceServer).PlaceOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradingService_PlaceOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradingServiceServer).PlaceOrder(ctx, req.(*PlaceOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradingService_CancelOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradingServiceServer).CancelOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradingService_CancelOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradingServiceServer).CancelOrder(ctx, req.(*CancelOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradingService_ListActiveOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListActiveOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradingServiceServer).ListActiveOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradingService_ListActiveOrders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradingServiceServer).ListActiveOrders(ctx, req.(*ListActiveOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradingService_GetOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradingServiceServer).GetOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradingService_GetOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradingServiceServer).GetOrder(ctx, req.(*GetOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradingService_WatchOrderBook_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchOrderBookRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TradingServiceServer).WatchOrderBook(m, &grpc.GenericServerStream[WatchOrderBookRequest, OrderBook]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TradingService_WatchOrderBookServer = grpc.ServerStreamingServer[OrderBook]
+
+func _TradingService_WatchOrders_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchOrdersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TradingServiceServer).WatchOrders(m, &grpc.GenericServerStream[WatchOrdersRequest, Order]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TradingService_WatchOrdersServer = grpc.ServerStreamingServer[Order]
+
+// TradingService_ServiceDesc is the grpc.ServiceDesc for TradingService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TradingService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "tradingbot.v1.TradingService",
+	HandlerType: (*TradingServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "FetchMarkets",
+			Handler:    _TradingService_FetchMarkets_Handler,
+		},
+		{
+			MethodName: "FetchOrderBook",
+			Handler:    _TradingService_FetchOrderBook_Handler,
+		},
+		{
+			MethodName: "PlaceOrder",
+			Handler:    _TradingService_PlaceOrder_Handler,
+		},
+		{
+			MethodName: "CancelOrder",
+			Handler:    _TradingService_CancelOrder_Handler,
+		},
+		{
+			MethodName: "ListActiveOrders",
+			Handler:    _TradingService_ListActiveOrders_Handler,
+		},
+		{
+			MethodName: "GetOrder",
+			Handler:    _TradingService_GetOrder_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchOrderBook",
+			Handler:       _TradingService_WatchOrderBook_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchOrders",
+			Handler:       _TradingService_WatchOrders_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "trading.proto",
+}
@@ -0,0 +1,96 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"trading-bot/internal/application/killswitch"
+	"trading-bot/internal/application/risk"
+	"trading-bot/internal/domain/service"
+	"trading-bot/internal/infrastructure/httputil"
+)
+
+// Error is an error with the HTTP status it is reported with. Details
+// lists the individual problems of an invalid request.
+type Error struct {
+	Status  int
+	Message string
+	Details []string
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// errorBody is the JSON shape of every error, the same as the exchange's
+// and the CLI's -output json errors.
+type errorBody struct {
+	Error struct {
+		Message string   `json:"message"`
+		Code    int      `json:"code"`
+		Details []string `json:"details"`
+	} `json:"error"`
+}
+
+// statusOf maps the errors of the use cases to an HTTP status. A 4xx of
+// the exchange is the client's to fix and keeps its meaning; anything
+// else unknown comes from the exchange or its decorators, hence Bad
+// Gateway.
+func statusOf(err error) int {
+	var e *Error
+	var v *risk.Violation
+	var mbe *http.MaxBytesError
+	var se *httputil.StatusError
+	switch {
+	case errors.As(err, &e):
+		return e.Status
+	case errors.As(err, &mbe):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrOrderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, killswitch.ErrEngaged):
+		return http.StatusConflict
+	case errors.As(err, &v):
+		return http.StatusUnprocessableEntity
+	case errors.As(err, &se) && se.Code >= 400 && se.Code < 500:
+		return exchangeStatus(se.Code)
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable // the server is shutting down, or the client left
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// exchangeStatus maps a 4xx status of the exchange to the one the API
+// answers with.
+func exchangeStatus(code int) int {
+	switch code {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return http.StatusBadRequest
+	case http.StatusNotFound:
+		return http.StatusNotFound
+	case http.StatusTooManyRequests:
+		return http.StatusTooManyRequests
+	default: // e.g. insufficient balance, or credentials the exchange refuses
+		return http.StatusUnprocessableEntity
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var se *httputil.StatusError
+	if errors.As(err, &se) && se.Code == http.StatusTooManyRequests && se.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(se.RetryAfter.Seconds()))))
+	}
+	var b errorBody
+	b.Error.Code = statusOf(err)
+	b.Error.Message = err.Error()
+	b.Error.Details = []string{}
+	var e *Error
+	if errors.As(err, &e) && e.Details != nil {
+		b.Error.Details = e.Details
+	}
+	writeJSON(w, b.Error.Code, b)
+}
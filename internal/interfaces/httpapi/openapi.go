@@ -0,0 +1,162 @@
+package httpapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// object is a JSON object of the OpenAPI document.
+type object = map[string]any
+
+// OpenAPI returns the OpenAPI 3.0 document of the API, generated from the
+// route table and, for the schemas, from the Go types by reflection.
+func (s *Server) OpenAPI() object {
+	schemas := object{}
+	paths := object{}
+	for _, rt := range s.routes() {
+		op := object{
+			"operationId": rt.OpID,
+			"summary":     rt.Summary,
+			"security":    []object{{"bearer": []string{}}},
+			"responses":   responses(rt, schemas),
+		}
+		if len(rt.Params) > 0 {
+			var params []object
+			for _, p := range rt.Params {
+				params = append(params, object{
+					"name": p.Name, "in": p.In, "required": p.Required,
+					"description": p.Desc, "schema": object{"type": p.Type},
+				})
+			}
+			op["parameters"] = params
+		}
+		if rt.Body != nil {
+			op["requestBody"] = object{
+				"required": true,
+				"content":  object{"application/json": object{"schema": schemaOf(reflect.TypeOf(rt.Body), schemas)}},
+			}
+		}
+		item, _ := paths[rt.path()].(object)
+		if item == nil {
+			item = object{}
+			paths[rt.path()] = item
+		}
+		item[strings.ToLower(rt.Method)] = op
+	}
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":   "trading-bot API",
+			"version": Version,
+		},
+		"paths": paths,
+		"components": object{
+			"schemas": schemas,
+			"securitySchemes": object{
+				"bearer": object{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+func responses(rt route, schemas object) object {
+	errRef := object{"description": "Error", "content": object{
+		"application/json": object{"schema": schemaOf(reflect.TypeOf(errorBody{}), schemas)},
+	}}
+	res := object{"default": errRef}
+	if rt.Result == nil {
+		res["204"] = object{"description": "Done"}
+		return res
+	}
+	res[strconv.Itoa(rt.status())] = object{
+		"description": http.StatusText(rt.status()),
+		"content":     object{"application/json": object{"schema": schemaOf(reflect.TypeOf(rt.Result), schemas)}},
+	}
+	return res
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf returns the schema of t. Named structs are added to schemas
+// once and referenced.
+func schemaOf(t reflect.Type, schemas object) object {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return object{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return object{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case t.Kind() == reflect.Map:
+		return object{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case t.Kind() == reflect.Bool:
+		return object{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return object{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return object{"type": "number"}
+	case t.Kind() != reflect.Struct:
+		return object{"type": "string"}
+	}
+
+	name := schemaName(t)
+	if name != "" {
+		if _, ok := schemas[name]; ok {
+			return object{"$ref": "#/components/schemas/" + name}
+		}
+		schemas[name] = object{} // placeholder against recursive types
+	}
+	props := object{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jn := jsonName(f)
+		if !f.IsExported() || jn == "-" {
+			continue
+		}
+		p := schemaOf(f.Type, schemas)
+		if enum := f.Tag.Get("enum"); enum != "" {
+			p["enum"] = strings.Split(enum, ",")
+		}
+		if d := f.Tag.Get("desc"); d != "" {
+			p["description"] = d
+		}
+		props[jn] = p
+		if f.Tag.Get("required") == "true" {
+			required = append(required, jn)
+		}
+	}
+	s := object{"type": "object", "properties": props}
+	if required != nil {
+		s["required"] = required
+	}
+	if name == "" {
+		return s
+	}
+	schemas[name] = s
+	return object{"$ref": "#/components/schemas/" + name}
+}
+
+// schemaName names the schema of a struct type after the Go type, with
+// the unexported request types capitalised.
+func schemaName(t reflect.Type) string {
+	n := t.Name()
+	if n == "" {
+		return ""
+	}
+	return strings.ToUpper(n[:1]) + n[1:]
+}
+
+// jsonName returns the name of a field in JSON.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
@@ -0,0 +1,238 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/model"
+)
+
+// route is one endpoint. The OpenAPI document is generated from the same
+// table, so it cannot drift from what is served.
+type route struct {
+	Method  string
+	Path    string // under /v1, with {name} path parameters
+	OpID    string
+	Summary string
+	Params  []param
+	Body    any // zero value of the request body type, if any
+	Result  any // zero value of the response type; nil for 204
+	Created bool
+	handle  func(*http.Request) (any, error)
+}
+
+// param is a path or query parameter.
+type param struct {
+	Name     string
+	In       string // "path" or "query"
+	Type     string // "string" or "integer"
+	Required bool
+	Desc     string
+}
+
+func (rt route) path() string { return "/" + Version + rt.Path }
+
+func (rt route) status() int {
+	if rt.Created {
+		return http.StatusCreated
+	}
+	return http.StatusOK
+}
+
+// placeOrderRequest is the body of POST /v1/orders. Only LIMIT and MARKET
+// orders go to the exchange; conditional orders are the trigger engine's.
+type placeOrderRequest struct {
+	Market   string `json:"market" required:"true" desc:"Market symbol, e.g. btcbrl"`
+	Side     string `json:"side" required:"true" enum:"BUY,SELL"`
+	Type     string `json:"type" enum:"LIMIT,MARKET" desc:"Defaults to LIMIT"`
+	Price    string `json:"price" desc:"Required for LIMIT orders, not allowed for MARKET ones"`
+	Quantity string `json:"quantity" required:"true" desc:"In the base currency"`
+	PostOnly *bool  `json:"post_only" desc:"LIMIT orders only; defaults to true, as with the CLI's place-order"`
+}
+
+func (s *Server) routes() []route {
+	return []route{
+		{
+			Method: "GET", Path: "/markets", OpID: "fetchMarkets",
+			Summary: "List the markets and their precision rules",
+			Result:  []model.Market{},
+			handle: func(r *http.Request) (any, error) {
+				return (&usecase.FetchMarkets{Ex: s.Ex}).Execute(r.Context())
+			},
+		},
+		{
+			Method: "GET", Path: "/markets/{market}/orderbook", OpID: "fetchOrderBook",
+			Summary: "Get the order book of a market",
+			Params: []param{
+				{Name: "market", In: "path", Type: "string", Required: true, Desc: "Market symbol, e.g. btcbrl"},
+				{Name: "depth", In: "query", Type: "integer", Desc: "Levels per side, 1 to 500 (default 10)"},
+			},
+			Result: model.OrderBook{},
+			handle: func(r *http.Request) (any, error) {
+				depth, err := intQuery(r, "depth", 10, 1, 500)
+				if err != nil {
+					return nil, err
+				}
+				return (&usecase.FetchOrderBook{Ex: s.Ex}).Execute(r.Context(), r.PathValue("market"), depth)
+			},
+		},
+		{
+			Method: "GET", Path: "/orders", OpID: "listActiveOrders",
+			Summary: "List the active orders of a market, client-side triggers included",
+			Params: []param{
+				{Name: "market", In: "query", Type: "string", Required: true, Desc: "Market symbol"},
+			},
+			Result: []model.Order{},
+			handle: func(r *http.Request) (any, error) {
+				market := r.URL.Query().Get("market")
+				if market == "" {
+					return nil, invalid("the market query parameter is required")
+				}
+				return (&usecase.ListActiveOrders{Ex: s.Ex, Local: s.Local}).Execute(r.Context(), market)
+			},
+		},
+		{
+			Method: "POST", Path: "/orders", OpID: "placeOrder",
+			Summary: "Place a LIMIT or MARKET order",
+			Body:    placeOrderRequest{},
+			Result:  model.Order{},
+			Created: true,
+			handle: func(r *http.Request) (any, error) {
+				var req placeOrderRequest
+				if err := decode(r, &req); err != nil {
+					return nil, err
+				}
+				o, err := req.order()
+				if err != nil {
+					return nil, err
+				}
+				return (&usecase.PlaceOrder{Ex: s.Ex}).Execute(r.Context(), o)
+			},
+		},
+		{
+			Method: "GET", Path: "/orders/{id}", OpID: "getOrder",
+			Summary: "Get an order by ID",
+			Params:  []param{{Name: "id", In: "path", Type: "string", Required: true, Desc: "Order ID"}},
+			Result:  model.Order{},
+			handle: func(r *http.Request) (any, error) {
+				return (&usecase.GetOrder{Ex: s.Ex}).Execute(r.Context(), r.PathValue("id"))
+			},
+		},
+		{
+			Method: "DELETE", Path: "/orders/{id}", OpID: "cancelOrder",
+			Summary: "Cancel an order by ID",
+			Params:  []param{{Name: "id", In: "path", Type: "string", Required: true, Desc: "Order ID"}},
+			handle: func(r *http.Request) (any, error) {
+				return nil, (&usecase.CancelOrder{Ex: s.Ex}).Execute(r.Context(), r.PathValue("id"))
+			},
+		},
+	}
+}
+
+// order validates the request and turns it into the domain order.
+func (req placeOrderRequest) order() (model.Order, error) {
+	req.Side = strings.ToUpper(req.Side)
+	req.Type = strings.ToUpper(req.Type)
+	if req.Type == "" {
+		req.Type = string(model.Limit)
+	}
+	problems := checkTags(req)
+	if !positive(req.Quantity) {
+		problems = append(problems, "quantity must be a positive number")
+	}
+	switch {
+	case req.Type == string(model.Limit) && !positive(req.Price):
+		problems = append(problems, "price must be a positive number for LIMIT orders")
+	case req.Type == string(model.MarketOrder) && req.Price != "":
+		problems = append(problems, "price is not allowed for MARKET orders")
+	case req.Type == string(model.MarketOrder) && req.PostOnly != nil && *req.PostOnly:
+		problems = append(problems, "post_only is not allowed for MARKET orders")
+	}
+	if len(problems) > 0 {
+		return model.Order{}, &Error{Status: http.StatusBadRequest, Message: "invalid order", Details: problems}
+	}
+	return model.Order{
+		MarketSymbol: req.Market,
+		Side:         model.OrderSide(req.Side),
+		Type:         model.OrderType(req.Type),
+		Price:        req.Price,
+		Quantity:     req.Quantity,
+		PostOnly:     req.Type == string(model.Limit) && (req.PostOnly == nil || *req.PostOnly),
+	}, nil
+}
+
+// checkTags enforces the required and enum tags of a request struct, the
+// same tags the OpenAPI document is generated from.
+func checkTags(v any) []string {
+	var problems []string
+	rv := reflect.ValueOf(v)
+	for i := 0; i < rv.NumField(); i++ {
+		f := rv.Type().Field(i)
+		name := jsonName(f)
+		s, ok := rv.Field(i).Interface().(string)
+		if !ok {
+			continue
+		}
+		if s == "" {
+			if f.Tag.Get("required") == "true" {
+				problems = append(problems, name+" is required")
+			}
+			continue
+		}
+		if enum := f.Tag.Get("enum"); enum != "" && !contains(strings.Split(enum, ","), s) {
+			problems = append(problems, fmt.Sprintf("%s must be one of %s", name, enum))
+		}
+	}
+	return problems
+}
+
+// decode reads a single JSON object into dst, rejecting unknown fields.
+func decode(r *http.Request, dst any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		if _, ok := err.(*http.MaxBytesError); ok {
+			return err
+		}
+		return invalid("malformed JSON body: " + err.Error())
+	}
+	if dec.More() {
+		return invalid("the body must hold a single JSON object")
+	}
+	return nil
+}
+
+func intQuery(r *http.Request, name string, def, lo, hi int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < lo || n > hi {
+		return 0, invalid(fmt.Sprintf("%s must be an integer from %d to %d", name, lo, hi))
+	}
+	return n, nil
+}
+
+func invalid(msg string) error {
+	return &Error{Status: http.StatusBadRequest, Message: msg}
+}
+
+func positive(s string) bool {
+	f, err := strconv.ParseFloat(s, 64)
+	return err == nil && f > 0
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+// Package httpapi exposes the use cases as a versioned JSON HTTP API, so
+// other services can trade without shelling out to the CLI.
+package httpapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"trading-bot/internal/application/usecase"
+	"trading-bot/internal/domain/service"
+	"trading-bot/internal/infrastructure/logging"
+)
+
+// Version prefixes every API path. A breaking change gets a new prefix
+// served alongside the old one.
+const Version = "v1"
+
+// maxBody caps the size of request bodies.
+const maxBody = 1 << 20
+
+// Server serves the API. Every /v1 endpoint and /metrics require one of
+// Tokens as a bearer token; the OpenAPI document and the health check do
+// not.
+type Server struct {
+	Ex      service.Exchange
+	Local   usecase.LocalOrders // listed with the active orders when set
+	Tokens  []string
+	Metrics http.Handler // served on /metrics when set
+
+	// ShutdownTimeout bounds how long Run waits for requests in flight
+	// once its context is cancelled. Zero means 10 seconds.
+	ShutdownTimeout time.Duration
+}
+
+// Handler returns the routes of the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for _, rt := range s.routes() {
+		mux.Handle(rt.Method+" "+rt.path(), s.authenticate(s.endpoint(rt)))
+	}
+	mux.HandleFunc("GET /"+Version+"/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.OpenAPI())
+	})
+	if s.Metrics != nil {
+		mux.Handle("GET /metrics", s.authenticate(s.Metrics))
+	}
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, &Error{Status: http.StatusNotFound, Message: "no such endpoint: " + r.Method + " " + r.URL.Path})
+	})
+	return logRequests(mux)
+}
+
+// Run listens on addr until ctx is cancelled, then stops accepting
+// connections and waits for the requests in flight.
+func (s *Server) Run(ctx context.Context, addr string) error {
+	if len(s.Tokens) == 0 {
+		return errors.New("httpapi: no API tokens configured")
+	}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	slog.Info("httpapi: listening", "addr", addr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	timeout := s.ShutdownTimeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	slog.Info("httpapi: shutting down, waiting for requests in flight", "timeout", timeout)
+	sctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return srv.Shutdown(sctx)
+}
+
+// authenticate rejects requests without a known bearer token. Tokens are
+// compared in constant time.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok {
+			for _, t := range s.Tokens {
+				if subtle.ConstantTimeCompare([]byte(got), []byte(t)) == 1 {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="trading-bot"`)
+		writeError(w, &Error{Status: http.StatusUnauthorized, Message: "missing or invalid bearer token"})
+	})
+}
+
+// endpoint adapts a route to an http.Handler, writing its result or error
+// as JSON.
+func (s *Server) endpoint(rt route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+		res, err := rt.handle(r)
+		if err != nil {
+			if statusOf(err) >= http.StatusInternalServerError {
+				slog.WarnContext(r.Context(), "httpapi: request failed", "err", err)
+			}
+			writeError(w, err)
+			return
+		}
+		if res == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if v := reflect.ValueOf(res); v.Kind() == reflect.Slice && v.IsNil() {
+			res = []struct{}{} // [] rather than null
+		}
+		writeJSON(w, rt.status(), res)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// statusRecorder remembers the status code for the access log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// RequestIDHeader carries the ID of a request. A client may choose it,
+// to find its requests in the logs; otherwise one is generated. Either
+// way it is echoed in the response.
+const RequestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// logRequests tags each request with its ID and logs it once answered,
+// with its status and latency.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = logging.NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		r = r.WithContext(logging.WithRequestID(r.Context(), id))
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		slog.InfoContext(r.Context(), "httpapi: request", "method", r.Method, "path", r.URL.Path,
+			"status", rec.status, "duration", time.Since(start), "remote", r.RemoteAddr)
+	})
+}
@@ -0,0 +1,242 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"trading-bot/internal/application/killswitch"
+	"trading-bot/internal/application/risk"
+	"trading-bot/internal/domain/model"
+	"trading-bot/internal/domain/service"
+	"trading-bot/internal/infrastructure/httputil"
+)
+
+const token = "s3cret"
+
+func TestAuthentication(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{name: "missing token", path: "/v1/markets", want: http.StatusUnauthorized},
+		{name: "wrong token", path: "/v1/markets", header: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "wrong scheme", path: "/v1/markets", header: "Basic " + token, want: http.StatusUnauthorized},
+		{name: "right token", path: "/v1/markets", header: "Bearer " + token, want: http.StatusOK},
+		{name: "health check", path: "/healthz", want: http.StatusOK},
+		{name: "OpenAPI document", path: "/v1/openapi.json", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := serve(&fakeExchange{}, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("no WWW-Authenticate challenge")
+			}
+		})
+	}
+}
+
+func TestPlaceOrderValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		want        int
+		wantDetails []string
+		wantOrder   model.Order // sent to the exchange when created
+	}{
+		{
+			name:      "limit order",
+			body:      `{"market":"btcbrl","side":"buy","price":"100","quantity":"0.1"}`,
+			want:      http.StatusCreated,
+			wantOrder: model.Order{MarketSymbol: "btcbrl", Side: model.Buy, Type: model.Limit, Price: "100", Quantity: "0.1", PostOnly: true},
+		},
+		{
+			name:      "market order",
+			body:      `{"market":"btcbrl","side":"SELL","type":"market","quantity":"0.1"}`,
+			want:      http.StatusCreated,
+			wantOrder: model.Order{MarketSymbol: "btcbrl", Side: model.Sell, Type: model.MarketOrder, Quantity: "0.1"},
+		},
+		{
+			name:      "limit order taking liquidity",
+			body:      `{"market":"btcbrl","side":"buy","price":"100","quantity":"0.1","post_only":false}`,
+			want:      http.StatusCreated,
+			wantOrder: model.Order{MarketSymbol: "btcbrl", Side: model.Buy, Type: model.Limit, Price: "100", Quantity: "0.1"},
+		},
+		{
+			name:        "required fields",
+			body:        `{}`,
+			want:        http.StatusBadRequest,
+			wantDetails: []string{"market is required", "side is required", "quantity is required", "quantity must be a positive number", "price must be a positive number for LIMIT orders"},
+		},
+		{
+			name:        "values outside the enums",
+			body:        `{"market":"btcbrl","side":"hold","type":"stop","quantity":"1"}`,
+			want:        http.StatusBadRequest,
+			wantDetails: []string{"side must be one of BUY,SELL", "type must be one of LIMIT,MARKET"},
+		},
+		{
+			name:        "market order with a price",
+			body:        `{"market":"btcbrl","side":"buy","type":"MARKET","price":"100","quantity":"1"}`,
+			want:        http.StatusBadRequest,
+			wantDetails: []string{"price is not allowed for MARKET orders"},
+		},
+		{
+			name:        "post-only market order",
+			body:        `{"market":"btcbrl","side":"buy","type":"MARKET","quantity":"1","post_only":true}`,
+			want:        http.StatusBadRequest,
+			wantDetails: []string{"post_only is not allowed for MARKET orders"},
+		},
+		{
+			name:        "negative quantity",
+			body:        `{"market":"btcbrl","side":"buy","price":"100","quantity":"-1"}`,
+			want:        http.StatusBadRequest,
+			wantDetails: []string{"quantity must be a positive number"},
+		},
+		{name: "unknown field", body: `{"market":"btcbrl","side":"buy","price":"100","quantity":"1","stop":"90"}`, want: http.StatusBadRequest},
+		{name: "malformed JSON", body: `{"market":`, want: http.StatusBadRequest},
+		{name: "two objects", body: `{"market":"btcbrl"} {}`, want: http.StatusBadRequest},
+		{name: "body too large", body: `{"market":"` + strings.Repeat("x", maxBody) + `"}`, want: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := &fakeExchange{}
+			rec := serve(ex, authorized(httptest.NewRequest("POST", "/v1/orders", strings.NewReader(tt.body))))
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusCreated {
+				if len(ex.placed) != 1 || ex.placed[0] != tt.wantOrder {
+					t.Errorf("placed %+v, want %+v", ex.placed, tt.wantOrder)
+				}
+				return
+			}
+			if len(ex.placed) != 0 {
+				t.Errorf("placed %+v, want nothing", ex.placed)
+			}
+			b := decodeError(t, rec)
+			if b.Error.Code != tt.want {
+				t.Errorf("code %d in the body, want %d", b.Error.Code, tt.want)
+			}
+			if tt.wantDetails != nil && !reflect.DeepEqual(b.Error.Details, tt.wantDetails) {
+				t.Errorf("details %q, want %q", b.Error.Details, tt.wantDetails)
+			}
+		})
+	}
+}
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "invalid request", err: invalid("bad"), want: http.StatusBadRequest},
+		{name: "body too large", err: &http.MaxBytesError{Limit: maxBody}, want: http.StatusRequestEntityTooLarge},
+		{name: "unknown order", err: fmt.Errorf("get: %w", service.ErrOrderNotFound), want: http.StatusNotFound},
+		{name: "kill switch", err: killswitch.ErrEngaged, want: http.StatusConflict},
+		{name: "risk limit", err: &risk.Violation{Rule: risk.MaxNotional}, want: http.StatusUnprocessableEntity},
+		{name: "exchange 400", err: &httputil.StatusError{Code: 400}, want: http.StatusBadRequest},
+		{name: "exchange 422", err: &httputil.StatusError{Code: 422}, want: http.StatusBadRequest},
+		{name: "exchange 404", err: &httputil.StatusError{Code: 404}, want: http.StatusNotFound},
+		{name: "exchange 429", err: &httputil.StatusError{Code: 429}, want: http.StatusTooManyRequests},
+		{name: "exchange 403", err: &httputil.StatusError{Code: 403}, want: http.StatusUnprocessableEntity},
+		{name: "exchange 503", err: &httputil.StatusError{Code: 503}, want: http.StatusBadGateway},
+		{name: "cancelled", err: fmt.Errorf("get: %w", context.Canceled), want: http.StatusServiceUnavailable},
+		{name: "timed out", err: context.DeadlineExceeded, want: http.StatusGatewayTimeout},
+		{name: "connection reset", err: errors.New("connection reset by peer"), want: http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusOf(tt.err); got != tt.want {
+				t.Errorf("statusOf(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExchangeErrorsReachTheClient(t *testing.T) {
+	ex := &fakeExchange{err: &httputil.StatusError{Code: http.StatusTooManyRequests, Body: "slow down", RetryAfter: 1500 * time.Millisecond}}
+	body := `{"market":"btcbrl","side":"buy","price":"100","quantity":"0.1"}`
+	rec := serve(ex, authorized(httptest.NewRequest("POST", "/v1/orders", strings.NewReader(body))))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After %q, want 2", got)
+	}
+	if b := decodeError(t, rec); !strings.Contains(b.Error.Message, "slow down") || b.Error.Details == nil {
+		t.Errorf("error body %+v", b)
+	}
+}
+
+func TestEmptyListIsNotNull(t *testing.T) {
+	rec := serve(&fakeExchange{}, authorized(httptest.NewRequest("GET", "/v1/orders?market=btcbrl", nil)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != "[]" {
+		t.Errorf("body %s, want []", got)
+	}
+}
+
+func serve(ex service.Exchange, req *http.Request) *httptest.ResponseRecorder {
+	s := &Server{Ex: ex, Tokens: []string{"other", token}}
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func authorized(req *http.Request) *http.Request {
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) errorBody {
+	t.Helper()
+	var b errorBody
+	if err := json.NewDecoder(rec.Body).Decode(&b); err != nil {
+		t.Fatalf("error body: %v", err)
+	}
+	return b
+}
+
+// fakeExchange has no markets nor active orders, and answers CreateOrder
+// with err when set.
+type fakeExchange struct {
+	service.Exchange
+	err    error
+	placed []model.Order
+}
+
+func (f *fakeExchange) GetMarkets(ctx context.Context) ([]model.Market, error) {
+	return nil, nil
+}
+
+func (f *fakeExchange) GetActiveOrders(ctx context.Context, market string) ([]model.Order, error) {
+	return nil, nil
+}
+
+func (f *fakeExchange) CreateOrder(ctx context.Context, o model.Order) (*model.Order, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.placed = append(f.placed, o)
+	o.ID = "1"
+	return &o, nil
+}
@@ -0,0 +1,170 @@
+// Package webhook receives trading alerts posted by charting tools and
+// hands them to an alert.Receiver.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"trading-bot/internal/application/alert"
+	"trading-bot/internal/infrastructure/logging"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the body, keyed with the
+// shared secret, optionally prefixed "sha256=". Senders that cannot set
+// headers put the secret in the "secret" field of the alert instead.
+const SignatureHeader = "X-Signature"
+
+// TimestampHeader carries when a signed alert was sent, in Unix seconds.
+// With it the signature covers the timestamp, a dot and the body;
+// without it the alert needs a "timestamp" field.
+const TimestampHeader = "X-Timestamp"
+
+// maxBody caps the size of an alert.
+const maxBody = 64 << 10
+
+// Server accepts alerts with a POST to Path.
+type Server struct {
+	Receiver *alert.Receiver
+	Secret   string
+	Path     string // default "/webhook"
+	// MaxAge is how far the timestamp of an alert may be from the clock
+	// of the server, so a captured alert cannot be replayed later; five
+	// minutes when zero.
+	MaxAge time.Duration
+}
+
+// Handler returns the HTTP handler of the webhook.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+s.path(), s.receive)
+	return mux
+}
+
+func (s *Server) path() string {
+	if s.Path == "" {
+		return "/webhook"
+	}
+	return s.Path
+}
+
+// Run listens on addr until ctx is cancelled, then waits for the alerts
+// being handled.
+func (s *Server) Run(ctx context.Context, addr string) error {
+	if s.Secret == "" {
+		return errors.New("webhook: no shared secret configured")
+	}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	slog.Info("webhook: listening", "addr", addr, "path", s.path())
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	sctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return srv.Shutdown(sctx)
+}
+
+func (s *Server) receive(w http.ResponseWriter, r *http.Request) {
+	id := logging.NewRequestID()
+	w.Header().Set("X-Request-ID", id)
+	r = r.WithContext(logging.WithRequestID(r.Context(), id))
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+	if err != nil {
+		reply(w, http.StatusRequestEntityTooLarge, map[string]string{"error": err.Error()})
+		return
+	}
+	sig, ts := r.Header.Get(SignatureHeader), r.Header.Get(TimestampHeader)
+	if sig != "" && !s.signed(sig, ts, body) {
+		s.unauthorized(w, r)
+		return
+	}
+	a, err := alert.Parse(body)
+	if err != nil {
+		slog.WarnContext(r.Context(), "webhook: malformed alert", "remote", r.RemoteAddr, "err", err)
+		reply(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if sig == "" && subtle.ConstantTimeCompare([]byte(a.Secret), []byte(s.Secret)) != 1 {
+		s.unauthorized(w, r)
+		return
+	}
+	// only a timestamp covered by the signature or the secret counts
+	if sig == "" || ts == "" {
+		ts = string(a.Timestamp)
+	}
+	sent, err := alert.ParseTime(ts)
+	if err != nil {
+		slog.WarnContext(r.Context(), "webhook: alert without a valid timestamp", "remote", r.RemoteAddr, "err", err)
+		reply(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if age := time.Since(sent); age > s.maxAge() || age < -s.maxAge() {
+		slog.WarnContext(r.Context(), "webhook: stale alert ignored", "remote", r.RemoteAddr, "sent", sent)
+		reply(w, http.StatusUnauthorized, map[string]string{"error": "stale alert: sent at " + sent.Format(time.RFC3339)})
+		return
+	}
+
+	d := s.Receiver.Handle(r.Context(), a)
+	status := http.StatusOK
+	switch d.Outcome {
+	case alert.Rejected:
+		status = http.StatusUnprocessableEntity
+	case alert.Failed:
+		status = http.StatusBadGateway
+	}
+	reply(w, status, d)
+}
+
+// signed checks the signature of body, preceded by ts and a dot when ts
+// is set, in constant time.
+func (s *Server) signed(sig, ts string, body []byte) bool {
+	got, err := hex.DecodeString(strings.TrimPrefix(sig, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(s.Secret))
+	if ts != "" {
+		mac.Write([]byte(ts + "."))
+	}
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+func (s *Server) maxAge() time.Duration {
+	if s.MaxAge <= 0 {
+		return 5 * time.Minute
+	}
+	return s.MaxAge
+}
+
+func (s *Server) unauthorized(w http.ResponseWriter, r *http.Request) {
+	slog.WarnContext(r.Context(), "webhook: alert with a bad signature or secret ignored", "remote", r.RemoteAddr)
+	reply(w, http.StatusUnauthorized, map[string]string{"error": "bad signature or secret"})
+}
+
+func reply(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"trading-bot/internal/application/alert"
+)
+
+const secret = "s3cret"
+
+func TestReceive(t *testing.T) {
+	now := time.Now()
+	fresh := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+	// ETHBRL is not allowed, so accepted alerts are rejected by the
+	// receiver without reaching an exchange
+	body := func(ts string) string {
+		return fmt.Sprintf(`{"secret": %q, "timestamp": %q, "action": "buy", "market": "ETHBRL", "size": 1}`, secret, ts)
+	}
+	unsecret := `{"action": "buy", "market": "ETHBRL", "size": 1, "timestamp": "` + stale + `"}`
+
+	tests := []struct {
+		name    string
+		body    string
+		headers map[string]string
+		want    int
+	}{
+		{name: "secret field", body: body(fresh), want: http.StatusUnprocessableEntity},
+		{name: "RFC 3339 timestamp", body: body(now.UTC().Format(time.RFC3339)), want: http.StatusUnprocessableEntity},
+		{name: "wrong secret", body: strings.Replace(body(fresh), secret, "guess", 1), want: http.StatusUnauthorized},
+		{name: "no timestamp", body: body(""), want: http.StatusBadRequest},
+		{name: "stale", body: body(stale), want: http.StatusUnauthorized},
+		{name: "from the future", body: body(strconv.FormatInt(now.Add(10*time.Minute).Unix(), 10)), want: http.StatusUnauthorized},
+		{
+			name:    "signed timestamp header",
+			body:    unsecret,
+			headers: map[string]string{TimestampHeader: fresh, SignatureHeader: sign(fresh, unsecret)},
+			want:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:    "tampered timestamp header",
+			body:    unsecret,
+			headers: map[string]string{TimestampHeader: fresh, SignatureHeader: sign(stale, unsecret)},
+			want:    http.StatusUnauthorized,
+		},
+		{
+			// the body timestamp is only covered by the signature
+			name:    "signed body without a header",
+			body:    unsecret,
+			headers: map[string]string{SignatureHeader: "sha256=" + sign("", unsecret)},
+			want:    http.StatusUnauthorized,
+		},
+		{
+			name:    "unsigned timestamp header",
+			body:    strings.Replace(body(stale), "buy", "sell", 1),
+			headers: map[string]string{TimestampHeader: fresh},
+			want:    http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{Receiver: &alert.Receiver{Allowed: []string{"BTCBRL"}}, Secret: secret}
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func sign(ts, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	if ts != "" {
+		mac.Write([]byte(ts + "."))
+	}
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}